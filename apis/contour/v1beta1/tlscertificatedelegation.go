@@ -29,12 +29,18 @@ type CertificateDelegation struct {
 	// required, the name of a secret in the current namespace.
 	SecretName string `json:"secretName"`
 
-	// required, the namespaces the authority to reference the
+	// the namespaces the authority to reference the
 	// the secret will be delegated to.
-	// If TargetNamespaces is nil or empty, the CertificateDelegation'
-	// is ignored. If the TargetNamespace list contains the character, "*"
+	// If TargetNamespaces and TargetNamespaceSelector are both
+	// nil or empty, the CertificateDelegation is ignored.
+	// If the TargetNamespace list contains the character, "*"
 	// the secret will be delegated to all namespaces.
 	TargetNamespaces []string `json:"targetNamespaces"`
+
+	// TargetNamespaceSelector delegates the secret to the namespaces
+	// whose labels match the selector, in addition to TargetNamespaces.
+	// An empty selector matches every namespace.
+	TargetNamespaceSelector *metav1.LabelSelector `json:"targetNamespaceSelector,omitempty"`
 }
 
 // +genclient
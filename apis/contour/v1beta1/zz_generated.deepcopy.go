@@ -1,3 +1,4 @@
+//go:build !ignore_autogenerated
 // +build !ignore_autogenerated
 
 /*
@@ -22,6 +23,7 @@ package v1beta1
 
 import (
 	v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -33,6 +35,11 @@ func (in *CertificateDelegation) DeepCopyInto(out *CertificateDelegation) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TargetNamespaceSelector != nil {
+		in, out := &in.TargetNamespaceSelector, &out.TargetNamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -200,7 +207,7 @@ func (in *Service) DeepCopyInto(out *Service) {
 	if in.HealthCheck != nil {
 		in, out := &in.HealthCheck, &out.HealthCheck
 		*out = new(v1alpha1.HealthCheck)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.UpstreamValidation != nil {
 		in, out := &in.UpstreamValidation, &out.UpstreamValidation
@@ -19,6 +19,8 @@ limitations under the License.
 package fake
 
 import (
+	"context"
+
 	v1beta1 "github.com/projectcontour/contour/apis/contour/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	labels "k8s.io/apimachinery/pkg/labels"
@@ -39,7 +41,7 @@ var ingressroutesResource = schema.GroupVersionResource{Group: "contour.heptio.c
 var ingressroutesKind = schema.GroupVersionKind{Group: "contour.heptio.com", Version: "v1beta1", Kind: "IngressRoute"}
 
 // Get takes name of the ingressRoute, and returns the corresponding ingressRoute object, and an error if there is any.
-func (c *FakeIngressRoutes) Get(name string, options v1.GetOptions) (result *v1beta1.IngressRoute, err error) {
+func (c *FakeIngressRoutes) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.IngressRoute, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewGetAction(ingressroutesResource, c.ns, name), &v1beta1.IngressRoute{})
 
@@ -50,7 +52,7 @@ func (c *FakeIngressRoutes) Get(name string, options v1.GetOptions) (result *v1b
 }
 
 // List takes label and field selectors, and returns the list of IngressRoutes that match those selectors.
-func (c *FakeIngressRoutes) List(opts v1.ListOptions) (result *v1beta1.IngressRouteList, err error) {
+func (c *FakeIngressRoutes) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.IngressRouteList, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewListAction(ingressroutesResource, ingressroutesKind, c.ns, opts), &v1beta1.IngressRouteList{})
 
@@ -72,14 +74,14 @@ func (c *FakeIngressRoutes) List(opts v1.ListOptions) (result *v1beta1.IngressRo
 }
 
 // Watch returns a watch.Interface that watches the requested ingressRoutes.
-func (c *FakeIngressRoutes) Watch(opts v1.ListOptions) (watch.Interface, error) {
+func (c *FakeIngressRoutes) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
 	return c.Fake.
 		InvokesWatch(testing.NewWatchAction(ingressroutesResource, c.ns, opts))
 
 }
 
 // Create takes the representation of a ingressRoute and creates it.  Returns the server's representation of the ingressRoute, and an error, if there is any.
-func (c *FakeIngressRoutes) Create(ingressRoute *v1beta1.IngressRoute) (result *v1beta1.IngressRoute, err error) {
+func (c *FakeIngressRoutes) Create(ctx context.Context, ingressRoute *v1beta1.IngressRoute, opts v1.CreateOptions) (result *v1beta1.IngressRoute, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewCreateAction(ingressroutesResource, c.ns, ingressRoute), &v1beta1.IngressRoute{})
 
@@ -90,7 +92,7 @@ func (c *FakeIngressRoutes) Create(ingressRoute *v1beta1.IngressRoute) (result *
 }
 
 // Update takes the representation of a ingressRoute and updates it. Returns the server's representation of the ingressRoute, and an error, if there is any.
-func (c *FakeIngressRoutes) Update(ingressRoute *v1beta1.IngressRoute) (result *v1beta1.IngressRoute, err error) {
+func (c *FakeIngressRoutes) Update(ctx context.Context, ingressRoute *v1beta1.IngressRoute, opts v1.UpdateOptions) (result *v1beta1.IngressRoute, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewUpdateAction(ingressroutesResource, c.ns, ingressRoute), &v1beta1.IngressRoute{})
 
@@ -102,7 +104,7 @@ func (c *FakeIngressRoutes) Update(ingressRoute *v1beta1.IngressRoute) (result *
 
 // UpdateStatus was generated because the type contains a Status member.
 // Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
-func (c *FakeIngressRoutes) UpdateStatus(ingressRoute *v1beta1.IngressRoute) (*v1beta1.IngressRoute, error) {
+func (c *FakeIngressRoutes) UpdateStatus(ctx context.Context, ingressRoute *v1beta1.IngressRoute, opts v1.UpdateOptions) (*v1beta1.IngressRoute, error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewUpdateSubresourceAction(ingressroutesResource, "status", c.ns, ingressRoute), &v1beta1.IngressRoute{})
 
@@ -113,7 +115,7 @@ func (c *FakeIngressRoutes) UpdateStatus(ingressRoute *v1beta1.IngressRoute) (*v
 }
 
 // Delete takes name of the ingressRoute and deletes it. Returns an error if one occurs.
-func (c *FakeIngressRoutes) Delete(name string, options *v1.DeleteOptions) error {
+func (c *FakeIngressRoutes) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
 		Invokes(testing.NewDeleteAction(ingressroutesResource, c.ns, name), &v1beta1.IngressRoute{})
 
@@ -121,15 +123,15 @@ func (c *FakeIngressRoutes) Delete(name string, options *v1.DeleteOptions) error
 }
 
 // DeleteCollection deletes a collection of objects.
-func (c *FakeIngressRoutes) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
-	action := testing.NewDeleteCollectionAction(ingressroutesResource, c.ns, listOptions)
+func (c *FakeIngressRoutes) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(ingressroutesResource, c.ns, listOpts)
 
 	_, err := c.Fake.Invokes(action, &v1beta1.IngressRouteList{})
 	return err
 }
 
 // Patch applies the patch and returns the patched ingressRoute.
-func (c *FakeIngressRoutes) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta1.IngressRoute, err error) {
+func (c *FakeIngressRoutes) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.IngressRoute, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewPatchSubresourceAction(ingressroutesResource, c.ns, name, pt, data, subresources...), &v1beta1.IngressRoute{})
 
@@ -19,6 +19,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	"context"
 	"time"
 
 	v1beta1 "github.com/projectcontour/contour/apis/contour/v1beta1"
@@ -37,15 +38,15 @@ type IngressRoutesGetter interface {
 
 // IngressRouteInterface has methods to work with IngressRoute resources.
 type IngressRouteInterface interface {
-	Create(*v1beta1.IngressRoute) (*v1beta1.IngressRoute, error)
-	Update(*v1beta1.IngressRoute) (*v1beta1.IngressRoute, error)
-	UpdateStatus(*v1beta1.IngressRoute) (*v1beta1.IngressRoute, error)
-	Delete(name string, options *v1.DeleteOptions) error
-	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
-	Get(name string, options v1.GetOptions) (*v1beta1.IngressRoute, error)
-	List(opts v1.ListOptions) (*v1beta1.IngressRouteList, error)
-	Watch(opts v1.ListOptions) (watch.Interface, error)
-	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta1.IngressRoute, err error)
+	Create(ctx context.Context, ingressRoute *v1beta1.IngressRoute, opts v1.CreateOptions) (*v1beta1.IngressRoute, error)
+	Update(ctx context.Context, ingressRoute *v1beta1.IngressRoute, opts v1.UpdateOptions) (*v1beta1.IngressRoute, error)
+	UpdateStatus(ctx context.Context, ingressRoute *v1beta1.IngressRoute, opts v1.UpdateOptions) (*v1beta1.IngressRoute, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1beta1.IngressRoute, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1beta1.IngressRouteList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.IngressRoute, err error)
 	IngressRouteExpansion
 }
 
@@ -64,20 +65,20 @@ func newIngressRoutes(c *ContourV1beta1Client, namespace string) *ingressRoutes
 }
 
 // Get takes name of the ingressRoute, and returns the corresponding ingressRoute object, and an error if there is any.
-func (c *ingressRoutes) Get(name string, options v1.GetOptions) (result *v1beta1.IngressRoute, err error) {
+func (c *ingressRoutes) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.IngressRoute, err error) {
 	result = &v1beta1.IngressRoute{}
 	err = c.client.Get().
 		Namespace(c.ns).
 		Resource("ingressroutes").
 		Name(name).
 		VersionedParams(&options, scheme.ParameterCodec).
-		Do().
+		Do(ctx).
 		Into(result)
 	return
 }
 
 // List takes label and field selectors, and returns the list of IngressRoutes that match those selectors.
-func (c *ingressRoutes) List(opts v1.ListOptions) (result *v1beta1.IngressRouteList, err error) {
+func (c *ingressRoutes) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.IngressRouteList, err error) {
 	var timeout time.Duration
 	if opts.TimeoutSeconds != nil {
 		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
@@ -88,13 +89,13 @@ func (c *ingressRoutes) List(opts v1.ListOptions) (result *v1beta1.IngressRouteL
 		Resource("ingressroutes").
 		VersionedParams(&opts, scheme.ParameterCodec).
 		Timeout(timeout).
-		Do().
+		Do(ctx).
 		Into(result)
 	return
 }
 
 // Watch returns a watch.Interface that watches the requested ingressRoutes.
-func (c *ingressRoutes) Watch(opts v1.ListOptions) (watch.Interface, error) {
+func (c *ingressRoutes) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
 	var timeout time.Duration
 	if opts.TimeoutSeconds != nil {
 		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
@@ -105,87 +106,90 @@ func (c *ingressRoutes) Watch(opts v1.ListOptions) (watch.Interface, error) {
 		Resource("ingressroutes").
 		VersionedParams(&opts, scheme.ParameterCodec).
 		Timeout(timeout).
-		Watch()
+		Watch(ctx)
 }
 
 // Create takes the representation of a ingressRoute and creates it.  Returns the server's representation of the ingressRoute, and an error, if there is any.
-func (c *ingressRoutes) Create(ingressRoute *v1beta1.IngressRoute) (result *v1beta1.IngressRoute, err error) {
+func (c *ingressRoutes) Create(ctx context.Context, ingressRoute *v1beta1.IngressRoute, opts v1.CreateOptions) (result *v1beta1.IngressRoute, err error) {
 	result = &v1beta1.IngressRoute{}
 	err = c.client.Post().
 		Namespace(c.ns).
 		Resource("ingressroutes").
+		VersionedParams(&opts, scheme.ParameterCodec).
 		Body(ingressRoute).
-		Do().
+		Do(ctx).
 		Into(result)
 	return
 }
 
 // Update takes the representation of a ingressRoute and updates it. Returns the server's representation of the ingressRoute, and an error, if there is any.
-func (c *ingressRoutes) Update(ingressRoute *v1beta1.IngressRoute) (result *v1beta1.IngressRoute, err error) {
+func (c *ingressRoutes) Update(ctx context.Context, ingressRoute *v1beta1.IngressRoute, opts v1.UpdateOptions) (result *v1beta1.IngressRoute, err error) {
 	result = &v1beta1.IngressRoute{}
 	err = c.client.Put().
 		Namespace(c.ns).
 		Resource("ingressroutes").
 		Name(ingressRoute.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
 		Body(ingressRoute).
-		Do().
+		Do(ctx).
 		Into(result)
 	return
 }
 
 // UpdateStatus was generated because the type contains a Status member.
 // Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
-
-func (c *ingressRoutes) UpdateStatus(ingressRoute *v1beta1.IngressRoute) (result *v1beta1.IngressRoute, err error) {
+func (c *ingressRoutes) UpdateStatus(ctx context.Context, ingressRoute *v1beta1.IngressRoute, opts v1.UpdateOptions) (result *v1beta1.IngressRoute, err error) {
 	result = &v1beta1.IngressRoute{}
 	err = c.client.Put().
 		Namespace(c.ns).
 		Resource("ingressroutes").
 		Name(ingressRoute.Name).
 		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
 		Body(ingressRoute).
-		Do().
+		Do(ctx).
 		Into(result)
 	return
 }
 
 // Delete takes name of the ingressRoute and deletes it. Returns an error if one occurs.
-func (c *ingressRoutes) Delete(name string, options *v1.DeleteOptions) error {
+func (c *ingressRoutes) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	return c.client.Delete().
 		Namespace(c.ns).
 		Resource("ingressroutes").
 		Name(name).
-		Body(options).
-		Do().
+		Body(&opts).
+		Do(ctx).
 		Error()
 }
 
 // DeleteCollection deletes a collection of objects.
-func (c *ingressRoutes) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+func (c *ingressRoutes) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
 	var timeout time.Duration
-	if listOptions.TimeoutSeconds != nil {
-		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
 	}
 	return c.client.Delete().
 		Namespace(c.ns).
 		Resource("ingressroutes").
-		VersionedParams(&listOptions, scheme.ParameterCodec).
+		VersionedParams(&listOpts, scheme.ParameterCodec).
 		Timeout(timeout).
-		Body(options).
-		Do().
+		Body(&opts).
+		Do(ctx).
 		Error()
 }
 
 // Patch applies the patch and returns the patched ingressRoute.
-func (c *ingressRoutes) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1beta1.IngressRoute, err error) {
+func (c *ingressRoutes) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.IngressRoute, err error) {
 	result = &v1beta1.IngressRoute{}
 	err = c.client.Patch(pt).
 		Namespace(c.ns).
 		Resource("ingressroutes").
-		SubResource(subresources...).
 		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
 		Body(data).
-		Do().
+		Do(ctx).
 		Into(result)
 	return
 }
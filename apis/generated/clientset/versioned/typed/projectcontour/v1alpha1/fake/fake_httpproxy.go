@@ -19,6 +19,8 @@ limitations under the License.
 package fake
 
 import (
+	"context"
+
 	v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	labels "k8s.io/apimachinery/pkg/labels"
@@ -39,7 +41,7 @@ var httpproxiesResource = schema.GroupVersionResource{Group: "projectcontour.io"
 var httpproxiesKind = schema.GroupVersionKind{Group: "projectcontour.io", Version: "v1alpha1", Kind: "HTTPProxy"}
 
 // Get takes name of the hTTPProxy, and returns the corresponding hTTPProxy object, and an error if there is any.
-func (c *FakeHTTPProxies) Get(name string, options v1.GetOptions) (result *v1alpha1.HTTPProxy, err error) {
+func (c *FakeHTTPProxies) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.HTTPProxy, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewGetAction(httpproxiesResource, c.ns, name), &v1alpha1.HTTPProxy{})
 
@@ -50,7 +52,7 @@ func (c *FakeHTTPProxies) Get(name string, options v1.GetOptions) (result *v1alp
 }
 
 // List takes label and field selectors, and returns the list of HTTPProxies that match those selectors.
-func (c *FakeHTTPProxies) List(opts v1.ListOptions) (result *v1alpha1.HTTPProxyList, err error) {
+func (c *FakeHTTPProxies) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.HTTPProxyList, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewListAction(httpproxiesResource, httpproxiesKind, c.ns, opts), &v1alpha1.HTTPProxyList{})
 
@@ -72,14 +74,14 @@ func (c *FakeHTTPProxies) List(opts v1.ListOptions) (result *v1alpha1.HTTPProxyL
 }
 
 // Watch returns a watch.Interface that watches the requested hTTPProxies.
-func (c *FakeHTTPProxies) Watch(opts v1.ListOptions) (watch.Interface, error) {
+func (c *FakeHTTPProxies) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
 	return c.Fake.
 		InvokesWatch(testing.NewWatchAction(httpproxiesResource, c.ns, opts))
 
 }
 
 // Create takes the representation of a hTTPProxy and creates it.  Returns the server's representation of the hTTPProxy, and an error, if there is any.
-func (c *FakeHTTPProxies) Create(hTTPProxy *v1alpha1.HTTPProxy) (result *v1alpha1.HTTPProxy, err error) {
+func (c *FakeHTTPProxies) Create(ctx context.Context, hTTPProxy *v1alpha1.HTTPProxy, opts v1.CreateOptions) (result *v1alpha1.HTTPProxy, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewCreateAction(httpproxiesResource, c.ns, hTTPProxy), &v1alpha1.HTTPProxy{})
 
@@ -90,7 +92,7 @@ func (c *FakeHTTPProxies) Create(hTTPProxy *v1alpha1.HTTPProxy) (result *v1alpha
 }
 
 // Update takes the representation of a hTTPProxy and updates it. Returns the server's representation of the hTTPProxy, and an error, if there is any.
-func (c *FakeHTTPProxies) Update(hTTPProxy *v1alpha1.HTTPProxy) (result *v1alpha1.HTTPProxy, err error) {
+func (c *FakeHTTPProxies) Update(ctx context.Context, hTTPProxy *v1alpha1.HTTPProxy, opts v1.UpdateOptions) (result *v1alpha1.HTTPProxy, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewUpdateAction(httpproxiesResource, c.ns, hTTPProxy), &v1alpha1.HTTPProxy{})
 
@@ -102,7 +104,7 @@ func (c *FakeHTTPProxies) Update(hTTPProxy *v1alpha1.HTTPProxy) (result *v1alpha
 
 // UpdateStatus was generated because the type contains a Status member.
 // Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
-func (c *FakeHTTPProxies) UpdateStatus(hTTPProxy *v1alpha1.HTTPProxy) (*v1alpha1.HTTPProxy, error) {
+func (c *FakeHTTPProxies) UpdateStatus(ctx context.Context, hTTPProxy *v1alpha1.HTTPProxy, opts v1.UpdateOptions) (*v1alpha1.HTTPProxy, error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewUpdateSubresourceAction(httpproxiesResource, "status", c.ns, hTTPProxy), &v1alpha1.HTTPProxy{})
 
@@ -113,7 +115,7 @@ func (c *FakeHTTPProxies) UpdateStatus(hTTPProxy *v1alpha1.HTTPProxy) (*v1alpha1
 }
 
 // Delete takes name of the hTTPProxy and deletes it. Returns an error if one occurs.
-func (c *FakeHTTPProxies) Delete(name string, options *v1.DeleteOptions) error {
+func (c *FakeHTTPProxies) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
 		Invokes(testing.NewDeleteAction(httpproxiesResource, c.ns, name), &v1alpha1.HTTPProxy{})
 
@@ -121,15 +123,15 @@ func (c *FakeHTTPProxies) Delete(name string, options *v1.DeleteOptions) error {
 }
 
 // DeleteCollection deletes a collection of objects.
-func (c *FakeHTTPProxies) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
-	action := testing.NewDeleteCollectionAction(httpproxiesResource, c.ns, listOptions)
+func (c *FakeHTTPProxies) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(httpproxiesResource, c.ns, listOpts)
 
 	_, err := c.Fake.Invokes(action, &v1alpha1.HTTPProxyList{})
 	return err
 }
 
 // Patch applies the patch and returns the patched hTTPProxy.
-func (c *FakeHTTPProxies) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1alpha1.HTTPProxy, err error) {
+func (c *FakeHTTPProxies) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.HTTPProxy, err error) {
 	obj, err := c.Fake.
 		Invokes(testing.NewPatchSubresourceAction(httpproxiesResource, c.ns, name, pt, data, subresources...), &v1alpha1.HTTPProxy{})
 
@@ -32,6 +32,10 @@ func (c *FakeProjectcontourV1alpha1) HTTPProxies(namespace string) v1alpha1.HTTP
 	return &FakeHTTPProxies{c, namespace}
 }
 
+func (c *FakeProjectcontourV1alpha1) ServiceDelegations(namespace string) v1alpha1.ServiceDelegationInterface {
+	return &FakeServiceDelegations{c, namespace}
+}
+
 func (c *FakeProjectcontourV1alpha1) TLSCertificateDelegations(namespace string) v1alpha1.TLSCertificateDelegationInterface {
 	return &FakeTLSCertificateDelegations{c, namespace}
 }
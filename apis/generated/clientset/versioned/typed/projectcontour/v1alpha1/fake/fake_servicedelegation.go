@@ -0,0 +1,130 @@
+/*
+Copyright 2019 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeServiceDelegations implements ServiceDelegationInterface
+type FakeServiceDelegations struct {
+	Fake *FakeProjectcontourV1alpha1
+	ns   string
+}
+
+var servicedelegationsResource = schema.GroupVersionResource{Group: "projectcontour.io", Version: "v1alpha1", Resource: "servicedelegations"}
+
+var servicedelegationsKind = schema.GroupVersionKind{Group: "projectcontour.io", Version: "v1alpha1", Kind: "ServiceDelegation"}
+
+// Get takes name of the serviceDelegation, and returns the corresponding serviceDelegation object, and an error if there is any.
+func (c *FakeServiceDelegations) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.ServiceDelegation, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(servicedelegationsResource, c.ns, name), &v1alpha1.ServiceDelegation{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ServiceDelegation), err
+}
+
+// List takes label and field selectors, and returns the list of ServiceDelegations that match those selectors.
+func (c *FakeServiceDelegations) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.ServiceDelegationList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(servicedelegationsResource, servicedelegationsKind, c.ns, opts), &v1alpha1.ServiceDelegationList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.ServiceDelegationList{ListMeta: obj.(*v1alpha1.ServiceDelegationList).ListMeta}
+	for _, item := range obj.(*v1alpha1.ServiceDelegationList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested serviceDelegations.
+func (c *FakeServiceDelegations) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(servicedelegationsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a serviceDelegation and creates it.  Returns the server's representation of the serviceDelegation, and an error, if there is any.
+func (c *FakeServiceDelegations) Create(ctx context.Context, serviceDelegation *v1alpha1.ServiceDelegation, opts v1.CreateOptions) (result *v1alpha1.ServiceDelegation, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(servicedelegationsResource, c.ns, serviceDelegation), &v1alpha1.ServiceDelegation{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ServiceDelegation), err
+}
+
+// Update takes the representation of a serviceDelegation and updates it. Returns the server's representation of the serviceDelegation, and an error, if there is any.
+func (c *FakeServiceDelegations) Update(ctx context.Context, serviceDelegation *v1alpha1.ServiceDelegation, opts v1.UpdateOptions) (result *v1alpha1.ServiceDelegation, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(servicedelegationsResource, c.ns, serviceDelegation), &v1alpha1.ServiceDelegation{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ServiceDelegation), err
+}
+
+// Delete takes name of the serviceDelegation and deletes it. Returns an error if one occurs.
+func (c *FakeServiceDelegations) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(servicedelegationsResource, c.ns, name), &v1alpha1.ServiceDelegation{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeServiceDelegations) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(servicedelegationsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.ServiceDelegationList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched serviceDelegation.
+func (c *FakeServiceDelegations) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ServiceDelegation, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(servicedelegationsResource, c.ns, name, pt, data, subresources...), &v1alpha1.ServiceDelegation{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.ServiceDelegation), err
+}
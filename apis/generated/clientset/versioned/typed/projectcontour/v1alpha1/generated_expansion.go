@@ -20,4 +20,6 @@ package v1alpha1
 
 type HTTPProxyExpansion interface{}
 
+type ServiceDelegationExpansion interface{}
+
 type TLSCertificateDelegationExpansion interface{}
@@ -21,13 +21,13 @@ package v1alpha1
 import (
 	"github.com/projectcontour/contour/apis/generated/clientset/versioned/scheme"
 	v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
-	serializer "k8s.io/apimachinery/pkg/runtime/serializer"
 	rest "k8s.io/client-go/rest"
 )
 
 type ProjectcontourV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	HTTPProxiesGetter
+	ServiceDelegationsGetter
 	TLSCertificateDelegationsGetter
 }
 
@@ -40,6 +40,10 @@ func (c *ProjectcontourV1alpha1Client) HTTPProxies(namespace string) HTTPProxyIn
 	return newHTTPProxies(c, namespace)
 }
 
+func (c *ProjectcontourV1alpha1Client) ServiceDelegations(namespace string) ServiceDelegationInterface {
+	return newServiceDelegations(c, namespace)
+}
+
 func (c *ProjectcontourV1alpha1Client) TLSCertificateDelegations(namespace string) TLSCertificateDelegationInterface {
 	return newTLSCertificateDelegations(c, namespace)
 }
@@ -76,7 +80,7 @@ func setConfigDefaults(config *rest.Config) error {
 	gv := v1alpha1.SchemeGroupVersion
 	config.GroupVersion = &gv
 	config.APIPath = "/apis"
-	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: scheme.Codecs}
+	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
 
 	if config.UserAgent == "" {
 		config.UserAgent = rest.DefaultKubernetesUserAgent()
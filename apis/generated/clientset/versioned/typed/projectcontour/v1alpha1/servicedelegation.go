@@ -0,0 +1,178 @@
+/*
+Copyright 2019 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	scheme "github.com/projectcontour/contour/apis/generated/clientset/versioned/scheme"
+	v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ServiceDelegationsGetter has a method to return a ServiceDelegationInterface.
+// A group's client should implement this interface.
+type ServiceDelegationsGetter interface {
+	ServiceDelegations(namespace string) ServiceDelegationInterface
+}
+
+// ServiceDelegationInterface has methods to work with ServiceDelegation resources.
+type ServiceDelegationInterface interface {
+	Create(ctx context.Context, serviceDelegation *v1alpha1.ServiceDelegation, opts v1.CreateOptions) (*v1alpha1.ServiceDelegation, error)
+	Update(ctx context.Context, serviceDelegation *v1alpha1.ServiceDelegation, opts v1.UpdateOptions) (*v1alpha1.ServiceDelegation, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.ServiceDelegation, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.ServiceDelegationList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ServiceDelegation, err error)
+	ServiceDelegationExpansion
+}
+
+// serviceDelegations implements ServiceDelegationInterface
+type serviceDelegations struct {
+	client rest.Interface
+	ns     string
+}
+
+// newServiceDelegations returns a ServiceDelegations
+func newServiceDelegations(c *ProjectcontourV1alpha1Client, namespace string) *serviceDelegations {
+	return &serviceDelegations{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the serviceDelegation, and returns the corresponding serviceDelegation object, and an error if there is any.
+func (c *serviceDelegations) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.ServiceDelegation, err error) {
+	result = &v1alpha1.ServiceDelegation{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("servicedelegations").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of ServiceDelegations that match those selectors.
+func (c *serviceDelegations) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.ServiceDelegationList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.ServiceDelegationList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("servicedelegations").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested serviceDelegations.
+func (c *serviceDelegations) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("servicedelegations").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a serviceDelegation and creates it.  Returns the server's representation of the serviceDelegation, and an error, if there is any.
+func (c *serviceDelegations) Create(ctx context.Context, serviceDelegation *v1alpha1.ServiceDelegation, opts v1.CreateOptions) (result *v1alpha1.ServiceDelegation, err error) {
+	result = &v1alpha1.ServiceDelegation{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("servicedelegations").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(serviceDelegation).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a serviceDelegation and updates it. Returns the server's representation of the serviceDelegation, and an error, if there is any.
+func (c *serviceDelegations) Update(ctx context.Context, serviceDelegation *v1alpha1.ServiceDelegation, opts v1.UpdateOptions) (result *v1alpha1.ServiceDelegation, err error) {
+	result = &v1alpha1.ServiceDelegation{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("servicedelegations").
+		Name(serviceDelegation.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(serviceDelegation).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the serviceDelegation and deletes it. Returns an error if one occurs.
+func (c *serviceDelegations) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("servicedelegations").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *serviceDelegations) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("servicedelegations").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched serviceDelegation.
+func (c *serviceDelegations) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.ServiceDelegation, err error) {
+	result = &v1alpha1.ServiceDelegation{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("servicedelegations").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
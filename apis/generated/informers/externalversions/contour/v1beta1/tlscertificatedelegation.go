@@ -19,6 +19,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	"context"
 	time "time"
 
 	contourv1beta1 "github.com/projectcontour/contour/apis/contour/v1beta1"
@@ -61,13 +62,13 @@ func NewFilteredTLSCertificateDelegationInformer(client versioned.Interface, nam
 				if tweakListOptions != nil {
 					tweakListOptions(&options)
 				}
-				return client.ContourV1beta1().TLSCertificateDelegations(namespace).List(options)
+				return client.ContourV1beta1().TLSCertificateDelegations(namespace).List(context.TODO(), options)
 			},
 			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
 				if tweakListOptions != nil {
 					tweakListOptions(&options)
 				}
-				return client.ContourV1beta1().TLSCertificateDelegations(namespace).Watch(options)
+				return client.ContourV1beta1().TLSCertificateDelegations(namespace).Watch(context.TODO(), options)
 			},
 		},
 		&contourv1beta1.TLSCertificateDelegation{},
@@ -62,6 +62,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		// Group=projectcontour.io, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("httpproxies"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Projectcontour().V1alpha1().HTTPProxies().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("servicedelegations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Projectcontour().V1alpha1().ServiceDelegations().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("tlscertificatedelegations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Projectcontour().V1alpha1().TLSCertificateDelegations().Informer()}, nil
 
@@ -26,6 +26,8 @@ import (
 type Interface interface {
 	// HTTPProxies returns a HTTPProxyInformer.
 	HTTPProxies() HTTPProxyInformer
+	// ServiceDelegations returns a ServiceDelegationInformer.
+	ServiceDelegations() ServiceDelegationInformer
 	// TLSCertificateDelegations returns a TLSCertificateDelegationInformer.
 	TLSCertificateDelegations() TLSCertificateDelegationInformer
 }
@@ -46,6 +48,11 @@ func (v *version) HTTPProxies() HTTPProxyInformer {
 	return &hTTPProxyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ServiceDelegations returns a ServiceDelegationInformer.
+func (v *version) ServiceDelegations() ServiceDelegationInformer {
+	return &serviceDelegationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // TLSCertificateDelegations returns a TLSCertificateDelegationInformer.
 func (v *version) TLSCertificateDelegations() TLSCertificateDelegationInformer {
 	return &tLSCertificateDelegationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -0,0 +1,90 @@
+/*
+Copyright 2019 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	versioned "github.com/projectcontour/contour/apis/generated/clientset/versioned"
+	internalinterfaces "github.com/projectcontour/contour/apis/generated/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/projectcontour/contour/apis/generated/listers/projectcontour/v1alpha1"
+	projectcontourv1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ServiceDelegationInformer provides access to a shared informer and lister for
+// ServiceDelegations.
+type ServiceDelegationInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.ServiceDelegationLister
+}
+
+type serviceDelegationInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewServiceDelegationInformer constructs a new informer for ServiceDelegation type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewServiceDelegationInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredServiceDelegationInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredServiceDelegationInformer constructs a new informer for ServiceDelegation type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredServiceDelegationInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.ProjectcontourV1alpha1().ServiceDelegations(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.ProjectcontourV1alpha1().ServiceDelegations(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&projectcontourv1alpha1.ServiceDelegation{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *serviceDelegationInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredServiceDelegationInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *serviceDelegationInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&projectcontourv1alpha1.ServiceDelegation{}, f.defaultInformer)
+}
+
+func (f *serviceDelegationInformer) Lister() v1alpha1.ServiceDelegationLister {
+	return v1alpha1.NewServiceDelegationLister(f.Informer().GetIndexer())
+}
@@ -19,6 +19,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	time "time"
 
 	versioned "github.com/projectcontour/contour/apis/generated/clientset/versioned"
@@ -61,13 +62,13 @@ func NewFilteredTLSCertificateDelegationInformer(client versioned.Interface, nam
 				if tweakListOptions != nil {
 					tweakListOptions(&options)
 				}
-				return client.ProjectcontourV1alpha1().TLSCertificateDelegations(namespace).List(options)
+				return client.ProjectcontourV1alpha1().TLSCertificateDelegations(namespace).List(context.TODO(), options)
 			},
 			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
 				if tweakListOptions != nil {
 					tweakListOptions(&options)
 				}
-				return client.ProjectcontourV1alpha1().TLSCertificateDelegations(namespace).Watch(options)
+				return client.ProjectcontourV1alpha1().TLSCertificateDelegations(namespace).Watch(context.TODO(), options)
 			},
 		},
 		&projectcontourv1alpha1.TLSCertificateDelegation{},
@@ -26,6 +26,14 @@ type HTTPProxyListerExpansion interface{}
 // HTTPProxyNamespaceLister.
 type HTTPProxyNamespaceListerExpansion interface{}
 
+// ServiceDelegationListerExpansion allows custom methods to be added to
+// ServiceDelegationLister.
+type ServiceDelegationListerExpansion interface{}
+
+// ServiceDelegationNamespaceListerExpansion allows custom methods to be added to
+// ServiceDelegationNamespaceLister.
+type ServiceDelegationNamespaceListerExpansion interface{}
+
 // TLSCertificateDelegationListerExpansion allows custom methods to be added to
 // TLSCertificateDelegationLister.
 type TLSCertificateDelegationListerExpansion interface{}
@@ -0,0 +1,99 @@
+/*
+Copyright 2019 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ServiceDelegationLister helps list ServiceDelegations.
+// All objects returned here must be treated as read-only.
+type ServiceDelegationLister interface {
+	// List lists all ServiceDelegations in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.ServiceDelegation, err error)
+	// ServiceDelegations returns an object that can list and get ServiceDelegations.
+	ServiceDelegations(namespace string) ServiceDelegationNamespaceLister
+	ServiceDelegationListerExpansion
+}
+
+// serviceDelegationLister implements the ServiceDelegationLister interface.
+type serviceDelegationLister struct {
+	indexer cache.Indexer
+}
+
+// NewServiceDelegationLister returns a new ServiceDelegationLister.
+func NewServiceDelegationLister(indexer cache.Indexer) ServiceDelegationLister {
+	return &serviceDelegationLister{indexer: indexer}
+}
+
+// List lists all ServiceDelegations in the indexer.
+func (s *serviceDelegationLister) List(selector labels.Selector) (ret []*v1alpha1.ServiceDelegation, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ServiceDelegation))
+	})
+	return ret, err
+}
+
+// ServiceDelegations returns an object that can list and get ServiceDelegations.
+func (s *serviceDelegationLister) ServiceDelegations(namespace string) ServiceDelegationNamespaceLister {
+	return serviceDelegationNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// ServiceDelegationNamespaceLister helps list and get ServiceDelegations.
+// All objects returned here must be treated as read-only.
+type ServiceDelegationNamespaceLister interface {
+	// List lists all ServiceDelegations in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.ServiceDelegation, err error)
+	// Get retrieves the ServiceDelegation from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.ServiceDelegation, error)
+	ServiceDelegationNamespaceListerExpansion
+}
+
+// serviceDelegationNamespaceLister implements the ServiceDelegationNamespaceLister
+// interface.
+type serviceDelegationNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all ServiceDelegations in the indexer for a given namespace.
+func (s serviceDelegationNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.ServiceDelegation, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ServiceDelegation))
+	})
+	return ret, err
+}
+
+// Get retrieves the ServiceDelegation from the indexer for a given namespace and name.
+func (s serviceDelegationNamespaceLister) Get(name string) (*v1alpha1.ServiceDelegation, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("servicedelegation"), name)
+	}
+	return obj.(*v1alpha1.ServiceDelegation), nil
+}
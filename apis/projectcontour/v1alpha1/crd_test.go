@@ -0,0 +1,361 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	structuraldefaulting "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// crdManifest is the file hack/generate-crd-yaml.sh writes the
+// CRDs generated from the types of this package to.
+const crdManifest = "../../../examples/contour/01-crds.yaml"
+
+// loadCRDs returns the CRDs in crdManifest, keyed by kind.
+func loadCRDs(t *testing.T) map[string]*apiextensionsv1.CustomResourceDefinition {
+	t.Helper()
+	f, err := os.Open(crdManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	crds := make(map[string]*apiextensionsv1.CustomResourceDefinition)
+	dec := utilyaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		var crd apiextensionsv1.CustomResourceDefinition
+		err := dec.Decode(&crd)
+		if err == io.EOF {
+			return crds
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if crd.Kind == "" {
+			// empty document.
+			continue
+		}
+		crds[crd.Spec.Names.Kind] = &crd
+	}
+}
+
+// schemaOf returns the internal representation of the
+// schema of kind's CRD, which must have a single version.
+func schemaOf(t *testing.T, kind string) *apiextensions.CustomResourceValidation {
+	t.Helper()
+	crd, ok := loadCRDs(t)[kind]
+	if !ok {
+		t.Fatalf("%s: no CRD for %s", crdManifest, kind)
+	}
+	if crd.Spec.Group != GroupName {
+		t.Fatalf("%s: expected group %q, got %q", kind, GroupName, crd.Spec.Group)
+	}
+	if len(crd.Spec.Versions) != 1 || crd.Spec.Versions[0].Name != SchemeGroupVersion.Version {
+		t.Fatalf("%s: expected the single version %q", kind, SchemeGroupVersion.Version)
+	}
+	if crd.Spec.Versions[0].Subresources != nil && crd.Spec.Versions[0].Subresources.Status != nil {
+		// contour writes the status with the main resource.
+		t.Fatalf("%s: unexpected status subresource", kind)
+	}
+
+	var v apiextensions.CustomResourceValidation
+	if err := apiextensionsv1.Convert_v1_CustomResourceValidation_To_apiextensions_CustomResourceValidation(crd.Spec.Versions[0].Schema, &v, nil); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func TestCRDsMatchTypes(t *testing.T) {
+	tests := map[string]interface{}{
+		"HTTPProxy":                HTTPProxy{},
+		"TLSCertificateDelegation": TLSCertificateDelegation{},
+		"ServiceDelegation":        ServiceDelegation{},
+	}
+
+	for kind, obj := range tests {
+		t.Run(kind, func(t *testing.T) {
+			v := schemaOf(t, kind)
+			for _, err := range compareSchema(kind, v.OpenAPIV3Schema, reflect.TypeOf(obj)) {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCRDsAreStructural(t *testing.T) {
+	for _, kind := range []string{"HTTPProxy", "TLSCertificateDelegation", "ServiceDelegation"} {
+		t.Run(kind, func(t *testing.T) {
+			v := schemaOf(t, kind)
+			s, err := structuralschema.NewStructural(v.OpenAPIV3Schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if errs := structuralschema.ValidateStructural(nil, s); len(errs) > 0 {
+				t.Fatal(errs.ToAggregate())
+			}
+			errs, err := structuraldefaulting.ValidateDefaults(nil, s, true, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(errs) > 0 {
+				t.Fatal(errs.ToAggregate())
+			}
+		})
+	}
+}
+
+func TestHTTPProxyValidation(t *testing.T) {
+	v := schemaOf(t, "HTTPProxy")
+	s, err := structuralschema.NewStructural(v.OpenAPIV3Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator, _, err := validation.NewSchemaValidator(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// admit returns proxy as the API server would store it,
+	// defaulted, or the errors of its validation.
+	admit := func(proxy *HTTPProxy) (*HTTPProxy, []string) {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(proxy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		structuraldefaulting.PruneNonNullableNullsWithoutDefaults(u, s)
+		structuraldefaulting.Default(u, s)
+		if errs := validation.ValidateCustomResource(nil, u, validator); len(errs) > 0 {
+			var msgs []string
+			for _, err := range errs {
+				msgs = append(msgs, err.Error())
+			}
+			// the order of the errors follows the iteration
+			// of the unstructured object's maps.
+			sort.Strings(msgs)
+			return nil, msgs
+		}
+		var admitted HTTPProxy
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, &admitted); err != nil {
+			t.Fatal(err)
+		}
+		return &admitted, nil
+	}
+
+	proxy := func(vhost *VirtualHost, routes ...Route) *HTTPProxy {
+		return &HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example",
+				Namespace: "default",
+			},
+			Spec: HTTPProxySpec{
+				VirtualHost: vhost,
+				Routes:      routes,
+			},
+		}
+	}
+
+	route := func(services ...Service) Route {
+		return Route{Services: services}
+	}
+
+	tests := map[string]struct {
+		proxy    *HTTPProxy
+		want     *HTTPProxy
+		wantErrs []string
+	}{
+		"minimal": {
+			proxy: proxy(&VirtualHost{Fqdn: "example.com"}, route(Service{Name: "kuard", Port: 80})),
+			want:  proxy(&VirtualHost{Fqdn: "example.com"}, route(Service{Name: "kuard", Port: 80})),
+		},
+		"includes without routes": {
+			proxy: &HTTPProxy{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: HTTPProxySpec{
+					VirtualHost: &VirtualHost{Fqdn: "example.com"},
+					Includes:    []Include{{Name: "child"}},
+				},
+			},
+			want: &HTTPProxy{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: HTTPProxySpec{
+					VirtualHost: &VirtualHost{Fqdn: "example.com"},
+					Includes:    []Include{{Name: "child"}},
+				},
+			},
+		},
+		"minimum protocol version defaulted": {
+			proxy: proxy(&VirtualHost{Fqdn: "example.com", TLS: &TLS{SecretName: "secret"}}),
+			want:  proxy(&VirtualHost{Fqdn: "example.com", TLS: &TLS{SecretName: "secret", MinimumProtocolVersion: "1.1"}}),
+		},
+		"health check type defaulted": {
+			proxy: proxy(nil, route(Service{Name: "kuard", Port: 80, HealthCheck: &HealthCheck{Path: "/healthz"}})),
+			want:  proxy(nil, route(Service{Name: "kuard", Port: 80, HealthCheck: &HealthCheck{Type: "http", Path: "/healthz"}})),
+		},
+		"expected status out of range": {
+			proxy: proxy(nil, route(Service{Name: "kuard", Port: 80, HealthCheck: &HealthCheck{
+				Path:             "/healthz",
+				ExpectedStatuses: []StatusRange{{Start: 99, End: 601}},
+			}})),
+			wantErrs: []string{
+				"spec.routes.services.healthCheck.expectedStatuses.end: Invalid value: 601: spec.routes.services.healthCheck.expectedStatuses.end in body should be less than or equal to 600",
+				"spec.routes.services.healthCheck.expectedStatuses.start: Invalid value: 99: spec.routes.services.healthCheck.expectedStatuses.start in body should be greater than or equal to 100",
+			},
+		},
+		"invalid minimum protocol version": {
+			proxy:    proxy(&VirtualHost{Fqdn: "example.com", TLS: &TLS{MinimumProtocolVersion: "1.0"}}),
+			wantErrs: []string{`spec.virtualhost.tls.minimumProtocolVersion: Unsupported value: "1.0": supported values: "1.1", "1.2", "1.3"`},
+		},
+		"invalid strategy": {
+			proxy:    proxy(nil, route(Service{Name: "kuard", Port: 80, Strategy: "LeastConnections"})),
+			wantErrs: []string{`spec.routes.services.strategy: Unsupported value: "LeastConnections": supported values: "RoundRobin", "WeightedLeastRequest", "Random", "Cookie"`},
+		},
+		"invalid protocol": {
+			proxy:    proxy(nil, route(Service{Name: "kuard", Port: 80, Protocol: "h3"})),
+			wantErrs: []string{`spec.routes.services.protocol: Unsupported value: "h3": supported values: "h2", "h2c", "tls", "auto"`},
+		},
+		"invalid health check type": {
+			proxy:    proxy(nil, route(Service{Name: "kuard", Port: 80, HealthCheck: &HealthCheck{Type: "udp"}})),
+			wantErrs: []string{`spec.routes.services.healthCheck.type: Unsupported value: "udp": supported values: "http", "tcp", "grpc"`},
+		},
+		"port out of range": {
+			proxy: proxy(nil,
+				route(Service{Name: "kuard", Port: 0}),
+				route(Service{Name: "kuard", Port: 65536}),
+			),
+			wantErrs: []string{
+				"spec.routes.services.port: Invalid value: 0: spec.routes.services.port in body should be greater than or equal to 1",
+				"spec.routes.services.port: Invalid value: 65536: spec.routes.services.port in body should be less than or equal to 65535",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, errs := admit(tc.proxy)
+			if diff := cmp.Diff(tc.wantErrs, errs); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+// compareSchema returns the differences between the properties
+// of schema and the JSON representation of typ, found at path.
+func compareSchema(path string, schema *apiextensions.JSONSchemaProps, typ reflect.Type) []string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if schema == nil {
+		return []string{path + ": missing from the CRD schema"}
+	}
+
+	expect := func(want string) []string {
+		if schema.Type != want {
+			return []string{path + ": expected type " + want + ", got " + schema.Type}
+		}
+		return nil
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return expect("string")
+	case reflect.Bool:
+		return expect("boolean")
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint32, reflect.Uint64:
+		return expect("integer")
+	case reflect.Slice:
+		if errs := expect("array"); errs != nil {
+			return errs
+		}
+		if schema.Items == nil {
+			return []string{path + ": missing items schema"}
+		}
+		return compareSchema(path+"[]", schema.Items.Schema, typ.Elem())
+	case reflect.Map:
+		if errs := expect("object"); errs != nil {
+			return errs
+		}
+		if schema.AdditionalProperties == nil {
+			return []string{path + ": missing additionalProperties schema"}
+		}
+		return compareSchema(path+"{}", schema.AdditionalProperties.Schema, typ.Elem())
+	case reflect.Struct:
+		if errs := expect("object"); errs != nil {
+			return errs
+		}
+		if typ == reflect.TypeOf(metav1.ObjectMeta{}) {
+			// the API server validates metadata itself.
+			return nil
+		}
+		var errs []string
+		fields := jsonFields(typ)
+		for name, ftyp := range fields {
+			prop, ok := schema.Properties[name]
+			if !ok {
+				errs = append(errs, path+"."+name+": missing from the CRD schema")
+				continue
+			}
+			errs = append(errs, compareSchema(path+"."+name, &prop, ftyp)...)
+		}
+		for name := range schema.Properties {
+			if _, ok := fields[name]; !ok {
+				errs = append(errs, path+"."+name+": not a field of "+typ.String())
+			}
+		}
+		return errs
+	default:
+		return []string{path + ": unexpected kind " + typ.Kind().String()}
+	}
+}
+
+// jsonFields returns the types of the fields of the struct typ,
+// keyed by their JSON names. The fields of inlined structs are
+// included.
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch {
+		case name == "-":
+			continue
+		case name == "" && f.Anonymous:
+			for n, t := range jsonFields(f.Type) {
+				fields[n] = t
+			}
+		case name == "":
+			fields[f.Name] = f.Type
+		default:
+			fields[name] = f.Type
+		}
+	}
+	return fields
+}
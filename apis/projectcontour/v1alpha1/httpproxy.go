@@ -23,11 +23,15 @@ type HTTPProxySpec struct {
 	// to be a "root".
 	VirtualHost *VirtualHost `json:"virtualhost,omitempty"`
 	// Routes are the ingress routes. If TCPProxy is present, Routes is ignored.
+	// +optional
 	Routes []Route `json:"routes"`
 	// TCPProxy holds TCP proxy information.
 	TCPProxy *TCPProxy `json:"tcpproxy,omitempty"`
 	// Includes allow for specific routing configuration to be appended to another HTTPProxy in another namespace.
 	Includes []Include `json:"includes,omitempty"`
+	// Fleet selects the fleet of Envoy nodes this HTTPProxy is served to.
+	// If empty, the HTTPProxy is served to every Envoy. Only valid on a root HTTPProxy.
+	Fleet string `json:"fleet,omitempty"`
 }
 
 // Include describes a set of policies that can be applied to an HTTPProxy in a namespace.
@@ -37,6 +41,7 @@ type Include struct {
 	// Namespace of the HTTPProxy
 	Namespace string `json:"namespace,omitempty"`
 	// Condition is a set of routing properies that is applied to an HTTPProxy in a namespace.
+	// +optional
 	Condition `json:"conditions"`
 }
 
@@ -69,6 +74,8 @@ type TLS struct {
 	// required, the name of a secret in the current namespace
 	SecretName string `json:"secretName,omitempty"`
 	// Minimum TLS version this vhost should negotiate
+	// +kubebuilder:validation:Enum="1.1";"1.2";"1.3"
+	// +kubebuilder:default="1.1"
 	MinimumProtocolVersion string `json:"minimumProtocolVersion,omitempty"`
 	// If Passthrough is set to true, the SecretName will be ignored
 	// and the encrypted handshake will be passed through to the
@@ -108,40 +115,188 @@ type Service struct {
 	// Name is the name of Kubernetes service to proxy traffic.
 	// Names defined here will be used to look up corresponding endpoints which contain the ips to route.
 	Name string `json:"name"`
+	// Namespace is the namespace of the Kubernetes service. If empty, it
+	// defaults to the namespace of the HTTPProxy. A service in another
+	// namespace may only be referenced if a ServiceDelegation in that
+	// namespace delegates it to the namespace of the HTTPProxy.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 	// Port (defined as Integer) to proxy traffic to since a service can have multiple defined.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
 	Port int `json:"port"`
 	// Weight defines percentage of traffic to balance traffic
 	Weight uint32 `json:"weight,omitempty"`
 	// HealthCheck defines optional healthchecks on the upstream service
 	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+	// OutlierDetection defines optional passive health checking
+	// of the upstream service's endpoints.
+	OutlierDetection *OutlierDetection `json:"outlierDetection,omitempty"`
+	// CircuitBreakers defines optional limits on the connections and
+	// requests Envoy makes to the upstream service from this route.
+	CircuitBreakers *CircuitBreakers `json:"circuitBreakers,omitempty"`
 	// LB Algorithm to apply.
+	// +kubebuilder:validation:Enum=RoundRobin;WeightedLeastRequest;Random;Cookie
 	Strategy string `json:"strategy,omitempty"`
 	// UpstreamValidation defines how to verify the backend service's certificate
 	UpstreamValidation *UpstreamValidation `json:"validation,omitempty"`
+	// Protocol is the protocol Envoy uses to connect to the upstream service,
+	// one of "h2", "h2c", "tls", or "auto". If left empty (default value), the
+	// protocol is taken from the contour.heptio.com/upstream-protocol.*
+	// annotations of the Kubernetes service.
+	// +kubebuilder:validation:Enum=h2;h2c;tls;auto
+	Protocol string `json:"protocol,omitempty"`
+	// ProtocolOptions defines optional HTTP/1 and HTTP/2 settings of the
+	// connections Envoy makes to the upstream service.
+	ProtocolOptions *ProtocolOptions `json:"protocolOptions,omitempty"`
+	// ClientCertificate is the name of a kubernetes.io/tls Secret, in the
+	// namespace of the HTTPProxy, which Envoy presents to the upstream service
+	// as its client certificate. Valid only with the "tls", "h2", or "auto" protocols.
+	ClientCertificate string `json:"clientCertificate,omitempty"`
+}
+
+// ProtocolOptions defines the HTTP/1 and HTTP/2 settings of the connections
+// Envoy makes to the upstream service.
+type ProtocolOptions struct {
+	// HTTP/1 settings. Not valid with the "h2" or "h2c" protocols.
+	HTTP1 *HTTP1ProtocolOptions `json:"http1,omitempty"`
+	// HTTP/2 settings. Valid only with the "h2", "h2c", or "auto" protocols.
+	HTTP2 *HTTP2ProtocolOptions `json:"http2,omitempty"`
+}
+
+// HTTP1ProtocolOptions defines the HTTP/1 settings of upstream connections.
+type HTTP1ProtocolOptions struct {
+	// ProperCaseHeaders capitalises the first letter, and any letter following
+	// a hyphen, of each header name sent to the upstream service.
+	ProperCaseHeaders bool `json:"properCaseHeaders,omitempty"`
+	// EnableTrailers allows trailers to be sent to and received from the
+	// upstream service.
+	EnableTrailers bool `json:"enableTrailers,omitempty"`
+}
+
+// HTTP2ProtocolOptions defines the HTTP/2 settings of upstream connections.
+type HTTP2ProtocolOptions struct {
+	// The maximum number of concurrent streams on each connection.
+	// Must be between 1 and 2147483647. Defaults to 2147483647 if not set.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=2147483647
+	MaxConcurrentStreams uint32 `json:"maxConcurrentStreams,omitempty"`
+	// The initial window size (bytes) of each stream.
+	// Must be between 65535 and 2147483647. Defaults to 268435456 if not set.
+	// +kubebuilder:validation:Minimum=65535
+	// +kubebuilder:validation:Maximum=2147483647
+	InitialStreamWindowSize uint32 `json:"initialStreamWindowSize,omitempty"`
+	// The initial window size (bytes) of each connection.
+	// Must be between 65535 and 2147483647. Defaults to 268435456 if not set.
+	// +kubebuilder:validation:Minimum=65535
+	// +kubebuilder:validation:Maximum=2147483647
+	InitialConnectionWindowSize uint32 `json:"initialConnectionWindowSize,omitempty"`
 }
 
 // HealthCheck defines optional healthchecks on the upstream service
 type HealthCheck struct {
-	// HTTP endpoint used to perform health checks on upstream service
-	Path string `json:"path"`
+	// The type of health check, one of "http", "tcp", or "grpc".
+	// If left empty (default value), an HTTP health check is performed.
+	// +kubebuilder:validation:Enum=http;tcp;grpc
+	// +kubebuilder:default=http
+	Type string `json:"type,omitempty"`
+	// HTTP endpoint used to perform health checks on upstream service.
+	// Required for HTTP health checks.
+	Path string `json:"path,omitempty"`
 	// The value of the host header in the HTTP health check request.
 	// If left empty (default value), the name "contour-envoy-healthcheck"
 	// will be used.
 	Host string `json:"host,omitempty"`
+	// The ranges of HTTP response statuses considered healthy.
+	// If left empty (default value), only a 200 response is healthy.
+	ExpectedStatuses []StatusRange `json:"expectedStatuses,omitempty"`
+	// TCP health check settings.
+	TCP *TCPHealthCheck `json:"tcp,omitempty"`
+	// gRPC health check settings.
+	GRPC *GRPCHealthCheck `json:"grpc,omitempty"`
 	// The interval (seconds) between health checks
+	// +optional
 	IntervalSeconds int64 `json:"intervalSeconds"`
 	// The time to wait (seconds) for a health check response
+	// +optional
 	TimeoutSeconds int64 `json:"timeoutSeconds"`
 	// The number of unhealthy health checks required before a host is marked unhealthy
+	// +optional
 	UnhealthyThresholdCount uint32 `json:"unhealthyThresholdCount"`
 	// The number of healthy health checks required before a host is marked healthy
+	// +optional
 	HealthyThresholdCount uint32 `json:"healthyThresholdCount"`
 }
 
+// OutlierDetection defines how endpoints of the upstream service are
+// ejected from the load balancing set when their responses indicate
+// they are unhealthy.
+type OutlierDetection struct {
+	// The number of consecutive 5xx responses, or connection failures,
+	// after which an endpoint is ejected. Defaults to 5 if not set.
+	Consecutive5xxErrors uint32 `json:"consecutive5xxErrors,omitempty"`
+	// The number of consecutive 502, 503, or 504 responses, or connection
+	// failures, after which an endpoint is ejected. If not set, endpoints
+	// are not ejected for gateway errors alone.
+	ConsecutiveGatewayErrors uint32 `json:"consecutiveGatewayErrors,omitempty"`
+	// The interval (seconds) between ejection sweeps. Defaults to 10 seconds if not set.
+	IntervalSeconds int64 `json:"intervalSeconds,omitempty"`
+	// The time (seconds) an endpoint is ejected for, multiplied by the number of
+	// times it has been ejected. Defaults to 30 seconds if not set.
+	BaseEjectionTimeSeconds int64 `json:"baseEjectionTimeSeconds,omitempty"`
+	// The maximum percentage of the service's endpoints which may be ejected
+	// at once. Defaults to 10 percent if not set.
+	MaxEjectionPercent uint32 `json:"maxEjectionPercent,omitempty"`
+}
+
+// CircuitBreakers defines the limits on the connections and requests Envoy
+// makes to the upstream service. Each limit set here takes precedence over
+// the matching contour.heptio.com/max-* annotation of the Kubernetes service.
+type CircuitBreakers struct {
+	// The maximum number of connections that Envoy will make to the upstream service.
+	MaxConnections uint32 `json:"maxConnections,omitempty"`
+	// The maximum number of requests that Envoy will queue waiting for a connection.
+	MaxPendingRequests uint32 `json:"maxPendingRequests,omitempty"`
+	// The maximum number of parallel requests that Envoy will make to the upstream service.
+	MaxRequests uint32 `json:"maxRequests,omitempty"`
+	// The maximum number of parallel retries that Envoy will make to the upstream service.
+	MaxRetries uint32 `json:"maxRetries,omitempty"`
+}
+
+// StatusRange is a range of HTTP response statuses, from Start
+// inclusive, to End exclusive.
+type StatusRange struct {
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=599
+	Start int64 `json:"start"`
+	// +kubebuilder:validation:Minimum=101
+	// +kubebuilder:validation:Maximum=600
+	End int64 `json:"end"`
+}
+
+// TCPHealthCheck defines the payloads of a TCP health check.
+// If neither is set, a successful connection is healthy.
+type TCPHealthCheck struct {
+	// Send is the hex encoded payload sent to the upstream.
+	Send string `json:"send,omitempty"`
+	// Receive are the hex encoded payloads which must all be
+	// found in the upstream's response for it to be healthy.
+	Receive []string `json:"receive,omitempty"`
+}
+
+// GRPCHealthCheck defines a gRPC health check, which uses the
+// grpc.health.v1.Health service.
+type GRPCHealthCheck struct {
+	// ServiceName is the name of the service to check.
+	// If left empty, the health of the whole server is checked.
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
 // TimeoutPolicy define the attributes associated with timeout
 type TimeoutPolicy struct {
 	// Timeout for receiving a response from the server after processing a request from client.
 	// If not supplied the timeout duration is undefined.
+	// +optional
 	Request string `json:"request"`
 }
 
@@ -149,6 +304,7 @@ type TimeoutPolicy struct {
 type RetryPolicy struct {
 	// NumRetries is maximum allowed number of retries.
 	// If not supplied, the number of retries is zero.
+	// +optional
 	NumRetries uint32 `json:"count"`
 	// PerTryTimeout specifies the timeout per retry attempt.
 	// Ignored if NumRetries is not supplied.
@@ -165,23 +321,34 @@ type UpstreamValidation struct {
 
 // Status reports the current state of the HTTPProxy.
 type Status struct {
+	// +optional
 	CurrentStatus string `json:"currentStatus"`
-	Description   string `json:"description"`
+	// +optional
+	Description string `json:"description"`
 }
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced,shortName=proxy
+// +kubebuilder:printcolumn:name="FQDN",type="string",JSONPath=".spec.virtualhost.fqdn",description="Fully qualified domain name"
+// +kubebuilder:printcolumn:name="TLS Secret",type="string",JSONPath=".spec.virtualhost.tls.secretName",description="Secret with TLS credentials"
+// +kubebuilder:printcolumn:name="First route",type="string",JSONPath=".spec.routes[0].condition.prefix",description="First routes defined"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.currentStatus",description="The current status of the HTTPProxy"
+// +kubebuilder:printcolumn:name="Status Description",type="string",JSONPath=".status.description",description="Description of the current status"
 
 // HTTPProxy is an Ingress CRD specification
 type HTTPProxy struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 
-	Spec   HTTPProxySpec `json:"spec"`
+	Spec HTTPProxySpec `json:"spec"`
+	// +optional
 	Status `json:"status"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
 
 // HTTPProxyList is a list of HTTPProxies.
 type HTTPProxyList struct {
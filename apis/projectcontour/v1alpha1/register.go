@@ -46,6 +46,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&HTTPProxyList{},
 		&TLSCertificateDelegation{},
 		&TLSCertificateDelegationList{},
+		&ServiceDelegation{},
+		&ServiceDelegationList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
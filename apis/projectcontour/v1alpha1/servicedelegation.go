@@ -0,0 +1,70 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceDelegationSpec defines the spec of the CRD
+type ServiceDelegationSpec struct {
+	Delegations []DelegatedService `json:"delegations"`
+}
+
+// DelegatedService maps the authority to reference a service
+// in the current namespace to a set of namespaces.
+type DelegatedService struct {
+
+	// required, the name of a service in the current namespace.
+	ServiceName string `json:"serviceName"`
+
+	// the namespaces the authority to reference the
+	// service will be delegated to.
+	// If TargetNamespaces and TargetNamespaceSelector are both
+	// nil or empty, the DelegatedService is ignored.
+	// If the TargetNamespace list contains the character, "*"
+	// the service will be delegated to all namespaces.
+	// +optional
+	TargetNamespaces []string `json:"targetNamespaces"`
+
+	// TargetNamespaceSelector delegates the service to the namespaces
+	// whose labels match the selector, in addition to TargetNamespaces.
+	// An empty selector matches every namespace.
+	// +optional
+	TargetNamespaceSelector *metav1.LabelSelector `json:"targetNamespaceSelector,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
+
+// ServiceDelegation permits HTTPProxies in other namespaces to
+// route to services in the namespace of the ServiceDelegation.
+type ServiceDelegation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec ServiceDelegationSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// ServiceDelegationList is a list of ServiceDelegations.
+type ServiceDelegationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ServiceDelegation `json:"items"`
+}
@@ -29,16 +29,26 @@ type CertificateDelegation struct {
 	// required, the name of a secret in the current namespace.
 	SecretName string `json:"secretName"`
 
-	// required, the namespaces the authority to reference the
+	// the namespaces the authority to reference the
 	// the secret will be delegated to.
-	// If TargetNamespaces is nil or empty, the CertificateDelegation'
-	// is ignored. If the TargetNamespace list contains the character, "*"
+	// If TargetNamespaces and TargetNamespaceSelector are both
+	// nil or empty, the CertificateDelegation is ignored.
+	// If the TargetNamespace list contains the character, "*"
 	// the secret will be delegated to all namespaces.
+	// +optional
 	TargetNamespaces []string `json:"targetNamespaces"`
+
+	// TargetNamespaceSelector delegates the secret to the namespaces
+	// whose labels match the selector, in addition to TargetNamespaces.
+	// An empty selector matches every namespace.
+	// +optional
+	TargetNamespaceSelector *metav1.LabelSelector `json:"targetNamespaceSelector,omitempty"`
 }
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
 
 // TLSCertificateDelegation is an TLS Certificate Delegation CRD specificiation.
 // See design/tls-certificate-delegation.md for details.
@@ -50,6 +60,7 @@ type TLSCertificateDelegation struct {
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
 
 // TLSCertificateDelegationList is a list of TLSCertificateDelegations.
 type TLSCertificateDelegationList struct {
@@ -17,6 +17,7 @@ import (
 	"os"
 
 	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
 	"github.com/projectcontour/contour/internal/envoy"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
@@ -30,25 +31,35 @@ func registerBootstrap(app *kingpin.Application) (*kingpin.CmdClause, *bootstrap
 	bootstrap.Arg("path", "Configuration file.").Required().StringVar(&ctx.path)
 	bootstrap.Flag("admin-address", "Envoy admin interface address").StringVar(&ctx.config.AdminAddress)
 	bootstrap.Flag("admin-port", "Envoy admin interface port").IntVar(&ctx.config.AdminPort)
-	bootstrap.Flag("xds-address", "xDS gRPC API address").StringVar(&ctx.config.XDSAddress)
+	bootstrap.Flag("xds-address", "xDS gRPC API address, or unix:///path/to/socket").StringVar(&ctx.config.XDSAddress)
 	bootstrap.Flag("xds-port", "xDS gRPC API port").IntVar(&ctx.config.XDSGRPCPort)
 	bootstrap.Flag("envoy-cafile", "gRPC CA Filename for Envoy to load").Envar("ENVOY_CAFILE").StringVar(&ctx.config.GrpcCABundle)
 	bootstrap.Flag("envoy-cert-file", "gRPC Client cert filename for Envoy to load").Envar("ENVOY_CERT_FILE").StringVar(&ctx.config.GrpcClientCert)
 	bootstrap.Flag("envoy-key-file", "gRPC Client key filename for Envoy to load").Envar("ENVOY_KEY_FILE").StringVar(&ctx.config.GrpcClientKey)
 	bootstrap.Flag("namespace", "The namespace the Envoy container will run in").Envar("CONTOUR_NAMESPACE").Default("projectcontour").StringVar(&ctx.config.Namespace)
+	bootstrap.Flag("fleet", "The fleet of Envoys this Envoy is a member of").StringVar(&ctx.config.Fleet)
+	bootstrap.Flag("node-region", "The region of the node this Envoy will run on").Envar("ENVOY_NODE_REGION").StringVar(&ctx.config.Region)
+	bootstrap.Flag("node-zone", "The zone of the node this Envoy will run on").Envar("ENVOY_NODE_ZONE").StringVar(&ctx.config.Zone)
+	bootstrap.Flag("local-cluster", "The EDS service name of this Envoy's own Service, for zone aware routing").StringVar(&ctx.config.LocalCluster)
+	bootstrap.Flag("xds-resource-version", "The xDS API version Envoy should use to fetch resources").Default("v2").EnumVar(&ctx.resourceVersion, "v2", "v3")
 	return bootstrap, &ctx
 }
 
 type bootstrapContext struct {
-	config envoy.BootstrapConfig
-	path   string
+	config          envoy.BootstrapConfig
+	path            string
+	resourceVersion string
 }
 
 // doBootstrap writes an Envoy bootstrap configuration file to the supplied path.
 func doBootstrap(ctx *bootstrapContext) {
 	f, err := os.Create(ctx.path)
 	check(err)
-	bs := envoy.Bootstrap(&ctx.config)
+	var bs proto.Message = envoy.Bootstrap(&ctx.config)
+	if ctx.resourceVersion == "v3" {
+		bs, err = envoy.BootstrapV3(&ctx.config)
+		check(err)
+	}
 	m := &jsonpb.Marshaler{OrigName: true}
 	err = m.Marshal(f, bs)
 	check(err)
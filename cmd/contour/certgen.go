@@ -35,6 +35,7 @@ func registerCertGen(app *kingpin.Application) (*kingpin.CmdClause, *certgenConf
 	certgenApp.Flag("incluster", "use in cluster configuration.").BoolVar(&certgenConfig.InCluster)
 	certgenApp.Flag("kubeconfig", "path to kubeconfig (if not in running inside a cluster)").Default(filepath.Join(os.Getenv("HOME"), ".kube", "config")).StringVar(&certgenConfig.KubeConfig)
 	certgenApp.Flag("namespace", "Kubernetes namespace, used for Kube objects").Default("projectcontour").Envar("CONTOUR_NAMESPACE").StringVar(&certgenConfig.Namespace)
+	certgenApp.Flag("client-identity", "Additional xDS client identity to generate a keypair for. May be repeated.").StringsVar(&certgenConfig.ClientIdentities)
 	certgenApp.Arg("outputdir", "Directory to output any files to").Default("certs").StringVar(&certgenConfig.OutputDir)
 
 	return certgenApp, &certgenConfig
@@ -63,6 +64,10 @@ type certgenConfig struct {
 
 	// OutputPEM means that the certs generated will be output as PEM files in the current directory.
 	OutputPEM bool
+
+	// ClientIdentities are the names of any xDS clients, in addition to Envoy,
+	// which are issued a keypair of their own.
+	ClientIdentities []string
 }
 
 // GenerateCerts performs the actual cert generation steps and then returns the certs for the output function.
@@ -100,6 +105,23 @@ func GenerateCerts(certConfig *certgenConfig) (map[string][]byte, error) {
 	newCerts["envoycert.pem"] = envoyCert
 	newCerts["envoykey.pem"] = envoyKey
 
+	for _, identity := range certConfig.ClientIdentities {
+		if _, ok := newCerts[identity+"cert.pem"]; ok {
+			return nil, fmt.Errorf("duplicate client identity %q", identity)
+		}
+		clientCert, clientKey, err := certgen.NewCert(caCertPEM,
+			caKeyPEM,
+			expiry,
+			identity,
+			certConfig.Namespace,
+		)
+		if err != nil {
+			return nil, err
+		}
+		newCerts[identity+"cert.pem"] = clientCert
+		newCerts[identity+"key.pem"] = clientKey
+	}
+
 	return newCerts, nil
 
 }
@@ -128,6 +150,6 @@ func OutputCerts(config *certgenConfig,
 func doCertgen(config *certgenConfig) {
 	generatedCerts, err := GenerateCerts(config)
 	check(err)
-	kubeclient, _, _ := newClient(config.KubeConfig, config.InCluster)
+	kubeclient, _, _, _ := newClient(config.KubeConfig, config.InCluster)
 	OutputCerts(config, kubeclient, generatedCerts)
 }
@@ -17,7 +17,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
 	clientset "github.com/projectcontour/contour/apis/generated/clientset/versioned"
 	"github.com/sirupsen/logrus"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
@@ -25,7 +25,8 @@ import (
 	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/klog"
+	"k8s.io/klog/v2"
+	gatewayclientset "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
 )
 
 func init() {
@@ -44,6 +45,8 @@ func main() {
 
 	certgenApp, certgenConfig := registerCertGen(app)
 
+	convertApp, convertConfig := registerConvert(app)
+
 	cli := app.Command("cli", "A CLI client for the Heptio Contour Kubernetes ingress controller.")
 	var client Client
 	cli.Flag("contour", "contour host:port.").Default("127.0.0.1:8001").StringVar(&client.ContourAddr)
@@ -65,27 +68,31 @@ func main() {
 
 	serve, serveCtx := registerServe(app)
 
+	webhook, webhookCtx := registerWebhook(app)
+
 	args := os.Args[1:]
 	switch kingpin.MustParse(app.Parse(args)) {
 	case bootstrap.FullCommand():
 		doBootstrap(bootstrapCtx)
 	case certgenApp.FullCommand():
 		doCertgen(certgenConfig)
+	case convertApp.FullCommand():
+		doConvert(convertConfig)
 	case cds.FullCommand():
 		stream := client.ClusterStream()
-		watchstream(stream, cache.ClusterType, resources)
+		watchstream(stream, resource.ClusterType, resources)
 	case eds.FullCommand():
 		stream := client.EndpointStream()
-		watchstream(stream, cache.EndpointType, resources)
+		watchstream(stream, resource.EndpointType, resources)
 	case lds.FullCommand():
 		stream := client.ListenerStream()
-		watchstream(stream, cache.ListenerType, resources)
+		watchstream(stream, resource.ListenerType, resources)
 	case rds.FullCommand():
 		stream := client.RouteStream()
-		watchstream(stream, cache.RouteType, resources)
+		watchstream(stream, resource.RouteType, resources)
 	case sds.FullCommand():
 		stream := client.RouteStream()
-		watchstream(stream, cache.SecretType, resources)
+		watchstream(stream, resource.SecretType, resources)
 	case serve.FullCommand():
 		// parse args a second time so cli flags are applied
 		// on top of any values sourced from -c's config file.
@@ -93,13 +100,20 @@ func main() {
 		check(err)
 		log.Infof("args: %v", args)
 		doServe(log, serveCtx)
+	case webhook.FullCommand():
+		// parse args a second time so cli flags are applied
+		// on top of any values sourced from -c's config file.
+		_, err := app.Parse(args)
+		check(err)
+		log.Infof("args: %v", args)
+		check(doWebhook(log, webhookCtx))
 	default:
 		app.Usage(args)
 		os.Exit(2)
 	}
 }
 
-func newClient(kubeconfig string, inCluster bool) (*kubernetes.Clientset, *clientset.Clientset, *coordinationv1.CoordinationV1Client) {
+func newClient(kubeconfig string, inCluster bool) (*kubernetes.Clientset, *clientset.Clientset, *coordinationv1.CoordinationV1Client, *gatewayclientset.Clientset) {
 	var err error
 	var config *rest.Config
 	if kubeconfig != "" && !inCluster {
@@ -116,8 +130,10 @@ func newClient(kubeconfig string, inCluster bool) (*kubernetes.Clientset, *clien
 	check(err)
 	coordinationClient, err := coordinationv1.NewForConfig(config)
 	check(err)
+	gatewayClient, err := gatewayclientset.NewForConfig(config)
+	check(err)
 
-	return client, contourClient, coordinationClient
+	return client, contourClient, coordinationClient, gatewayClient
 }
 
 func check(err error) {
@@ -0,0 +1,98 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	ingressroutev1 "github.com/projectcontour/contour/apis/contour/v1beta1"
+	"github.com/projectcontour/contour/internal/convert"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// registerConvert registers the convert subcommand and flags
+// with the Application provided.
+func registerConvert(app *kingpin.Application) (*kingpin.CmdClause, *convertConfig) {
+	var config convertConfig
+	convertApp := app.Command("convert", "Convert IngressRoute objects to HTTPProxy objects, written to stdout as YAML")
+	convertApp.Flag("incluster", "use in cluster configuration.").BoolVar(&config.InCluster)
+	convertApp.Flag("kubeconfig", "path to kubeconfig (if not in running inside a cluster)").Default(filepath.Join(os.Getenv("HOME"), ".kube", "config")).StringVar(&config.KubeConfig)
+	convertApp.Flag("namespace", "Kubernetes namespace to read IngressRoutes from, if no files are given. Defaults to all namespaces").StringVar(&config.Namespace)
+	convertApp.Arg("files", "YAML or JSON files to read IngressRoutes from, or - for stdin. If not given, IngressRoutes are read from the cluster").StringsVar(&config.Files)
+
+	return convertApp, &config
+}
+
+// convertConfig holds the configuration for the convert subcommand.
+type convertConfig struct {
+	// KubeConfig is the path to the Kubeconfig file if we're not running in a cluster
+	KubeConfig string
+
+	// Incluster means that we should assume we are running in a Kubernetes cluster and work accordingly.
+	InCluster bool
+
+	// Namespace is the namespace to read IngressRoutes from.
+	Namespace string
+
+	// Files are the files to read IngressRoutes from.
+	Files []string
+}
+
+// doConvert converts the IngressRoutes read from the configured files,
+// or the cluster, to HTTPProxies written to stdout. Constructs which
+// do not translate cleanly are reported on stderr.
+func doConvert(config *convertConfig) {
+	var irs []*ingressroutev1.IngressRoute
+	if len(config.Files) == 0 {
+		_, contourClient, _, _ := newClient(config.KubeConfig, config.InCluster)
+		list, err := contourClient.ContourV1beta1().IngressRoutes(config.Namespace).List(context.TODO(), metav1.ListOptions{})
+		check(err)
+		for i := range list.Items {
+			irs = append(irs, &list.Items[i])
+		}
+	}
+	for _, file := range config.Files {
+		decoded, err := decodeFile(file)
+		check(err)
+		irs = append(irs, decoded...)
+	}
+
+	proxies, warnings := convert.IngressRoutes(irs)
+	check(convert.Encode(os.Stdout, proxies))
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+}
+
+func decodeFile(file string) ([]*ingressroutev1.IngressRoute, error) {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	irs, err := convert.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return irs, nil
+}
@@ -0,0 +1,135 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	clientset "github.com/projectcontour/contour/apis/generated/clientset/versioned"
+	contourinformers "github.com/projectcontour/contour/apis/generated/informers/externalversions"
+	"github.com/projectcontour/contour/internal/workgroup"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+)
+
+// namespacedInformers holds the SharedInformerFactories contour
+// watches namespaced resources with. If contour watches all
+// namespaces there is a single core and contour factory for
+// metav1.NamespaceAll, otherwise there is one of each per
+// watched namespace, so that contour only needs namespaced Roles.
+type namespacedInformers struct {
+	client kubernetes.Interface
+
+	// namespaces are the watched namespaces, or
+	// metav1.NamespaceAll if all namespaces are watched.
+	namespaces []string
+
+	core    map[string]coreinformers.SharedInformerFactory
+	contour map[string]contourinformers.SharedInformerFactory
+}
+
+// newNamespacedInformers returns the namespacedInformers watching
+// namespaces, or all namespaces if namespaces is empty.
+func newNamespacedInformers(client kubernetes.Interface, contourClient clientset.Interface, namespaces []string) *namespacedInformers {
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	inf := &namespacedInformers{
+		client:     client,
+		namespaces: namespaces,
+		core:       make(map[string]coreinformers.SharedInformerFactory),
+		contour:    make(map[string]contourinformers.SharedInformerFactory),
+	}
+	for _, namespace := range namespaces {
+		inf.coreFor(namespace)
+		// note: 0 means resync timers are disabled
+		inf.contour[namespace] = contourinformers.NewSharedInformerFactoryWithOptions(contourClient, 0, contourinformers.WithNamespace(namespace))
+	}
+	return inf
+}
+
+// watchesAllNamespaces returns true if contour watches all namespaces,
+// and so may also watch cluster scoped resources with allNamespaces.
+func (inf *namespacedInformers) watchesAllNamespaces() bool {
+	return len(inf.namespaces) == 1 && inf.namespaces[0] == metav1.NamespaceAll
+}
+
+// allNamespaces returns the core SharedInformerFactory for all
+// namespaces, which also watches cluster scoped resources.
+// It must only be used if watchesAllNamespaces returns true.
+func (inf *namespacedInformers) allNamespaces() coreinformers.SharedInformerFactory {
+	return inf.core[metav1.NamespaceAll]
+}
+
+// coreFor returns the core SharedInformerFactory for namespace,
+// creating it if it does not exist.
+func (inf *namespacedInformers) coreFor(namespace string) coreinformers.SharedInformerFactory {
+	f, ok := inf.core[namespace]
+	if !ok {
+		// note: 0 means resync timers are disabled
+		f = coreinformers.NewSharedInformerFactoryWithOptions(inf.client, 0, coreinformers.WithNamespace(namespace))
+		inf.core[namespace] = f
+	}
+	return f
+}
+
+// coreFactories returns the core SharedInformerFactories
+// of the watched namespaces.
+func (inf *namespacedInformers) coreFactories() []coreinformers.SharedInformerFactory {
+	var factories []coreinformers.SharedInformerFactory
+	for _, namespace := range inf.namespaces {
+		factories = append(factories, inf.core[namespace])
+	}
+	return factories
+}
+
+// contourFactories returns the contour SharedInformerFactories
+// of the watched namespaces.
+func (inf *namespacedInformers) contourFactories() []contourinformers.SharedInformerFactory {
+	var factories []contourinformers.SharedInformerFactory
+	for _, namespace := range inf.namespaces {
+		factories = append(factories, inf.contour[namespace])
+	}
+	return factories
+}
+
+// secretFactories returns the core SharedInformerFactories Secrets
+// are watched with. Secrets are only watched in the root namespaces,
+// if defined, otherwise in the watched namespaces.
+func (inf *namespacedInformers) secretFactories(rootNamespaces []string) []coreinformers.SharedInformerFactory {
+	if len(rootNamespaces) == 0 {
+		return inf.coreFactories()
+	}
+	var factories []coreinformers.SharedInformerFactory
+	for _, namespace := range rootNamespaces {
+		factories = append(factories, inf.coreFor(namespace))
+	}
+	return factories
+}
+
+// start adds each of the SharedInformerFactories to g.
+func (inf *namespacedInformers) start(g *workgroup.Group, log logrus.FieldLogger) {
+	withNamespace := func(log logrus.FieldLogger, namespace string) logrus.FieldLogger {
+		if namespace == metav1.NamespaceAll {
+			return log
+		}
+		return log.WithField("namespace", namespace)
+	}
+	for namespace, f := range inf.core {
+		g.Add(startInformer(f, withNamespace(log.WithField("context", "coreinformers"), namespace)))
+	}
+	for namespace, f := range inf.contour {
+		g.Add(startInformer(f, withNamespace(log.WithField("context", "contourinformers"), namespace)))
+	}
+}
@@ -15,15 +15,12 @@ package main
 
 import (
 	"context"
-	"net"
 	"os"
 	"os/signal"
 	"reflect"
-	"strconv"
 	"syscall"
 	"time"
 
-	contourinformers "github.com/projectcontour/contour/apis/generated/informers/externalversions"
 	"github.com/projectcontour/contour/internal/contour"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/debug"
@@ -37,6 +34,9 @@ import (
 	"gopkg.in/alecthomas/kingpin.v2"
 	"gopkg.in/yaml.v2"
 	coreinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	gatewayinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions"
 )
 
 // registerServe registers the serve subcommand and flags
@@ -44,41 +44,13 @@ import (
 func registerServe(app *kingpin.Application) (*kingpin.CmdClause, *serveContext) {
 	serve := app.Command("serve", "Serve xDS API traffic")
 
-	// The precedence of configuration for contour serve is as follows:
-	// config file, overridden by env vars, overridden by cli flags.
-	// however, as -c is a cli flag, we don't know its valye til cli flags
-	// have been parsed. To correct this ordering we assign a post parse
-	// action to -c, then parse cli flags twice (see main.main). On the second
-	// parse our action will return early, resulting in the precedence order
-	// we want.
-	var (
-		configFile string
-		parsed     bool
-	)
 	ctx := newServeContext()
-
-	parseConfig := func(_ *kingpin.ParseContext) error {
-		if parsed || configFile == "" {
-			// if there is no config file supplied, or we've
-			// already parsed it, return immediately.
-			return nil
-		}
-		f, err := os.Open(configFile)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		dec := yaml.NewDecoder(f)
-		parsed = true
-		return dec.Decode(&ctx)
-	}
-
-	serve.Flag("config-path", "path to base configuration").Short('c').Action(parseConfig).ExistingFileVar(&configFile)
+	registerConfigPath(serve, ctx)
 
 	serve.Flag("incluster", "use in cluster configuration.").BoolVar(&ctx.InCluster)
 	serve.Flag("kubeconfig", "path to kubeconfig (if not in running inside a cluster)").StringVar(&ctx.Kubeconfig)
 
-	serve.Flag("xds-address", "xDS gRPC API address").StringVar(&ctx.xdsAddr)
+	serve.Flag("xds-address", "xDS gRPC API address, or unix:///path/to/socket").StringVar(&ctx.xdsAddr)
 	serve.Flag("xds-port", "xDS gRPC API port").IntVar(&ctx.xdsPort)
 
 	serve.Flag("stats-address", "Envoy /stats interface address").StringVar(&ctx.statsAddr)
@@ -97,8 +69,11 @@ func registerServe(app *kingpin.Application) (*kingpin.CmdClause, *serveContext)
 	// TODO(sas) Deprecate `ingressroute-root-namespaces` in v1.0
 	serve.Flag("ingressroute-root-namespaces", "DEPRECATED (Use 'root-namespaces'): Restrict contour to searching these namespaces for root ingress routes").StringVar(&ctx.rootNamespaces)
 	serve.Flag("root-namespaces", "Restrict contour to searching these namespaces for root ingress routes").StringVar(&ctx.rootNamespaces)
+	serve.Flag("watch-namespaces", "Restrict contour to watching resources in these namespaces").StringVar(&ctx.watchNamespaces)
 
 	serve.Flag("ingress-class-name", "Contour IngressClass name").StringVar(&ctx.ingressClass)
+	serve.Flag("ingress-class-controller", "Controller name of Contour's IngressClass objects").StringVar(&ctx.ingressClassController)
+	serve.Flag("gateway-controller", "Controller name of Contour's GatewayClass objects").StringVar(&ctx.gatewayController)
 
 	serve.Flag("envoy-http-access-log", "Envoy HTTP access log").StringVar(&ctx.httpAccessLog)
 	serve.Flag("envoy-https-access-log", "Envoy HTTPS access log").StringVar(&ctx.httpsAccessLog)
@@ -107,29 +82,74 @@ func registerServe(app *kingpin.Application) (*kingpin.CmdClause, *serveContext)
 	serve.Flag("envoy-service-http-port", "Kubernetes Service port for HTTP requests").IntVar(&ctx.httpPort)
 	serve.Flag("envoy-service-https-port", "Kubernetes Service port for HTTPS requests").IntVar(&ctx.httpsPort)
 	serve.Flag("use-proxy-protocol", "Use PROXY protocol for all listeners").BoolVar(&ctx.useProxyProto)
+	serve.Flag("use-endpoint-slices", "Discover service endpoints from EndpointSlices rather than Endpoints (requires Kubernetes 1.21 or later)").BoolVar(&ctx.useEndpointSlices)
+	serve.Flag("use-endpoint-weights", "Weight endpoints by the projectcontour.io/endpoint-weight annotation of their Pod").BoolVar(&ctx.useEndpointWeights)
+
+	serve.Flag("enable-external-name-service", "Permit routing to Services of type ExternalName").BoolVar(&ctx.ExternalNameConfig.Enable)
+	serve.Flag("external-name-dns-refresh-rate", "How often Envoy resolves the names of ExternalName Services").DurationVar(&ctx.ExternalNameConfig.DNSRefreshRate)
+	serve.Flag("external-name-dns-lookup-family", "IP family Envoy resolves the names of ExternalName Services to: auto, v4, or v6").StringVar(&ctx.ExternalNameConfig.DNSLookupFamily)
 
 	serve.Flag("accesslog-format", "Format for Envoy access logs").StringVar(&ctx.AccessLogFormat)
 	serve.Flag("disable-leader-election", "Disable leader election mechanism").BoolVar(&ctx.DisableLeaderElection)
 	return serve, ctx
 }
 
+// registerConfigPath registers the config-path flag, which reads
+// ctx from a configuration file, with the command provided.
+func registerConfigPath(cmd *kingpin.CmdClause, ctx *serveContext) {
+	// The precedence of configuration for contour serve and contour webhook is as follows:
+	// config file, overridden by env vars, overridden by cli flags.
+	// however, as -c is a cli flag, we don't know its valye til cli flags
+	// have been parsed. To correct this ordering we assign a post parse
+	// action to -c, then parse cli flags twice (see main.main). On the second
+	// parse our action will return early, resulting in the precedence order
+	// we want.
+	var (
+		configFile string
+		parsed     bool
+	)
+
+	parseConfig := func(_ *kingpin.ParseContext) error {
+		if parsed || configFile == "" {
+			// if there is no config file supplied, or we've
+			// already parsed it, return immediately.
+			return nil
+		}
+		f, err := os.Open(configFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		dec := yaml.NewDecoder(f)
+		parsed = true
+		return dec.Decode(&ctx)
+	}
+
+	cmd.Flag("config-path", "path to base configuration").Short('c').Action(parseConfig).ExistingFileVar(&configFile)
+}
+
 // doServe runs the contour serve subcommand.
 func doServe(log logrus.FieldLogger, ctx *serveContext) error {
+	clientCertificate, err := ctx.clientCertificate()
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.checkWatchNamespaces(); err != nil {
+		return err
+	}
+
+	if err := ctx.checkExternalNameConfig(); err != nil {
+		return err
+	}
 
 	// step 1. establish k8s client connection
-	client, contourClient, coordinationClient := newClient(ctx.Kubeconfig, ctx.InCluster)
+	client, contourClient, coordinationClient, gatewayClient := newClient(ctx.Kubeconfig, ctx.InCluster)
 
 	// step 2. create informers
 	// note: 0 means resync timers are disabled
-	coreInformers := coreinformers.NewSharedInformerFactory(client, 0)
-	contourInformers := contourinformers.NewSharedInformerFactory(contourClient, 0)
-
-	// Create a set of SharedInformerFactories for each root-ingressroute namespace (if defined)
-	var namespacedInformers []coreinformers.SharedInformerFactory
-	for _, namespace := range ctx.ingressRouteRootNamespaces() {
-		inf := coreinformers.NewSharedInformerFactoryWithOptions(client, 0, coreinformers.WithNamespace(namespace))
-		namespacedInformers = append(namespacedInformers, inf)
-	}
+	informers := newNamespacedInformers(client, contourClient, ctx.watchedNamespaces())
+	gatewayInformers := gatewayinformers.NewSharedInformerFactory(gatewayClient, 0)
 
 	// step 3. build our mammoth Kubernetes event handler.
 	eh := &contour.EventHandler{
@@ -156,45 +176,117 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		},
 		Builder: dag.Builder{
 			Source: dag.KubernetesCache{
-				RootNamespaces: ctx.ingressRouteRootNamespaces(),
-				IngressClass:   ctx.ingressClass,
-				FieldLogger:    log.WithField("context", "KubernetesCache"),
+				RootNamespaces:         ctx.ingressRouteRootNamespaces(),
+				WatchNamespaces:        ctx.watchedNamespaces(),
+				IngressClass:           ctx.ingressClass,
+				IngressClassController: ctx.ingressClassController,
+				GatewayController:      ctx.gatewayController,
+				ClientCertificate:      clientCertificate,
+				FieldLogger:            log.WithField("context", "KubernetesCache"),
 			},
-			DisablePermitInsecure: ctx.DisablePermitInsecure,
+			DisablePermitInsecure:       ctx.DisablePermitInsecure,
+			EnableExternalNameService:   ctx.ExternalNameConfig.Enable,
+			ExternalNameDNSRefreshRate:  ctx.ExternalNameConfig.DNSRefreshRate,
+			ExternalNameDNSLookupFamily: ctx.ExternalNameConfig.DNSLookupFamily,
+			ExternalNameDeniedNames:     ctx.ExternalNameConfig.DeniedNames,
+			HTTPPort:                    ctx.httpPort,
+			HTTPSPort:                   ctx.httpsPort,
 		},
 		FieldLogger: log.WithField("context", "contourEventHandler"),
 	}
 
+	// Envoy nodes are served the listeners and routes of their fleet.
+	fleets := ctx.fleetSelector()
+	eh.CacheHandler.ListenerCache.FleetSelector = fleets
+	eh.CacheHandler.RouteCache.FleetSelector = fleets
+
 	// step 4. register our resource event handler with the k8s informers.
-	coreInformers.Core().V1().Services().Informer().AddEventHandler(eh)
-	coreInformers.Extensions().V1beta1().Ingresses().Informer().AddEventHandler(eh)
-	contourInformers.Contour().V1beta1().IngressRoutes().Informer().AddEventHandler(eh)
-	contourInformers.Contour().V1beta1().TLSCertificateDelegations().Informer().AddEventHandler(eh)
-	contourInformers.Projectcontour().V1alpha1().HTTPProxies().Informer().AddEventHandler(eh)
-	contourInformers.Projectcontour().V1alpha1().TLSCertificateDelegations().Informer().AddEventHandler(eh)
-
-	// Add informers for each root-ingressroute namespaces
-	for _, inf := range namespacedInformers {
+	for _, inf := range informers.coreFactories() {
+		inf.Core().V1().Services().Informer().AddEventHandler(eh)
+		ingressInformer(client, inf).AddEventHandler(eh)
+	}
+	for _, inf := range informers.contourFactories() {
+		inf.Contour().V1beta1().IngressRoutes().Informer().AddEventHandler(eh)
+		inf.Contour().V1beta1().TLSCertificateDelegations().Informer().AddEventHandler(eh)
+		inf.Projectcontour().V1alpha1().HTTPProxies().Informer().AddEventHandler(eh)
+		inf.Projectcontour().V1alpha1().TLSCertificateDelegations().Informer().AddEventHandler(eh)
+		inf.Projectcontour().V1alpha1().ServiceDelegations().Informer().AddEventHandler(eh)
+	}
+	for _, inf := range informers.secretFactories(ctx.ingressRouteRootNamespaces()) {
 		inf.Core().V1().Secrets().Informer().AddEventHandler(eh)
 	}
-	// If root-ingressroutes are not defined, then add the informer for all namespaces
-	if len(namespacedInformers) == 0 {
-		coreInformers.Core().V1().Secrets().Informer().AddEventHandler(eh)
+
+	// Cluster scoped resources are only watched if contour watches
+	// all namespaces, as watching them requires a ClusterRole.
+	allNamespaces := informers.watchesAllNamespaces()
+	if allNamespaces {
+		// Namespaces are watched for their labels, which TLSCertificateDelegations,
+		// ServiceDelegations, and Gateways may select the namespaces they apply to by.
+		informers.allNamespaces().Core().V1().Namespaces().Informer().AddEventHandler(eh)
+		if inf := ingressClassInformer(client, informers.allNamespaces()); inf != nil {
+			inf.AddEventHandler(eh)
+		}
+	} else {
+		log.WithField("namespaces", ctx.watchedNamespaces()).Info("watching only these namespaces: Namespaces, IngressClasses, Nodes, and the Gateway API are not watched")
 	}
 
-	// step 5. endpoints updates are handled directly by the EndpointsTranslator
-	// due to their high update rate and their orthogonal nature.
-	et := &contour.EndpointsTranslator{
-		FieldLogger: log.WithField("context", "endpointstranslator"),
+	// The Gateway API is only watched if its CRDs are installed.
+	gatewayAPI := allNamespaces && serverHasResource(client, "networking.x-k8s.io/v1alpha1", "gateways")
+	if gatewayAPI {
+		controller := ctx.gatewayController
+		if controller == "" {
+			controller = dag.DEFAULT_GATEWAY_CONTROLLER
+		}
+		eh.GatewayAPIStatus = &k8s.GatewayAPIStatus{
+			Client:     gatewayClient,
+			Controller: controller,
+		}
+		gatewayInformers.Networking().V1alpha1().GatewayClasses().Informer().AddEventHandler(eh)
+		gatewayInformers.Networking().V1alpha1().Gateways().Informer().AddEventHandler(eh)
+		gatewayInformers.Networking().V1alpha1().HTTPRoutes().Informer().AddEventHandler(eh)
+		gatewayInformers.Networking().V1alpha1().TLSRoutes().Informer().AddEventHandler(eh)
+	}
+
+	// step 5. endpoints updates are handled directly by the EndpointsTranslator,
+	// or the EndpointSliceTranslator, due to their high update rate and their
+	// orthogonal nature. Node, Pod, and Service updates are also sent to the
+	// translator so that endpoints can be grouped by locality, weighted by their
+	// Pod, and not ready endpoints can be translated according to the policy of
+	// their Service. Pods are only watched if endpoint weights are enabled, as
+	// the Pod cache is large in big clusters.
+	var et interface {
+		cgrpc.Resource
+		cache.ResourceEventHandler
+	}
+	if ctx.useEndpointSlices {
+		et = &contour.EndpointSliceTranslator{
+			FieldLogger: log.WithField("context", "endpointslicetranslator"),
+		}
+	} else {
+		et = &contour.EndpointsTranslator{
+			FieldLogger: log.WithField("context", "endpointstranslator"),
+		}
+	}
+	for _, inf := range informers.coreFactories() {
+		if ctx.useEndpointSlices {
+			inf.Discovery().V1().EndpointSlices().Informer().AddEventHandler(et)
+		} else {
+			inf.Core().V1().Endpoints().Informer().AddEventHandler(et)
+		}
+		if ctx.useEndpointWeights {
+			inf.Core().V1().Pods().Informer().AddEventHandler(et)
+		}
+		inf.Core().V1().Services().Informer().AddEventHandler(et)
+	}
+	if allNamespaces {
+		informers.allNamespaces().Core().V1().Nodes().Informer().AddEventHandler(et)
 	}
-	coreInformers.Core().V1().Endpoints().Informer().AddEventHandler(et)
 
 	// step 6. setup workgroup runner and register informers.
 	var g workgroup.Group
-	g.Add(startInformer(coreInformers, log.WithField("context", "coreinformers")))
-	g.Add(startInformer(contourInformers, log.WithField("context", "contourinformers")))
-	for _, inf := range namespacedInformers {
-		g.Add(startInformer(inf, log.WithField("context", "corenamespacedinformers")))
+	informers.start(&g, log)
+	if gatewayAPI {
+		g.Add(startInformer(gatewayInformers, log.WithField("context", "gatewayinformers")))
 	}
 
 	// step 7. register our event handler with the workgroup
@@ -275,10 +367,14 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 			eh.CacheHandler.SecretCache.TypeURL():   &eh.CacheHandler.SecretCache,
 			et.TypeURL():                            et,
 		}
-		opts := ctx.grpcOptions()
-		s := cgrpc.NewAPI(log, resources, opts...)
-		addr := net.JoinHostPort(ctx.xdsAddr, strconv.Itoa(ctx.xdsPort))
-		l, err := net.Listen("tcp", addr)
+		auth, err := ctx.authorizer()
+		if err != nil {
+			return err
+		}
+		opts := ctx.grpcOptions(log)
+		drain := make(chan struct{})
+		s := cgrpc.NewAuthorizedAPI(log, resources, auth, drain, opts...)
+		l, addr, err := ctx.xdsListen()
 		if err != nil {
 			return err
 		}
@@ -291,12 +387,23 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 		log.Info("started")
 		defer log.Info("stopped")
 
+		errc := make(chan error, 1)
 		go func() {
-			<-stop
-			s.Stop()
+			errc <- s.Serve(l) // s now owns l and will close l before returning
 		}()
 
-		return s.Serve(l)
+		select {
+		case err := <-errc:
+			return err
+		case <-stop:
+		}
+
+		// Serve returns once s stops accepting connections, so end
+		// the active streams and wait for them to finish before returning.
+		if !cgrpc.GracefulStop(s, drain, ctx.GRPCConfig.ShutdownTimeout) {
+			log.WithField("timeout", ctx.GRPCConfig.ShutdownTimeout).Info("forced shutdown of active streams")
+		}
+		return <-errc
 	})
 
 	// step 14. Setup SIGTERM handler
@@ -316,6 +423,48 @@ func doServe(log logrus.FieldLogger, ctx *serveContext) error {
 	return g.Run()
 }
 
+// ingressInformer returns the informer for the newest version
+// of the Ingress API served by the API server.
+func ingressInformer(client kubernetes.Interface, inf coreinformers.SharedInformerFactory) cache.SharedIndexInformer {
+	switch {
+	case serverHasResource(client, "networking.k8s.io/v1", "ingresses"):
+		return inf.Networking().V1().Ingresses().Informer()
+	case serverHasResource(client, "networking.k8s.io/v1beta1", "ingresses"):
+		return inf.Networking().V1beta1().Ingresses().Informer()
+	default:
+		return inf.Extensions().V1beta1().Ingresses().Informer()
+	}
+}
+
+// ingressClassInformer returns the informer for the newest version of
+// the IngressClass API served by the API server, or nil if the API
+// server does not serve IngressClasses.
+func ingressClassInformer(client kubernetes.Interface, inf coreinformers.SharedInformerFactory) cache.SharedIndexInformer {
+	switch {
+	case serverHasResource(client, "networking.k8s.io/v1", "ingressclasses"):
+		return inf.Networking().V1().IngressClasses().Informer()
+	case serverHasResource(client, "networking.k8s.io/v1beta1", "ingressclasses"):
+		return inf.Networking().V1beta1().IngressClasses().Informer()
+	default:
+		return nil
+	}
+}
+
+// serverHasResource returns true if the API server serves
+// the resource in the group version.
+func serverHasResource(client kubernetes.Interface, groupVersion, resource string) bool {
+	resources, err := client.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource {
+			return true
+		}
+	}
+	return false
+}
+
 type informer interface {
 	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
 	Start(stopCh <-chan struct{})
@@ -323,12 +472,13 @@ type informer interface {
 
 func startInformer(inf informer, log logrus.FieldLogger) func(stop <-chan struct{}) error {
 	return func(stop <-chan struct{}) error {
-		log.Println("waiting for cache sync")
-		inf.WaitForCacheSync(stop)
-
 		log.Println("started")
 		defer log.Println("stopped")
 		inf.Start(stop)
+
+		log.Println("waiting for cache sync")
+		inf.WaitForCacheSync(stop)
+		log.Println("cache synced")
 		<-stop
 		return nil
 	}
@@ -18,16 +18,25 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
 	"github.com/projectcontour/contour/internal/contour"
+	"github.com/projectcontour/contour/internal/envoy"
+	cgrpc "github.com/projectcontour/contour/internal/grpc"
+	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type serveContext struct {
@@ -48,12 +57,26 @@ type serveContext struct {
 	metricsAddr string
 	metricsPort int
 
+	// contour's admission webhook parameters
+	webhookAddr             string
+	webhookPort             int
+	webhookCert, webhookKey string
+
 	// ingressroute root namespaces
 	rootNamespaces string
 
+	// namespaces contour is restricted to watching
+	watchNamespaces string
+
 	// ingress class
 	ingressClass string
 
+	// controller name of Contour's IngressClass objects
+	ingressClassController string
+
+	// controller name of Contour's GatewayClass objects
+	gatewayController string
+
 	// envoy's stats listener parameters
 	statsAddr string
 	statsPort int
@@ -61,6 +84,13 @@ type serveContext struct {
 	// envoy's listener parameters
 	useProxyProto bool
 
+	// discover endpoints from EndpointSlices rather than Endpoints
+	useEndpointSlices bool
+
+	// weight endpoints by the projectcontour.io/endpoint-weight
+	// annotation of their Pod, which requires watching every Pod
+	useEndpointWeights bool
+
 	// envoy's http listener parameters
 	httpAddr      string
 	httpPort      int
@@ -95,6 +125,18 @@ type serveContext struct {
 
 	// LeaderElectionConfig can be set in the config file.
 	LeaderElectionConfig `yaml:"leaderelection,omitempty"`
+
+	// FleetConfig can be set in the config file.
+	FleetConfig `yaml:"fleets,omitempty"`
+
+	// AuthorizationConfig can be set in the config file.
+	AuthorizationConfig `yaml:"xds-authorization,omitempty"`
+
+	// GRPCConfig can be set in the config file.
+	GRPCConfig `yaml:"grpc,omitempty"`
+
+	// ExternalNameConfig can be set in the config file.
+	ExternalNameConfig `yaml:"externalname,omitempty"`
 }
 
 // newServeContext returns a serveContext initialized to defaults.
@@ -110,6 +152,8 @@ func newServeContext() *serveContext {
 		debugPort:             6060,
 		metricsAddr:           "0.0.0.0",
 		metricsPort:           8000,
+		webhookAddr:           "0.0.0.0",
+		webhookPort:           9443,
 		httpAccessLog:         contour.DEFAULT_HTTP_ACCESS_LOG,
 		httpsAccessLog:        contour.DEFAULT_HTTPS_ACCESS_LOG,
 		httpAddr:              "0.0.0.0",
@@ -150,12 +194,23 @@ func newServeContext() *serveContext {
 			Namespace:     "projectcontour",
 			Name:          "leader-elect",
 		},
+		FleetConfig: FleetConfig{
+			MetadataKey: contour.DEFAULT_FLEET_METADATA_KEY,
+		},
+		GRPCConfig: GRPCConfig{
+			KeepaliveMinTime: 5 * time.Minute,
+			ShutdownTimeout:  10 * time.Second,
+		},
 	}
 }
 
 // TLSConfig holds configuration file TLS configuration details.
 type TLSConfig struct {
 	MinimumProtocolVersion string `yaml:"minimum-protocol-version"`
+
+	// ClientCertificate is the namespace/name of the kubernetes.io/tls
+	// Secret Envoy presents to upstream services connected to with TLS.
+	ClientCertificate string `yaml:"envoy-client-certificate,omitempty"`
 }
 
 // LeaderElectionConfig holds the config bits for leader election inside the
@@ -168,10 +223,200 @@ type LeaderElectionConfig struct {
 	Name          string        `yaml:"configmap-name,omitempty"`
 }
 
+// FleetConfig holds the config bits for assigning Envoy nodes to
+// fleets inside the configuration file.
+type FleetConfig struct {
+	// MetadataKey is the Envoy node metadata key which names the node's fleet.
+	MetadataKey string `yaml:"metadata-key,omitempty"`
+
+	// NodeIDPrefixes maps a fleet name to the node ID prefix of its members.
+	NodeIDPrefixes map[string]string `yaml:"node-id-prefixes,omitempty"`
+}
+
+// fleetSelector returns a *contour.FleetSelector configured
+// according to the context's FleetConfig.
+func (ctx *serveContext) fleetSelector() *contour.FleetSelector {
+	return &contour.FleetSelector{
+		MetadataKey:    ctx.FleetConfig.MetadataKey,
+		NodeIDPrefixes: ctx.FleetConfig.NodeIDPrefixes,
+	}
+}
+
+// GRPCConfig holds the config bits for the xDS gRPC server inside the
+// configuration file.
+type GRPCConfig struct {
+	// KeepaliveTime is the period after which, if a connection is idle,
+	// Contour pings the client. If zero, defaults to two hours.
+	KeepaliveTime time.Duration `yaml:"keepalive-time,omitempty"`
+
+	// KeepaliveTimeout is how long Contour waits for a ping to be
+	// acknowledged before closing the connection.
+	// If zero, defaults to twenty seconds.
+	KeepaliveTimeout time.Duration `yaml:"keepalive-timeout,omitempty"`
+
+	// KeepaliveMinTime is the minimum period clients must wait between
+	// pings. Clients which ping more often are disconnected.
+	KeepaliveMinTime time.Duration `yaml:"keepalive-min-time,omitempty"`
+
+	// KeepalivePermitWithoutStream permits clients to ping when they
+	// have no active streams.
+	KeepalivePermitWithoutStream bool `yaml:"keepalive-permit-without-stream,omitempty"`
+
+	// MaxConnectionAge is the maximum age of a connection, after which
+	// the client is asked to reconnect, allowing clients to rebalance
+	// across Contour replicas. The age of each connection is jittered
+	// by +/- 10% so that clients do not reconnect in lockstep.
+	// If zero, connections are not aged out.
+	MaxConnectionAge time.Duration `yaml:"max-connection-age,omitempty"`
+
+	// MaxConnectionAgeGrace is how long streams on a connection which
+	// has reached MaxConnectionAge may continue before the connection
+	// is closed. If zero, streams may continue indefinitely.
+	MaxConnectionAgeGrace time.Duration `yaml:"max-connection-age-grace,omitempty"`
+
+	// MaxStreamsPerClient is the maximum number of concurrent streams
+	// each client, identified by its certificate or IP address, may open.
+	// If zero, the number is not limited.
+	MaxStreamsPerClient int `yaml:"max-streams-per-client,omitempty"`
+
+	// ShutdownTimeout is how long Contour waits for active streams to
+	// finish on shutdown before closing them.
+	ShutdownTimeout time.Duration `yaml:"shutdown-timeout,omitempty"`
+}
+
+// ExternalNameConfig holds the config bits for Services of type
+// ExternalName inside the configuration file.
+type ExternalNameConfig struct {
+	// Enable permits routing to Services of type ExternalName, which
+	// point Envoy at an arbitrary DNS name. Disabled by default.
+	Enable bool `yaml:"enable,omitempty"`
+
+	// DNSRefreshRate is how often Envoy resolves the names of
+	// ExternalName Services. If zero, Envoy's default of five
+	// seconds applies.
+	DNSRefreshRate time.Duration `yaml:"dns-refresh-rate,omitempty"`
+
+	// DNSLookupFamily is the IP family Envoy resolves the names of
+	// ExternalName Services to, one of "auto", "v4", or "v6".
+	// If empty, defaults to "auto".
+	DNSLookupFamily string `yaml:"dns-lookup-family,omitempty"`
+
+	// DeniedNames are names, and the subdomains of names, which
+	// ExternalName Services may not point at, in addition to
+	// localhost, loopback and link local addresses, and well known
+	// metadata service names.
+	DeniedNames []string `yaml:"denied-names,omitempty"`
+}
+
+// checkExternalNameConfig returns an error if the
+// context's ExternalNameConfig is invalid.
+func (ctx *serveContext) checkExternalNameConfig() error {
+	switch family := ctx.ExternalNameConfig.DNSLookupFamily; family {
+	case "", "auto", "v4", "v6":
+	default:
+		return fmt.Errorf("invalid ExternalName DNS lookup family %q: must be one of auto, v4, or v6", family)
+	}
+	if ctx.ExternalNameConfig.DNSRefreshRate < 0 {
+		return fmt.Errorf("invalid ExternalName DNS refresh rate %v: must not be negative", ctx.ExternalNameConfig.DNSRefreshRate)
+	}
+	return nil
+}
+
+// AuthorizationConfig holds the config bits for authorizing xDS
+// clients inside the configuration file.
+type AuthorizationConfig struct {
+	// Identities maps a client identity, a SAN of its certificate,
+	// to its permissions. Entries replace the built in permissions
+	// of the same identity.
+	Identities map[string]PermissionsConfig `yaml:"identities,omitempty"`
+
+	// Default, if set, replaces the built in permissions of clients
+	// whose identity does not appear in Identities.
+	Default *PermissionsConfig `yaml:"default,omitempty"`
+}
+
+// PermissionsConfig describes the xDS resources a client may stream.
+type PermissionsConfig struct {
+	// Resources lists the kinds of resource the client may stream.
+	// Valid entries are cluster, endpoint, listener, route, and secret.
+	Resources []string `yaml:"resources,omitempty"`
+
+	// Names lists the names, or glob patterns of the names, of the
+	// resources the client may stream. If empty, any name is permitted.
+	Names []string `yaml:"names,omitempty"`
+}
+
+// resourceTypes maps the resource kinds accepted in a
+// PermissionsConfig to their type URLs.
+var resourceTypes = map[string]string{
+	"cluster":  resource.ClusterType,
+	"endpoint": resource.EndpointType,
+	"listener": resource.ListenerType,
+	"route":    resource.RouteType,
+	"secret":   resource.SecretType,
+}
+
+func (pc *PermissionsConfig) permissions() (cgrpc.Permissions, error) {
+	perms := cgrpc.Permissions{
+		ResourceNames: pc.Names,
+	}
+	for _, r := range pc.Resources {
+		typeURL, ok := resourceTypes[r]
+		if !ok {
+			return cgrpc.Permissions{}, fmt.Errorf("unknown xds-authorization resource %q", r)
+		}
+		perms.TypeURLs = append(perms.TypeURLs, typeURL)
+	}
+	return perms, nil
+}
+
+// authorizer returns the *cgrpc.Authorizer for xDS clients, or nil
+// if ctx.PermitInsecureGRPC is true, as without TLS clients cannot
+// be identified.
+func (ctx *serveContext) authorizer() (*cgrpc.Authorizer, error) {
+	if ctx.PermitInsecureGRPC {
+		return nil, nil
+	}
+	auth := cgrpc.NewAuthorizer()
+	for identity, pc := range ctx.AuthorizationConfig.Identities {
+		perms, err := pc.permissions()
+		if err != nil {
+			return nil, err
+		}
+		auth.Identities[identity] = perms
+	}
+	if pc := ctx.AuthorizationConfig.Default; pc != nil {
+		perms, err := pc.permissions()
+		if err != nil {
+			return nil, err
+		}
+		auth.Default = perms
+	}
+	return auth, nil
+}
+
+// xdsListen returns a net.Listener for the xDS gRPC server, and its
+// address. If ctx.xdsAddr is prefixed with unix://, the listener is
+// bound to the named Unix domain socket, replacing any stale socket
+// left by a previous run, otherwise to ctx.xdsAddr:ctx.xdsPort over TCP.
+func (ctx *serveContext) xdsListen() (net.Listener, string, error) {
+	if strings.HasPrefix(ctx.xdsAddr, envoy.UNIX_SOCKET_PREFIX) {
+		path := strings.TrimPrefix(ctx.xdsAddr, envoy.UNIX_SOCKET_PREFIX)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, "", err
+		}
+		l, err := net.Listen("unix", path)
+		return l, ctx.xdsAddr, err
+	}
+	addr := net.JoinHostPort(ctx.xdsAddr, strconv.Itoa(ctx.xdsPort))
+	l, err := net.Listen("tcp", addr)
+	return l, addr, err
+}
+
 // grpcOptions returns a slice of grpc.ServerOptions.
 // if ctx.PermitInsecureGRPC is false, the option set will
 // include TLS configuration.
-func (ctx *serveContext) grpcOptions() []grpc.ServerOption {
+func (ctx *serveContext) grpcOptions(logger logrus.FieldLogger) []grpc.ServerOption {
 	opts := []grpc.ServerOption{
 		// By default the Go grpc library defaults to a value of ~100 streams per
 		// connection. This number is likely derived from the HTTP/2 spec:
@@ -182,6 +427,23 @@ func (ctx *serveContext) grpcOptions() []grpc.ServerOption {
 		//
 		// Somewhat arbitrary limit to handle many, many, EDS streams.
 		grpc.MaxConcurrentStreams(1 << 20),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  ctx.GRPCConfig.KeepaliveTime,
+			Timeout:               ctx.GRPCConfig.KeepaliveTimeout,
+			MaxConnectionAge:      ctx.GRPCConfig.MaxConnectionAge,
+			MaxConnectionAgeGrace: ctx.GRPCConfig.MaxConnectionAgeGrace,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             ctx.GRPCConfig.KeepaliveMinTime,
+			PermitWithoutStream: ctx.GRPCConfig.KeepalivePermitWithoutStream,
+		}),
+	}
+	if ctx.GRPCConfig.MaxStreamsPerClient > 0 {
+		limiter := &cgrpc.StreamLimiter{
+			FieldLogger: logger,
+			MaxStreams:  ctx.GRPCConfig.MaxStreamsPerClient,
+		}
+		opts = append(opts, limiter.ServerOptions()...)
 	}
 	if !ctx.PermitInsecureGRPC {
 		tlsconfig := ctx.tlsconfig()
@@ -229,15 +491,71 @@ func (ctx *serveContext) verifyTLSFlags() error {
 	return nil
 }
 
+// clientCertificate returns the name of the Secret Envoy presents to
+// upstream services, or nil if not configured.
+func (ctx *serveContext) clientCertificate() (*types.NamespacedName, error) {
+	if ctx.TLSConfig.ClientCertificate == "" {
+		return nil, nil
+	}
+	parts := strings.Split(ctx.TLSConfig.ClientCertificate, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid envoy-client-certificate %q: must be of the form namespace/name", ctx.TLSConfig.ClientCertificate)
+	}
+	return &types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
+
 // ingressRouteRootNamespaces returns a slice of namespaces restricting where
 // contour should look for ingressroute roots.
 func (ctx *serveContext) ingressRouteRootNamespaces() []string {
-	if strings.TrimSpace(ctx.rootNamespaces) == "" {
+	return splitNamespaces(ctx.rootNamespaces)
+}
+
+// watchedNamespaces returns a slice of namespaces restricting where
+// contour watches resources, or nil if contour watches all namespaces.
+func (ctx *serveContext) watchedNamespaces() []string {
+	return splitNamespaces(ctx.watchNamespaces)
+}
+
+// checkWatchNamespaces returns an error if contour is restricted to
+// watching namespaces which exclude a root namespace, or the namespace
+// of the client certificate, as their objects would be ignored.
+func (ctx *serveContext) checkWatchNamespaces() error {
+	watched := ctx.watchedNamespaces()
+	if len(watched) == 0 {
+		return nil
+	}
+	isWatched := func(namespace string) bool {
+		for _, ns := range watched {
+			if ns == namespace {
+				return true
+			}
+		}
+		return false
+	}
+	for _, ns := range ctx.ingressRouteRootNamespaces() {
+		if !isWatched(ns) {
+			return fmt.Errorf("root namespace %q is not one of the watched namespaces", ns)
+		}
+	}
+	clientCertificate, err := ctx.clientCertificate()
+	if err != nil {
+		return err
+	}
+	if clientCertificate != nil && !isWatched(clientCertificate.Namespace) {
+		return fmt.Errorf("envoy-client-certificate namespace %q is not one of the watched namespaces", clientCertificate.Namespace)
+	}
+	return nil
+}
+
+// splitNamespaces returns the comma separated namespaces
+// in s, or nil if s is blank.
+func splitNamespaces(s string) []string {
+	if strings.TrimSpace(s) == "" {
 		return nil
 	}
 	var ns []string
-	for _, s := range strings.Split(ctx.rootNamespaces, ",") {
-		ns = append(ns, strings.TrimSpace(s))
+	for _, n := range strings.Split(s, ",") {
+		ns = append(ns, strings.TrimSpace(n))
 	}
 	return ns
 }
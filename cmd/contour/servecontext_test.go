@@ -14,12 +14,19 @@
 package main
 
 import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
 	"github.com/google/go-cmp/cmp"
+	cgrpc "github.com/projectcontour/contour/internal/grpc"
 	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestServeContextIngressRouteRootNamespaces(t *testing.T) {
@@ -69,6 +76,142 @@ func TestServeContextIngressRouteRootNamespaces(t *testing.T) {
 	}
 }
 
+func TestServeContextClientCertificate(t *testing.T) {
+	tests := map[string]struct {
+		clientCertificate string
+		want              *types.NamespacedName
+		wantErr           bool
+	}{
+		"not set": {
+			clientCertificate: "",
+			want:              nil,
+		},
+		"namespace and name": {
+			clientCertificate: "projectcontour/envoy-client",
+			want:              &types.NamespacedName{Namespace: "projectcontour", Name: "envoy-client"},
+		},
+		"name only": {
+			clientCertificate: "envoy-client",
+			wantErr:           true,
+		},
+		"empty namespace": {
+			clientCertificate: "/envoy-client",
+			wantErr:           true,
+		},
+		"too many parts": {
+			clientCertificate: "projectcontour/envoy/client",
+			wantErr:           true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := serveContext{
+				TLSConfig: TLSConfig{
+					ClientCertificate: tc.clientCertificate,
+				},
+			}
+			got, err := ctx.clientCertificate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestServeContextCheckWatchNamespaces(t *testing.T) {
+	tests := map[string]struct {
+		ctx     serveContext
+		wantErr bool
+	}{
+		"all namespaces": {
+			ctx: serveContext{
+				rootNamespaces: "roots",
+			},
+		},
+		"root namespaces watched": {
+			ctx: serveContext{
+				rootNamespaces:  "roots",
+				watchNamespaces: "roots, tenant",
+			},
+		},
+		"root namespace not watched": {
+			ctx: serveContext{
+				rootNamespaces:  "roots,other",
+				watchNamespaces: "roots,tenant",
+			},
+			wantErr: true,
+		},
+		"client certificate watched": {
+			ctx: serveContext{
+				watchNamespaces: "projectcontour,tenant",
+				TLSConfig: TLSConfig{
+					ClientCertificate: "projectcontour/envoy-client",
+				},
+			},
+		},
+		"client certificate not watched": {
+			ctx: serveContext{
+				watchNamespaces: "tenant",
+				TLSConfig: TLSConfig{
+					ClientCertificate: "projectcontour/envoy-client",
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.ctx.checkWatchNamespaces()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestServeContextCheckExternalNameConfig(t *testing.T) {
+	tests := map[string]struct {
+		config  ExternalNameConfig
+		wantErr bool
+	}{
+		"defaults": {},
+		"enabled with options": {
+			config: ExternalNameConfig{
+				Enable:          true,
+				DNSRefreshRate:  30 * time.Second,
+				DNSLookupFamily: "v4",
+			},
+		},
+		"invalid lookup family": {
+			config: ExternalNameConfig{
+				DNSLookupFamily: "ipv4",
+			},
+			wantErr: true,
+		},
+		"negative refresh rate": {
+			config: ExternalNameConfig{
+				DNSRefreshRate: -time.Second,
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := serveContext{ExternalNameConfig: tc.config}
+			err := ctx.checkExternalNameConfig()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestServeContextTLSParams(t *testing.T) {
 	tests := map[string]struct {
 		ctx         serveContext
@@ -176,6 +319,71 @@ leaderelection:
 				return ctx
 			},
 		},
+		"fleets": {
+			yamlIn: `
+fleets:
+  metadata-key: envoy-fleet
+  node-id-prefixes:
+    internal: envoy-internal-
+    external: envoy-external-
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.FleetConfig.MetadataKey = "envoy-fleet"
+				ctx.FleetConfig.NodeIDPrefixes = map[string]string{
+					"internal": "envoy-internal-",
+					"external": "envoy-external-",
+				}
+				return ctx
+			},
+		},
+		"grpc": {
+			yamlIn: `
+grpc:
+  keepalive-time: 60s
+  keepalive-min-time: 30s
+  keepalive-permit-without-stream: true
+  max-connection-age: 30m
+  max-connection-age-grace: 1m
+  max-streams-per-client: 1000
+  shutdown-timeout: 20s
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.GRPCConfig.KeepaliveTime = 60 * time.Second
+				ctx.GRPCConfig.KeepaliveMinTime = 30 * time.Second
+				ctx.GRPCConfig.KeepalivePermitWithoutStream = true
+				ctx.GRPCConfig.MaxConnectionAge = 30 * time.Minute
+				ctx.GRPCConfig.MaxConnectionAgeGrace = time.Minute
+				ctx.GRPCConfig.MaxStreamsPerClient = 1000
+				ctx.GRPCConfig.ShutdownTimeout = 20 * time.Second
+				return ctx
+			},
+		},
+		"xds authorization": {
+			yamlIn: `
+xds-authorization:
+  identities:
+    monitor:
+      resources: [cluster, endpoint]
+      names: ["default/*"]
+  default:
+    resources: []
+`,
+			want: func() *serveContext {
+				ctx := newServeContext()
+				ctx.AuthorizationConfig.Identities = map[string]PermissionsConfig{
+					"monitor": {
+						Resources: []string{"cluster", "endpoint"},
+						Names:     []string{"default/*"},
+					},
+				}
+				ctx.AuthorizationConfig.Default = &PermissionsConfig{
+					Resources: []string{},
+				}
+				return ctx
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -197,3 +405,123 @@ func checkErr(t *testing.T, err error) {
 		t.Error(err)
 	}
 }
+
+func TestServeContextAuthorizer(t *testing.T) {
+	tests := map[string]struct {
+		ctx         serveContext
+		want        *cgrpc.Authorizer
+		expecterror bool
+	}{
+		"insecure": {
+			ctx: serveContext{
+				PermitInsecureGRPC: true,
+			},
+			want: nil,
+		},
+		"defaults": {
+			ctx:  serveContext{},
+			want: cgrpc.NewAuthorizer(),
+		},
+		"additional identity": {
+			ctx: serveContext{
+				AuthorizationConfig: AuthorizationConfig{
+					Identities: map[string]PermissionsConfig{
+						"monitor": {
+							Resources: []string{"cluster"},
+							Names:     []string{"default/*"},
+						},
+					},
+				},
+			},
+			want: func() *cgrpc.Authorizer {
+				auth := cgrpc.NewAuthorizer()
+				auth.Identities["monitor"] = cgrpc.Permissions{
+					TypeURLs:      []string{resource.ClusterType},
+					ResourceNames: []string{"default/*"},
+				}
+				return auth
+			}(),
+		},
+		"restricted default": {
+			ctx: serveContext{
+				AuthorizationConfig: AuthorizationConfig{
+					Default: &PermissionsConfig{
+						Resources: []string{"listener", "route"},
+					},
+				},
+			},
+			want: func() *cgrpc.Authorizer {
+				auth := cgrpc.NewAuthorizer()
+				auth.Default = cgrpc.Permissions{
+					TypeURLs: []string{resource.ListenerType, resource.RouteType},
+				}
+				return auth
+			}(),
+		},
+		"unknown resource": {
+			ctx: serveContext{
+				AuthorizationConfig: AuthorizationConfig{
+					Default: &PermissionsConfig{
+						Resources: []string{"potato"},
+					},
+				},
+			},
+			expecterror: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.ctx.authorizer()
+			goterror := err != nil
+			if goterror != tc.expecterror {
+				t.Fatalf("expected error: %v, got: %v", tc.expecterror, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestServeContextXDSListen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "contour")
+	checkErr(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "xds.sock")
+	ctx := serveContext{
+		xdsAddr: "unix://" + path,
+	}
+
+	// leave a stale socket behind, as a previous run would on crashing.
+	stale, err := net.Listen("unix", path)
+	checkErr(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	l, addr, err := ctx.xdsListen()
+	checkErr(t, err)
+	defer l.Close()
+
+	if addr != ctx.xdsAddr {
+		t.Fatalf("expected: %q, got: %q", ctx.xdsAddr, addr)
+	}
+	if got := l.Addr().Network(); got != "unix" {
+		t.Fatalf("expected: %q, got: %q", "unix", got)
+	}
+
+	ctx = serveContext{
+		xdsAddr: "127.0.0.1",
+		xdsPort: 0,
+	}
+	l, addr, err = ctx.xdsListen()
+	checkErr(t, err)
+	defer l.Close()
+
+	if addr != "127.0.0.1:0" {
+		t.Fatalf("expected: %q, got: %q", "127.0.0.1:0", addr)
+	}
+	if got := l.Addr().Network(); got != "tcp" {
+		t.Fatalf("expected: %q, got: %q", "tcp", got)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/httpsvc"
+	"github.com/projectcontour/contour/internal/webhook"
+	"github.com/projectcontour/contour/internal/workgroup"
+	"github.com/sirupsen/logrus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	"k8s.io/client-go/tools/cache"
+)
+
+// registerWebhook registers the webhook subcommand and flags
+// with the Application provided.
+func registerWebhook(app *kingpin.Application) (*kingpin.CmdClause, *serveContext) {
+	wh := app.Command("webhook", "Serve a validating admission webhook for IngressRoute and HTTPProxy objects")
+
+	ctx := newServeContext()
+	registerConfigPath(wh, ctx)
+
+	wh.Flag("incluster", "use in cluster configuration.").BoolVar(&ctx.InCluster)
+	wh.Flag("kubeconfig", "path to kubeconfig (if not in running inside a cluster)").StringVar(&ctx.Kubeconfig)
+
+	wh.Flag("webhook-address", "address the admission webhook will bind to").StringVar(&ctx.webhookAddr)
+	wh.Flag("webhook-port", "port the admission webhook will bind to").IntVar(&ctx.webhookPort)
+	wh.Flag("webhook-cert-file", "certificate file name for serving the admission webhook over TLS").Envar("WEBHOOK_CERT_FILE").StringVar(&ctx.webhookCert)
+	wh.Flag("webhook-key-file", "key file name for serving the admission webhook over TLS").Envar("WEBHOOK_KEY_FILE").StringVar(&ctx.webhookKey)
+
+	wh.Flag("root-namespaces", "Restrict contour to searching these namespaces for root ingress routes").StringVar(&ctx.rootNamespaces)
+	wh.Flag("watch-namespaces", "Restrict contour to watching resources in these namespaces").StringVar(&ctx.watchNamespaces)
+	wh.Flag("ingress-class-name", "Contour IngressClass name").StringVar(&ctx.ingressClass)
+	wh.Flag("enable-external-name-service", "Permit routing to Services of type ExternalName").BoolVar(&ctx.ExternalNameConfig.Enable)
+	return wh, ctx
+}
+
+// doWebhook runs the contour webhook subcommand.
+func doWebhook(log logrus.FieldLogger, ctx *serveContext) error {
+	clientCertificate, err := ctx.clientCertificate()
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.checkWatchNamespaces(); err != nil {
+		return err
+	}
+
+	client, contourClient, _, _ := newClient(ctx.Kubeconfig, ctx.InCluster)
+
+	informers := newNamespacedInformers(client, contourClient, ctx.watchedNamespaces())
+
+	svc := &webhook.Service{
+		Service: httpsvc.Service{
+			Addr:        ctx.webhookAddr,
+			Port:        ctx.webhookPort,
+			CertFile:    ctx.webhookCert,
+			KeyFile:     ctx.webhookKey,
+			FieldLogger: log.WithField("context", "webhook"),
+		},
+		Builder: dag.Builder{
+			Source: dag.KubernetesCache{
+				RootNamespaces:    ctx.ingressRouteRootNamespaces(),
+				WatchNamespaces:   ctx.watchedNamespaces(),
+				IngressClass:      ctx.ingressClass,
+				ClientCertificate: clientCertificate,
+				FieldLogger:       log.WithField("context", "KubernetesCache"),
+			},
+			DisablePermitInsecure:     ctx.DisablePermitInsecure,
+			EnableExternalNameService: ctx.ExternalNameConfig.Enable,
+			ExternalNameDeniedNames:   ctx.ExternalNameConfig.DeniedNames,
+		},
+	}
+
+	// register adds svc as an event handler of inf, and waits
+	// for inf to sync before serving.
+	register := func(inf cache.SharedIndexInformer) {
+		inf.AddEventHandler(svc)
+		svc.HasSynced = append(svc.HasSynced, inf.HasSynced)
+	}
+	for _, inf := range informers.coreFactories() {
+		register(inf.Core().V1().Services().Informer())
+	}
+	for _, inf := range informers.contourFactories() {
+		register(inf.Contour().V1beta1().IngressRoutes().Informer())
+		register(inf.Contour().V1beta1().TLSCertificateDelegations().Informer())
+		register(inf.Projectcontour().V1alpha1().HTTPProxies().Informer())
+		register(inf.Projectcontour().V1alpha1().TLSCertificateDelegations().Informer())
+		register(inf.Projectcontour().V1alpha1().ServiceDelegations().Informer())
+	}
+	for _, inf := range informers.secretFactories(ctx.ingressRouteRootNamespaces()) {
+		register(inf.Core().V1().Secrets().Informer())
+	}
+	if informers.watchesAllNamespaces() {
+		register(informers.allNamespaces().Core().V1().Namespaces().Informer())
+	}
+
+	var g workgroup.Group
+	informers.start(&g, log)
+	g.Add(svc.Start)
+
+	return g.Run()
+}
@@ -3,41 +3,33 @@ module github.com/projectcontour/contour
 go 1.13
 
 require (
-	cloud.google.com/go v0.37.4 // indirect
 	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751 // indirect
 	github.com/alecthomas/units v0.0.0-20190717042225-c3de453c63f4 // indirect
 	github.com/client9/misspell v0.3.4
-	github.com/envoyproxy/go-control-plane v0.9.0
-	github.com/evanphx/json-patch v4.1.0+incompatible
-	github.com/golang/groupcache v0.0.0-20190129154638-5b532d6fd5ef // indirect
-	github.com/golang/protobuf v1.3.2
-	github.com/google/go-cmp v0.3.1
-	github.com/google/uuid v1.0.0
-	github.com/googleapis/gnostic v0.2.0 // indirect
+	github.com/envoyproxy/go-control-plane v0.9.5
+	github.com/evanphx/json-patch v4.9.0+incompatible
+	github.com/golang/protobuf v1.5.0
+	github.com/google/go-cmp v0.5.5
+	github.com/google/uuid v1.1.2
 	github.com/gordonklaus/ineffassign v0.0.0-20190601041439-ed7b1b5ee0f8
-	github.com/hashicorp/golang-lru v0.5.1 // indirect
-	github.com/imdario/mergo v0.3.7 // indirect
-	github.com/kisielk/errcheck v1.2.0
-	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
+	github.com/kisielk/errcheck v1.5.0
 	github.com/mdempsky/unconvert v0.0.0-20190325185700-2f5dc3378ed3
-	github.com/prometheus/client_golang v1.1.0
-	github.com/prometheus/client_model v0.0.0-20190812154241-14fe0d1b01d4
-	github.com/sirupsen/logrus v1.4.2
-	github.com/spf13/pflag v1.0.3 // indirect
-	golang.org/x/sys v0.0.0-20190825160603-fb81701db80f // indirect
-	golang.org/x/text v0.3.2 // indirect
-	golang.org/x/tools v0.0.0-20190916034716-92af9d69eff2 // indirect
-	google.golang.org/grpc v1.23.0
+	github.com/prometheus/client_golang v1.7.1
+	github.com/prometheus/client_model v0.2.0
+	github.com/sirupsen/logrus v1.8.1
+	google.golang.org/grpc v1.27.1
+	google.golang.org/protobuf v1.26.0
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
-	gopkg.in/inf.v0 v0.9.1 // indirect
-	gopkg.in/yaml.v2 v2.2.2
-	honnef.co/go/tools v0.0.1-2019.2.3
-	k8s.io/api v0.0.0-20190620084959-7cf5895f2711
-	k8s.io/apimachinery v0.0.0-20190612205821-1799e75a0719
-	k8s.io/client-go v0.0.0-20190620085101-78d2af792bab
-	k8s.io/code-generator v0.0.0-20190311093542-50b561225d70
-	k8s.io/gengo v0.0.0-20190116091435-f8a0810f38af // indirect
-	k8s.io/klog v0.3.1
-	k8s.io/utils v0.0.0-20190607212802-c55fbcfc754a // indirect
+	gopkg.in/yaml.v2 v2.4.0
+	honnef.co/go/tools v0.0.1-2020.1.3
+	k8s.io/api v0.21.14
+	k8s.io/apiextensions-apiserver v0.21.14
+	k8s.io/apimachinery v0.21.14
+	k8s.io/client-go v0.21.14
+	k8s.io/code-generator v0.21.14
+	k8s.io/klog/v2 v2.9.0
 	mvdan.cc/unparam v0.0.0-20190720180237-d51796306d8f
+	sigs.k8s.io/controller-tools v0.5.0
+	sigs.k8s.io/gateway-api v0.3.0
+	sigs.k8s.io/yaml v1.2.0
 )
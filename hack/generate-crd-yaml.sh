@@ -0,0 +1,35 @@
+#!/bin/bash -e
+#
+# Copyright © 2019 VMware
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Generates the projectcontour.io CRDs from the types in
+# apis/projectcontour and writes them to examples/contour/01-crds.yaml.
+
+TMPDIR=$(mktemp -d)
+trap 'rm -rf ${TMPDIR}' EXIT
+
+go run sigs.k8s.io/controller-tools/cmd/controller-gen \
+  crd:crdVersions=v1 \
+  paths=./apis/projectcontour/... \
+  output:crd:dir=${TMPDIR}
+
+OUTPUT=examples/contour/01-crds.yaml
+rm -f ${OUTPUT}
+for crd in ${TMPDIR}/*.yaml; do
+  # Drop the document separator, the server populated
+  # creationTimestamp, and the status block controller-gen emits.
+  sed -e '1{/^$/d;}' -e '/^---$/d' -e '/creationTimestamp: null/d' -e '/^status:/,$d' ${crd} >> ${OUTPUT}
+  echo '---' >> ${OUTPUT}
+done
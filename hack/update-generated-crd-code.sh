@@ -14,12 +14,9 @@
 # See the License for the specific language governing permissions and
 # limitations under the License.
 
-VERSION=$(go list -m all | grep k8s.io/code-generator | rev | cut -d"-" -f1 | cut -d" " -f1 | rev)
-TMP_DIR=$(mktemp -d)
-git clone https://github.com/kubernetes/code-generator.git ${TMP_DIR}
-(cd ${TMP_DIR} && git reset --hard ${VERSION} && go mod init)
+CODEGEN_PKG=$(go list -m -f '{{.Dir}}' k8s.io/code-generator)
 
-${TMP_DIR}/generate-groups.sh \
+bash ${CODEGEN_PKG}/generate-groups.sh \
   all \
   github.com/projectcontour/contour/apis/generated \
   github.com/projectcontour/contour/apis \
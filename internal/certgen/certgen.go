@@ -16,9 +16,13 @@
 package certgen
 
 import (
+	"context"
 	"fmt"
 	"path"
+	"sort"
+	"strings"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -41,19 +45,17 @@ func WriteCertsPEM(outputDir string, certdata map[string][]byte) error {
 	if err != nil {
 		return err
 	}
-	err = writePEM(outputDir, "contourcert.pem", certdata["contourcert.pem"])
-	if err != nil {
-		return err
-	}
-	err = writePEM(outputDir, "contourkey.pem", certdata["contourkey.pem"])
-	if err != nil {
-		return err
+	for _, service := range keyPairs(certdata) {
+		err = writePEM(outputDir, service+"cert.pem", certdata[service+"cert.pem"])
+		if err != nil {
+			return err
+		}
+		err = writePEM(outputDir, service+"key.pem", certdata[service+"key.pem"])
+		if err != nil {
+			return err
+		}
 	}
-	err = writePEM(outputDir, "envoycert.pem", certdata["envoycert.pem"])
-	if err != nil {
-		return err
-	}
-	return writePEM(outputDir, "envoykey.pem", certdata["envoykey.pem"])
+	return nil
 
 }
 
@@ -64,12 +66,13 @@ func WriteSecretsYAML(outputDir, namespace string, certdata map[string][]byte) e
 	if err != nil {
 		return err
 	}
-	err = writeKeyPairSecret(outputDir, "contour", namespace, certdata["contourcert.pem"], certdata["contourkey.pem"])
-	if err != nil {
-		return err
+	for _, service := range keyPairs(certdata) {
+		err = writeKeyPairSecret(outputDir, service, namespace, certdata[service+"cert.pem"], certdata[service+"key.pem"])
+		if err != nil {
+			return err
+		}
 	}
-
-	return writeKeyPairSecret(outputDir, "envoy", namespace, certdata["envoycert.pem"], certdata["envoykey.pem"])
+	return nil
 
 }
 
@@ -80,13 +83,31 @@ func WriteSecretsKube(client *kubernetes.Clientset, namespace string, certdata m
 	if err != nil {
 		return err
 	}
-	err = writeKeyPairKube(client, "contour", namespace, certdata["contourcert.pem"], certdata["contourkey.pem"])
-	if err != nil {
-		return err
+	for _, service := range keyPairs(certdata) {
+		err = writeKeyPairKube(client, service, namespace, certdata[service+"cert.pem"], certdata[service+"key.pem"])
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 
-	return writeKeyPairKube(client, "envoy", namespace, certdata["envoycert.pem"], certdata["envoykey.pem"])
+}
 
+// keyPairs returns the sorted names of the services for which
+// certdata holds both a <service>cert.pem and a <service>key.pem.
+func keyPairs(certdata map[string][]byte) []string {
+	var services []string
+	for filename := range certdata {
+		if !strings.HasSuffix(filename, "cert.pem") || filename == "cacert.pem" {
+			continue
+		}
+		service := strings.TrimSuffix(filename, "cert.pem")
+		if _, ok := certdata[service+"key.pem"]; ok {
+			services = append(services, service)
+		}
+	}
+	sort.Strings(services)
+	return services
 }
 
 func writeCACertSecret(outputDir, namespace string, cert []byte) error {
@@ -101,7 +122,7 @@ func writeCACertSecret(outputDir, namespace string, cert []byte) error {
 
 func writeCACertKube(client *kubernetes.Clientset, namespace string, cert []byte) error {
 	secret := newCertOnlySecret("cacert", namespace, "cacert.pem", cert)
-	_, err := client.CoreV1().Secrets(namespace).Create(secret)
+	_, err := client.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
 	if err != nil {
 		return err
 	}
@@ -126,7 +147,7 @@ func writeKeyPairSecret(outputDir, service, namespace string, cert, key []byte)
 func writeKeyPairKube(client *kubernetes.Clientset, service, namespace string, cert, key []byte) error {
 	secretname := service + "cert"
 	secret := newTLSSecret(secretname, namespace, key, cert)
-	_, err := client.CoreV1().Secrets(namespace).Create(secret)
+	_, err := client.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
 	if err != nil {
 		return err
 	}
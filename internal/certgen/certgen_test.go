@@ -17,6 +17,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -92,3 +93,22 @@ func verifyCert(certPEM []byte, roots *x509.CertPool, dnsname string) error {
 
 	return nil
 }
+
+func TestKeyPairs(t *testing.T) {
+	certdata := map[string][]byte{
+		"cacert.pem":      nil,
+		"contourcert.pem": nil,
+		"contourkey.pem":  nil,
+		"envoycert.pem":   nil,
+		"envoykey.pem":    nil,
+		"monitorcert.pem": nil,
+		"monitorkey.pem":  nil,
+		"orphancert.pem":  nil,
+	}
+
+	got := keyPairs(certdata)
+	want := []string{"contour", "envoy", "monitor"}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected: %v, got: %v", want, got)
+	}
+}
@@ -19,6 +19,7 @@ package contour
 import (
 	"time"
 
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/metrics"
 	"github.com/prometheus/client_golang/prometheus"
@@ -57,12 +58,20 @@ func (ch *CacheHandler) updateSecrets(root dag.Visitable) {
 
 func (ch *CacheHandler) updateListeners(root dag.Visitable) {
 	listeners := visitListeners(root, &ch.ListenerVisitorConfig)
-	ch.ListenerCache.Update(listeners)
+	fleets := make(map[string]map[string]*v2.Listener)
+	for _, fleet := range visitFleets(root) {
+		fleets[fleet] = visitFleetListeners(root, &ch.ListenerVisitorConfig, fleet)
+	}
+	ch.ListenerCache.update(listeners, fleets)
 }
 
 func (ch *CacheHandler) updateRoutes(root dag.Visitable) {
 	routes := visitRoutes(root)
-	ch.RouteCache.Update(routes)
+	fleets := make(map[string]map[string]*v2.RouteConfiguration)
+	for _, fleet := range visitFleets(root) {
+		fleets[fleet] = visitFleetRoutes(root, fleet)
+	}
+	ch.RouteCache.update(routes, fleets)
 }
 
 func (ch *CacheHandler) updateClusters(root dag.Visitable) {
@@ -0,0 +1,413 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"sort"
+	"sync"
+
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	"github.com/projectcontour/contour/internal/envoy"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8scache "k8s.io/client-go/tools/cache"
+)
+
+// A EndpointSliceTranslator translates Kubernetes EndpointSlice objects
+// into Envoy ClusterLoadAssignment objects. The endpoints of a service
+// are spread across one or more EndpointSlices, so the translator
+// aggregates the slices of each service before translating them.
+// Like the EndpointsTranslator, it also consumes Node, Pod, and Service
+// objects to group endpoints by locality, to weight endpoints, and to
+// translate not ready endpoints.
+type EndpointSliceTranslator struct {
+	logrus.FieldLogger
+	clusterLoadAssignmentCache
+
+	mu       sync.Mutex
+	topology nodeTopology
+	weights  podWeights
+	services endpointServices
+
+	// slices holds the EndpointSlices of each service, by slice name.
+	slices map[types.NamespacedName]map[string]*discoveryv1.EndpointSlice
+
+	// clusters holds the names of the ClusterLoadAssignments
+	// last computed for each service.
+	clusters map[types.NamespacedName]map[string]bool
+}
+
+func (e *EndpointSliceTranslator) OnAdd(obj interface{}) {
+	switch obj := obj.(type) {
+	case *discoveryv1.EndpointSlice:
+		e.updateSlice(nil, obj)
+	case *v1.Node:
+		e.updateNode(obj)
+	case *v1.Pod:
+		e.updatePod(obj)
+	case *v1.Service:
+		e.updateService(obj)
+	default:
+		e.Errorf("OnAdd unexpected type %T: %#v", obj, obj)
+	}
+}
+
+func (e *EndpointSliceTranslator) OnUpdate(oldObj, newObj interface{}) {
+	switch newObj := newObj.(type) {
+	case *discoveryv1.EndpointSlice:
+		oldObj, ok := oldObj.(*discoveryv1.EndpointSlice)
+		if !ok {
+			e.Errorf("OnUpdate endpointslice %#v received invalid oldObj %T; %#v", newObj, oldObj, oldObj)
+			return
+		}
+		e.updateSlice(oldObj, newObj)
+	case *v1.Node:
+		e.updateNode(newObj)
+	case *v1.Pod:
+		e.updatePod(newObj)
+	case *v1.Service:
+		e.updateService(newObj)
+	default:
+		e.Errorf("OnUpdate unexpected type %T: %#v", newObj, newObj)
+	}
+}
+
+func (e *EndpointSliceTranslator) OnDelete(obj interface{}) {
+	switch obj := obj.(type) {
+	case *discoveryv1.EndpointSlice:
+		e.updateSlice(obj, nil)
+	case *v1.Node:
+		e.removeNode(obj)
+	case *v1.Pod:
+		e.removePod(obj)
+	case *v1.Service:
+		e.removeService(obj)
+	case k8scache.DeletedFinalStateUnknown:
+		e.OnDelete(obj.Obj) // recurse into ourselves with the tombstoned value
+	default:
+		e.Errorf("OnDelete unexpected type %T: %#v", obj, obj)
+	}
+}
+
+func (*EndpointSliceTranslator) TypeURL() string { return resource.EndpointType }
+
+// updateSlice replaces oldslice with newslice, either of which may be
+// nil, and recomputes the ClusterLoadAssignments of their service.
+func (e *EndpointSliceTranslator) updateSlice(oldslice, newslice *discoveryv1.EndpointSlice) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if oldslice != nil {
+		if svc, ok := serviceOf(oldslice); ok {
+			delete(e.slices[svc], oldslice.Name)
+			if newslice == nil || !sameService(oldslice, newslice) {
+				e.recomputeClusterLoadAssignments(svc)
+			}
+		}
+	}
+
+	if newslice != nil {
+		svc, ok := serviceOf(newslice)
+		if !ok {
+			// slices not managed on behalf of a service
+			// cannot be referenced by a cluster.
+			return
+		}
+		if e.slices == nil {
+			e.slices = make(map[types.NamespacedName]map[string]*discoveryv1.EndpointSlice)
+		}
+		if e.slices[svc] == nil {
+			e.slices[svc] = make(map[string]*discoveryv1.EndpointSlice)
+		}
+		e.slices[svc][newslice.Name] = newslice
+		e.recomputeClusterLoadAssignments(svc)
+	}
+}
+
+func (e *EndpointSliceTranslator) updateNode(node *v1.Node) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.topology.updateNode(node) {
+		e.recomputeNode(node.Name)
+	}
+}
+
+func (e *EndpointSliceTranslator) removeNode(node *v1.Node) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.topology.removeNode(node) {
+		e.recomputeNode(node.Name)
+	}
+}
+
+func (e *EndpointSliceTranslator) updatePod(pod *v1.Pod) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.weights.updatePod(pod) {
+		e.recomputePod(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+	}
+}
+
+func (e *EndpointSliceTranslator) removePod(pod *v1.Pod) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.weights.removePod(pod) {
+		e.recomputePod(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+	}
+}
+
+func (e *EndpointSliceTranslator) updateService(svc *v1.Service) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.services.updateService(svc) {
+		e.recomputeClusterLoadAssignments(types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name})
+	}
+}
+
+func (e *EndpointSliceTranslator) removeService(svc *v1.Service) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.services.removeService(svc) {
+		e.recomputeClusterLoadAssignments(types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name})
+	}
+}
+
+// recomputeNode recomputes the ClusterLoadAssignments of the
+// services with an endpoint on the named node.
+func (e *EndpointSliceTranslator) recomputeNode(nodename string) {
+	for svc, slices := range e.slices {
+		for _, slice := range slices {
+			if sliceOnNode(slice, nodename) {
+				e.recomputeClusterLoadAssignments(svc)
+				break
+			}
+		}
+	}
+}
+
+// recomputePod recomputes the ClusterLoadAssignments of the
+// services with an endpoint of the named pod.
+func (e *EndpointSliceTranslator) recomputePod(name types.NamespacedName) {
+	for svc, slices := range e.slices {
+		if svc.Namespace != name.Namespace {
+			continue
+		}
+		for _, slice := range slices {
+			if sliceOfPod(slice, name) {
+				e.recomputeClusterLoadAssignments(svc)
+				break
+			}
+		}
+	}
+}
+
+// recomputeClusterLoadAssignments recomputes the ClusterLoadAssignments
+// of svc from its EndpointSlices, removing those no longer present.
+func (e *EndpointSliceTranslator) recomputeClusterLoadAssignments(svc types.NamespacedName) {
+	meta := metav1.ObjectMeta{Namespace: svc.Namespace, Name: svc.Name}
+
+	policy := e.services.notReadyPolicy(svc)
+
+	// ready, terminating, and notReady hold, by port name, the
+	// addresses of the endpoints which are ready, of those which
+	// are still serving while terminating, and of those which are
+	// otherwise not ready.
+	ready := make(map[string]map[string]localityAddress)
+	terminating := make(map[string]map[string]localityAddress)
+	notReady := make(map[string]map[string]localityAddress)
+	add := func(m map[string]map[string]localityAddress, port string, address string, la localityAddress) {
+		if m[port] == nil {
+			m[port] = make(map[string]localityAddress)
+		}
+		m[port][address] = la
+	}
+
+	for _, slice := range e.slices[svc] {
+		if slice.AddressType != discoveryv1.AddressTypeIPv4 && slice.AddressType != discoveryv1.AddressTypeIPv6 {
+			// skip FQDN slices, which are not supported.
+			continue
+		}
+		for _, p := range slice.Ports {
+			var portname string
+			if p.Name != nil {
+				portname = *p.Name
+			}
+			if p.Port == nil || (p.Protocol != nil && *p.Protocol != v1.ProtocolTCP) {
+				// skip non TCP ports
+				e.Debugf("endpointslice %s/%s: skipping port %q: protocol is not supported", slice.Namespace, slice.Name, portname)
+				continue
+			}
+			for _, name := range e.services.portNames(svc, portname, *p.Port) {
+				for _, ep := range slice.Endpoints {
+					locality := e.endpointLocality(ep)
+					weight := e.weights.weight(ep.TargetRef)
+					for _, a := range ep.Addresses {
+						la := localityAddress{
+							address:  envoy.SocketAddress(a, int(*p.Port)),
+							locality: locality,
+							weight:   weight,
+						}
+						switch {
+						case isReady(ep.Conditions) || policy.publish:
+							add(ready, name, a, la)
+						case isServingTerminating(ep.Conditions):
+							add(terminating, name, a, la)
+						default:
+							add(notReady, name, a, la)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	ports := make(map[string]bool)
+	for _, m := range []map[string]map[string]localityAddress{ready, terminating, notReady} {
+		for p := range m {
+			ports[p] = true
+		}
+	}
+	for port := range ports {
+		addresses := make(map[string]localityAddress)
+		for ip, la := range ready[port] {
+			addresses[ip] = la
+		}
+		unready := []map[string]localityAddress{notReady[port]}
+		if len(addresses) == 0 {
+			// if no endpoints are ready, fall back to those which are
+			// terminating but still serving, so that in flight requests
+			// are not failed while the service rolls over.
+			for ip, la := range terminating[port] {
+				addresses[ip] = la
+			}
+		} else {
+			unready = append(unready, terminating[port])
+		}
+		if policy.include {
+			// send the remaining endpoints with the health status
+			// of the service's policy, rather than removing them.
+			for _, m := range unready {
+				for ip, la := range m {
+					la.healthStatus = policy.status
+					addresses[ip] = la
+				}
+			}
+		}
+		if len(addresses) == 0 {
+			continue
+		}
+
+		ips := make([]string, 0, len(addresses))
+		for ip := range addresses {
+			ips = append(ips, ip)
+		}
+		sort.Strings(ips)
+
+		addrs := make([]localityAddress, 0, len(ips))
+		for _, ip := range ips {
+			addrs = append(addrs, addresses[ip])
+		}
+
+		cla := clusterLoadAssignment(servicename(meta, port), addrs)
+		seen[cla.ClusterName] = true
+		e.Add(cla)
+	}
+
+	// remove the ClusterLoadAssignments of any ports no longer present.
+	for name := range e.clusters[svc] {
+		if !seen[name] {
+			e.Remove(name)
+		}
+	}
+
+	if len(e.slices[svc]) == 0 {
+		delete(e.slices, svc)
+	}
+	if len(seen) == 0 {
+		delete(e.clusters, svc)
+		return
+	}
+	if e.clusters == nil {
+		e.clusters = make(map[types.NamespacedName]map[string]bool)
+	}
+	e.clusters[svc] = seen
+}
+
+// endpointLocality returns the locality of the node ep is running on or,
+// if the node is not known, the locality recorded in the endpoint's zone.
+func (e *EndpointSliceTranslator) endpointLocality(ep discoveryv1.Endpoint) *envoy_api_v2_core.Locality {
+	if ep.NodeName != nil {
+		if l := e.topology.locality(*ep.NodeName); l != nil {
+			return l
+		}
+	}
+	if ep.Zone != nil && *ep.Zone != "" {
+		return &envoy_api_v2_core.Locality{Zone: *ep.Zone}
+	}
+	return nil
+}
+
+// sliceOnNode returns true if any endpoint of slice is on the named node.
+func sliceOnNode(slice *discoveryv1.EndpointSlice, nodename string) bool {
+	for _, ep := range slice.Endpoints {
+		if ep.NodeName != nil && *ep.NodeName == nodename {
+			return true
+		}
+	}
+	return false
+}
+
+// sliceOfPod returns true if any endpoint of slice targets the named pod.
+func sliceOfPod(slice *discoveryv1.EndpointSlice, name types.NamespacedName) bool {
+	for _, ep := range slice.Endpoints {
+		if isPod(ep.TargetRef, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceOf returns the name of the service on whose
+// behalf slice is managed, and true, if any.
+func serviceOf(slice *discoveryv1.EndpointSlice) (types.NamespacedName, bool) {
+	name, ok := slice.Labels[discoveryv1.LabelServiceName]
+	if !ok || name == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: slice.Namespace, Name: name}, true
+}
+
+// sameService returns true if a and b are managed on behalf of the same service.
+func sameService(a, b *discoveryv1.EndpointSlice) bool {
+	sa, _ := serviceOf(a)
+	sb, _ := serviceOf(b)
+	return sa == sb
+}
+
+// isReady returns true if the endpoint is ready to receive traffic.
+// An unknown ready condition is interpreted as ready.
+func isReady(c discoveryv1.EndpointConditions) bool {
+	return c.Ready == nil || *c.Ready
+}
+
+// isServingTerminating returns true if the endpoint is terminating
+// but is still able to serve traffic.
+func isServingTerminating(c discoveryv1.EndpointConditions) bool {
+	return c.Serving != nil && *c.Serving && c.Terminating != nil && *c.Terminating
+}
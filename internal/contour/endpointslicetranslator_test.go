@@ -0,0 +1,439 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"testing"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/projectcontour/contour/internal/envoy"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"google.golang.org/protobuf/testing/protocmp"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8scache "k8s.io/client-go/tools/cache"
+)
+
+func TestEndpointSliceTranslatorAddEndpointSlices(t *testing.T) {
+	tests := map[string]struct {
+		slices []*discoveryv1.EndpointSlice
+		want   []proto.Message
+	}{
+		"simple": {
+			slices: endpointslices(
+				endpointslice("default", "simple", "simple-abcde",
+					slicePorts(slicePort("", 8080)),
+					sliceEndpoint(ready, "192.168.183.24"),
+				),
+			),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple", envoy.SocketAddress("192.168.183.24", 8080)),
+			},
+		},
+		"multiple slices": {
+			slices: endpointslices(
+				endpointslice("default", "httpbin-org", "httpbin-org-abcde",
+					slicePorts(slicePort("", 80)),
+					sliceEndpoint(ready, "50.17.206.192", "50.17.192.147"),
+				),
+				endpointslice("default", "httpbin-org", "httpbin-org-fghij",
+					slicePorts(slicePort("", 80)),
+					sliceEndpoint(ready, "23.23.247.89"),
+					sliceEndpoint(ready, "50.17.206.192"),
+				),
+			),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/httpbin-org",
+					envoy.SocketAddress("23.23.247.89", 80),
+					envoy.SocketAddress("50.17.192.147", 80),
+					envoy.SocketAddress("50.17.206.192", 80),
+				),
+			},
+		},
+		"multiple ports": {
+			slices: endpointslices(
+				endpointslice("default", "httpbin-org", "httpbin-org-abcde",
+					slicePorts(slicePort("http", 80), slicePort("https", 443)),
+					sliceEndpoint(ready, "10.10.1.1"),
+				),
+			),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/httpbin-org/http", envoy.SocketAddress("10.10.1.1", 80)),
+				envoy.ClusterLoadAssignment("default/httpbin-org/https", envoy.SocketAddress("10.10.1.1", 443)),
+			},
+		},
+		"not ready endpoints are skipped": {
+			slices: endpointslices(
+				endpointslice("default", "simple", "simple-abcde",
+					slicePorts(slicePort("", 8080)),
+					sliceEndpoint(ready, "192.168.183.24"),
+					sliceEndpoint(notReady, "192.168.183.25"),
+					sliceEndpoint(terminating, "192.168.183.26"),
+				),
+			),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple", envoy.SocketAddress("192.168.183.24", 8080)),
+			},
+		},
+		"unknown ready condition": {
+			slices: endpointslices(
+				endpointslice("default", "simple", "simple-abcde",
+					slicePorts(slicePort("", 8080)),
+					sliceEndpoint(discoveryv1.EndpointConditions{}, "192.168.183.24"),
+				),
+			),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple", envoy.SocketAddress("192.168.183.24", 8080)),
+			},
+		},
+		"serving terminating endpoints when none ready": {
+			slices: endpointslices(
+				endpointslice("default", "simple", "simple-abcde",
+					slicePorts(slicePort("", 8080)),
+					sliceEndpoint(notReady, "192.168.183.25"),
+					sliceEndpoint(terminating, "192.168.183.26"),
+				),
+			),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple", envoy.SocketAddress("192.168.183.26", 8080)),
+			},
+		},
+		"fqdn and udp are skipped": {
+			slices: endpointslices(
+				func() *discoveryv1.EndpointSlice {
+					s := endpointslice("default", "simple", "simple-abcde",
+						slicePorts(slicePort("", 8080)),
+						sliceEndpoint(ready, "example.com"),
+					)
+					s.AddressType = discoveryv1.AddressTypeFQDN
+					return s
+				}(),
+				func() *discoveryv1.EndpointSlice {
+					udp := slicePort("dns", 53)
+					udp.Protocol = protocol(v1.ProtocolUDP)
+					return endpointslice("default", "simple", "simple-fghij",
+						slicePorts(udp),
+						sliceEndpoint(ready, "192.168.183.24"),
+					)
+				}(),
+			),
+			want: nil,
+		},
+		"unmanaged slice": {
+			slices: endpointslices(
+				func() *discoveryv1.EndpointSlice {
+					s := endpointslice("default", "simple", "custom",
+						slicePorts(slicePort("", 8080)),
+						sliceEndpoint(ready, "192.168.183.24"),
+					)
+					s.Labels = nil
+					return s
+				}(),
+			),
+			want: nil,
+		},
+	}
+
+	log := testLogger(t)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			et := &EndpointSliceTranslator{
+				FieldLogger: log,
+			}
+			for _, s := range tc.slices {
+				et.OnAdd(s)
+			}
+			got := et.Contents()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestEndpointSliceTranslatorUpdateEndpointSlices(t *testing.T) {
+	s1 := endpointslice("default", "simple", "simple-abcde",
+		slicePorts(slicePort("http", 8080), slicePort("https", 8443)),
+		sliceEndpoint(ready, "192.168.183.24"),
+	)
+	s2 := endpointslice("default", "simple", "simple-fghij",
+		slicePorts(slicePort("http", 8080), slicePort("https", 8443)),
+		sliceEndpoint(ready, "192.168.183.25"),
+	)
+
+	var et EndpointSliceTranslator
+	et.OnAdd(s1)
+	et.OnAdd(s2)
+
+	want := []proto.Message{
+		envoy.ClusterLoadAssignment("default/simple/http",
+			envoy.SocketAddress("192.168.183.24", 8080),
+			envoy.SocketAddress("192.168.183.25", 8080),
+		),
+		envoy.ClusterLoadAssignment("default/simple/https",
+			envoy.SocketAddress("192.168.183.24", 8443),
+			envoy.SocketAddress("192.168.183.25", 8443),
+		),
+	}
+	if diff := cmp.Diff(want, et.Contents()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// s3 is s2 without the https port.
+	s3 := endpointslice("default", "simple", "simple-fghij",
+		slicePorts(slicePort("http", 8080)),
+		sliceEndpoint(notReady, "192.168.183.25"),
+	)
+	et.OnUpdate(s2, s3)
+
+	want = []proto.Message{
+		envoy.ClusterLoadAssignment("default/simple/http",
+			envoy.SocketAddress("192.168.183.24", 8080),
+		),
+		envoy.ClusterLoadAssignment("default/simple/https",
+			envoy.SocketAddress("192.168.183.24", 8443),
+		),
+	}
+	if diff := cmp.Diff(want, et.Contents()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// s4 is s1 without the https port.
+	s4 := endpointslice("default", "simple", "simple-abcde",
+		slicePorts(slicePort("http", 8080)),
+		sliceEndpoint(ready, "192.168.183.24"),
+	)
+	et.OnUpdate(s1, s4)
+
+	want = []proto.Message{
+		envoy.ClusterLoadAssignment("default/simple/http",
+			envoy.SocketAddress("192.168.183.24", 8080),
+		),
+	}
+	if diff := cmp.Diff(want, et.Contents()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	et.OnDelete(s4)
+	et.OnDelete(k8scache.DeletedFinalStateUnknown{Key: "default/simple-fghij", Obj: s3})
+
+	want = nil
+	if diff := cmp.Diff(want, et.Contents()); diff != "" {
+		t.Fatal(diff)
+	}
+	if len(et.slices) != 0 || len(et.clusters) != 0 {
+		t.Fatalf("expected no remaining state, got slices: %v, clusters: %v", et.slices, et.clusters)
+	}
+}
+
+func TestEndpointSliceTranslatorLocality(t *testing.T) {
+	nodename, zone := "node-1", "us-east-1b"
+	onNode := sliceEndpoint(ready, "192.168.183.24")
+	onNode.NodeName = &nodename
+	inZone := sliceEndpoint(ready, "192.168.183.25")
+	inZone.Zone = &zone
+
+	var et EndpointSliceTranslator
+	et.OnAdd(node(nodename, map[string]string{
+		v1.LabelTopologyRegion: "us-east-1",
+		v1.LabelTopologyZone:   "us-east-1a",
+	}))
+	et.OnAdd(endpointslice("default", "simple", "simple-abcde",
+		slicePorts(slicePort("", 8080)),
+		onNode,
+		inZone,
+	))
+
+	want := []proto.Message{
+		&v2.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
+				Locality: locality("", "us-east-1b"),
+				LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+					envoy.LBEndpoint(envoy.SocketAddress("192.168.183.25", 8080)),
+				},
+				LoadBalancingWeight: protobuf.UInt32(1),
+			}, {
+				Locality: locality("us-east-1", "us-east-1a"),
+				LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+					envoy.LBEndpoint(envoy.SocketAddress("192.168.183.24", 8080)),
+				},
+				LoadBalancingWeight: protobuf.UInt32(1),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, et.Contents(), protocmp.Transform()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestEndpointSliceTranslatorPodWeights(t *testing.T) {
+	heavy := sliceEndpoint(ready, "192.168.183.24")
+	heavy.TargetRef = podRef("default", "simple-1")
+	light := sliceEndpoint(ready, "192.168.183.25")
+	light.TargetRef = podRef("default", "simple-2")
+
+	var et EndpointSliceTranslator
+	et.OnAdd(endpointslice("default", "simple", "simple-abcde",
+		slicePorts(slicePort("", 8080)),
+		heavy,
+		light,
+	))
+	et.OnAdd(pod("default", "simple-1", "3"))
+
+	want := []proto.Message{
+		&v2.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+					weighted(envoy.LBEndpoint(envoy.SocketAddress("192.168.183.24", 8080)), 3),
+					envoy.LBEndpoint(envoy.SocketAddress("192.168.183.25", 8080)),
+				},
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, et.Contents(), protocmp.Transform()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestEndpointSliceTranslatorNotReadyEndpoints(t *testing.T) {
+	s1 := endpointslice("default", "simple", "simple-abcde",
+		slicePorts(slicePort("", 8080)),
+		sliceEndpoint(ready, "192.168.183.24"),
+		sliceEndpoint(notReady, "192.168.183.25"),
+		sliceEndpoint(terminating, "192.168.183.26"),
+	)
+
+	tests := map[string]struct {
+		svc  *v1.Service
+		want []proto.Message
+	}{
+		"not ready endpoints are removed": {
+			svc: service("default", "simple"),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple", envoy.SocketAddress("192.168.183.24", 8080)),
+			},
+		},
+		"publish not ready addresses": {
+			svc: func() *v1.Service {
+				s := service("default", "simple")
+				s.Spec.PublishNotReadyAddresses = true
+				return s
+			}(),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple",
+					envoy.SocketAddress("192.168.183.24", 8080),
+					envoy.SocketAddress("192.168.183.25", 8080),
+					envoy.SocketAddress("192.168.183.26", 8080),
+				),
+			},
+		},
+		"not ready endpoints are unhealthy": {
+			svc: func() *v1.Service {
+				s := service("default", "simple")
+				s.Annotations = map[string]string{"projectcontour.io/not-ready-endpoints": "Unhealthy"}
+				return s
+			}(),
+			want: []proto.Message{
+				&v2.ClusterLoadAssignment{
+					ClusterName: "default/simple",
+					Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
+						LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+							envoy.LBEndpoint(envoy.SocketAddress("192.168.183.24", 8080)),
+							healthStatus(envoy.LBEndpoint(envoy.SocketAddress("192.168.183.25", 8080)), envoy_api_v2_core.HealthStatus_UNHEALTHY),
+							healthStatus(envoy.LBEndpoint(envoy.SocketAddress("192.168.183.26", 8080)), envoy_api_v2_core.HealthStatus_UNHEALTHY),
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var et EndpointSliceTranslator
+			et.OnAdd(tc.svc)
+			et.OnAdd(s1)
+			if diff := cmp.Diff(tc.want, et.Contents(), protocmp.Transform()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+var (
+	ready = discoveryv1.EndpointConditions{
+		Ready: boolptr(true),
+	}
+	notReady = discoveryv1.EndpointConditions{
+		Ready: boolptr(false),
+	}
+	terminating = discoveryv1.EndpointConditions{
+		Ready:       boolptr(false),
+		Serving:     boolptr(true),
+		Terminating: boolptr(true),
+	}
+)
+
+func endpointslices(slices ...*discoveryv1.EndpointSlice) []*discoveryv1.EndpointSlice {
+	return slices
+}
+
+func endpointslice(ns, service, name string, ports []discoveryv1.EndpointPort, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: service,
+			},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports:       ports,
+		Endpoints:   endpoints,
+	}
+}
+
+func slicePorts(ports ...discoveryv1.EndpointPort) []discoveryv1.EndpointPort {
+	return ports
+}
+
+func slicePort(name string, port int32) discoveryv1.EndpointPort {
+	return discoveryv1.EndpointPort{
+		Name:     &name,
+		Port:     &port,
+		Protocol: protocol(v1.ProtocolTCP),
+	}
+}
+
+func sliceEndpoint(conditions discoveryv1.EndpointConditions, addresses ...string) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		Addresses:  addresses,
+		Conditions: conditions,
+	}
+}
+
+func protocol(p v1.Protocol) *v1.Protocol {
+	return &p
+}
+
+func boolptr(b bool) *bool {
+	return &b
+}
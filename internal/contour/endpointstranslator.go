@@ -19,27 +19,52 @@ import (
 	"sync"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
-	envoy_api_v2_endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
-	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
 	"github.com/golang/protobuf/proto"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	k8scache "k8s.io/client-go/tools/cache"
 )
 
 // A EndpointsTranslator translates Kubernetes Endpoints objects into Envoy
-// ClusterLoadAssignment objects.
+// ClusterLoadAssignment objects. Endpoints are grouped by the locality of
+// the Node they are running on, weighted by the annotations of their Pod,
+// and their ports are resolved against the ports of their Service, so the
+// translator also consumes Node, Pod, and Service objects.
 type EndpointsTranslator struct {
 	logrus.FieldLogger
 	clusterLoadAssignmentCache
+
+	mu       sync.Mutex
+	topology nodeTopology
+	weights  podWeights
+	services endpointServices
+
+	// endpoints holds the Endpoints objects seen, so their
+	// ClusterLoadAssignments can be recomputed when the
+	// locality of a Node, the weight of a Pod, or a Service,
+	// changes.
+	endpoints map[types.NamespacedName]*v1.Endpoints
+
+	// clusters holds the names of the ClusterLoadAssignments
+	// last computed for each Endpoints object.
+	clusters map[types.NamespacedName]map[string]bool
 }
 
 func (e *EndpointsTranslator) OnAdd(obj interface{}) {
 	switch obj := obj.(type) {
 	case *v1.Endpoints:
 		e.addEndpoints(obj)
+	case *v1.Node:
+		e.updateNode(obj)
+	case *v1.Pod:
+		e.updatePod(obj)
+	case *v1.Service:
+		e.updateService(obj)
 	default:
 		e.Errorf("OnAdd unexpected type %T: %#v", obj, obj)
 	}
@@ -54,6 +79,12 @@ func (e *EndpointsTranslator) OnUpdate(oldObj, newObj interface{}) {
 			return
 		}
 		e.updateEndpoints(oldObj, newObj)
+	case *v1.Node:
+		e.updateNode(newObj)
+	case *v1.Pod:
+		e.updatePod(newObj)
+	case *v1.Service:
+		e.updateService(newObj)
 	default:
 		e.Errorf("OnUpdate unexpected type %T: %#v", newObj, newObj)
 	}
@@ -63,6 +94,12 @@ func (e *EndpointsTranslator) OnDelete(obj interface{}) {
 	switch obj := obj.(type) {
 	case *v1.Endpoints:
 		e.removeEndpoints(obj)
+	case *v1.Node:
+		e.removeNode(obj)
+	case *v1.Pod:
+		e.removePod(obj)
+	case *v1.Service:
+		e.removeService(obj)
 	case k8scache.DeletedFinalStateUnknown:
 		e.OnDelete(obj.Obj) // recurse into ourselves with the tombstoned value
 	default:
@@ -70,29 +107,6 @@ func (e *EndpointsTranslator) OnDelete(obj interface{}) {
 	}
 }
 
-func (e *EndpointsTranslator) Contents() []proto.Message {
-	values := e.clusterLoadAssignmentCache.Contents()
-	sort.Stable(clusterLoadAssignmentsByName(values))
-	return values
-}
-
-func (e *EndpointsTranslator) Query(names []string) []proto.Message {
-	e.clusterLoadAssignmentCache.mu.Lock()
-	defer e.clusterLoadAssignmentCache.mu.Unlock()
-	var values []proto.Message
-	for _, n := range names {
-		v, ok := e.entries[n]
-		if !ok {
-			v = &v2.ClusterLoadAssignment{
-				ClusterName: n,
-			}
-		}
-		values = append(values, v)
-	}
-	sort.Stable(clusterLoadAssignmentsByName(values))
-	return values
-}
-
 type clusterLoadAssignmentsByName []proto.Message
 
 func (c clusterLoadAssignmentsByName) Len() int      { return len(c) }
@@ -101,13 +115,19 @@ func (c clusterLoadAssignmentsByName) Less(i, j int) bool {
 	return c[i].(*v2.ClusterLoadAssignment).ClusterName < c[j].(*v2.ClusterLoadAssignment).ClusterName
 }
 
-func (*EndpointsTranslator) TypeURL() string { return cache.EndpointType }
+func (*EndpointsTranslator) TypeURL() string { return resource.EndpointType }
 
 func (e *EndpointsTranslator) addEndpoints(ep *v1.Endpoints) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.storeEndpoints(ep)
 	e.recomputeClusterLoadAssignment(nil, ep)
 }
 
 func (e *EndpointsTranslator) updateEndpoints(oldep, newep *v1.Endpoints) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.storeEndpoints(newep)
 	if len(newep.Subsets) == 0 && len(oldep.Subsets) == 0 {
 		// if there are no endpoints in this object, and the old
 		// object also had zero endpoints, ignore this update
@@ -118,9 +138,123 @@ func (e *EndpointsTranslator) updateEndpoints(oldep, newep *v1.Endpoints) {
 }
 
 func (e *EndpointsTranslator) removeEndpoints(ep *v1.Endpoints) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.endpoints, types.NamespacedName{Namespace: ep.Namespace, Name: ep.Name})
 	e.recomputeClusterLoadAssignment(ep, nil)
 }
 
+func (e *EndpointsTranslator) storeEndpoints(ep *v1.Endpoints) {
+	if e.endpoints == nil {
+		e.endpoints = make(map[types.NamespacedName]*v1.Endpoints)
+	}
+	e.endpoints[types.NamespacedName{Namespace: ep.Namespace, Name: ep.Name}] = ep
+}
+
+func (e *EndpointsTranslator) updateNode(node *v1.Node) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.topology.updateNode(node) {
+		e.recomputeNode(node.Name)
+	}
+}
+
+func (e *EndpointsTranslator) removeNode(node *v1.Node) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.topology.removeNode(node) {
+		e.recomputeNode(node.Name)
+	}
+}
+
+func (e *EndpointsTranslator) updatePod(pod *v1.Pod) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.weights.updatePod(pod) {
+		e.recomputePod(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+	}
+}
+
+func (e *EndpointsTranslator) removePod(pod *v1.Pod) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.weights.removePod(pod) {
+		e.recomputePod(types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+	}
+}
+
+func (e *EndpointsTranslator) updateService(svc *v1.Service) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.services.updateService(svc) {
+		e.recomputeService(types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name})
+	}
+}
+
+func (e *EndpointsTranslator) removeService(svc *v1.Service) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.services.removeService(svc) {
+		e.recomputeService(types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name})
+	}
+}
+
+// recomputeService recomputes the ClusterLoadAssignments
+// of the Endpoints of the named service.
+func (e *EndpointsTranslator) recomputeService(name types.NamespacedName) {
+	if ep, ok := e.endpoints[name]; ok {
+		e.recomputeClusterLoadAssignment(nil, ep)
+	}
+}
+
+// recomputeNode recomputes the ClusterLoadAssignments of the
+// Endpoints with an address on the named node.
+func (e *EndpointsTranslator) recomputeNode(nodename string) {
+	for _, ep := range e.endpoints {
+		if onNode(ep, nodename) {
+			e.recomputeClusterLoadAssignment(nil, ep)
+		}
+	}
+}
+
+// recomputePod recomputes the ClusterLoadAssignments of the
+// Endpoints with an address of the named pod.
+func (e *EndpointsTranslator) recomputePod(name types.NamespacedName) {
+	for _, ep := range e.endpoints {
+		if ep.Namespace == name.Namespace && ofPod(ep, name) {
+			e.recomputeClusterLoadAssignment(nil, ep)
+		}
+	}
+}
+
+// ofPod returns true if any address of ep targets the named pod.
+func ofPod(ep *v1.Endpoints, name types.NamespacedName) bool {
+	for _, s := range ep.Subsets {
+		for _, addresses := range [][]v1.EndpointAddress{s.Addresses, s.NotReadyAddresses} {
+			for _, a := range addresses {
+				if isPod(a.TargetRef, name) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// onNode returns true if any address of ep is on the named node.
+func onNode(ep *v1.Endpoints, nodename string) bool {
+	for _, s := range ep.Subsets {
+		for _, addresses := range [][]v1.EndpointAddress{s.Addresses, s.NotReadyAddresses} {
+			for _, a := range addresses {
+				if a.NodeName != nil && *a.NodeName == nodename {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // recomputeClusterLoadAssignment recomputes the EDS cache taking into account old and new endpoints.
 func (e *EndpointsTranslator) recomputeClusterLoadAssignment(oldep, newep *v1.Endpoints) {
 	// skip computation if either old and new services or endpoints are equal (thus also handling nil)
@@ -128,65 +262,93 @@ func (e *EndpointsTranslator) recomputeClusterLoadAssignment(oldep, newep *v1.En
 		return
 	}
 
-	if oldep == nil {
-		oldep = &v1.Endpoints{
-			ObjectMeta: newep.ObjectMeta,
-		}
-	}
-
 	if newep == nil {
 		newep = &v1.Endpoints{
 			ObjectMeta: oldep.ObjectMeta,
 		}
 	}
 
-	seen := make(map[string]bool)
-	// add or update endpoints
+	name := types.NamespacedName{Namespace: newep.Namespace, Name: newep.Name}
+	policy := e.services.notReadyPolicy(name)
+
+	// clusters holds, by cluster name, the addresses of each service
+	// port. A port's addresses may be spread across several subsets,
+	// as a named targetPort may resolve to a different port number
+	// for each pod.
+	clusters := make(map[string][]localityAddress)
 	for _, s := range newep.Subsets {
-		if len(s.Addresses) < 1 {
-			// skip subset without ready addresses.
+		addresses := make([]endpointAddress, 0, len(s.Addresses)+len(s.NotReadyAddresses))
+		for _, a := range s.Addresses {
+			addresses = append(addresses, endpointAddress{EndpointAddress: a})
+		}
+		for _, a := range s.NotReadyAddresses {
+			switch {
+			case policy.publish:
+				addresses = append(addresses, endpointAddress{EndpointAddress: a})
+			case policy.include:
+				addresses = append(addresses, endpointAddress{EndpointAddress: a, healthStatus: policy.status})
+			}
+		}
+		if len(addresses) < 1 {
+			// skip subset without addresses to send.
 			continue
 		}
+
 		for _, p := range s.Ports {
-			if p.Protocol != "TCP" {
+			if p.Protocol != v1.ProtocolTCP {
 				// skip non TCP ports
+				e.Debugf("endpoints %s/%s: skipping port %q: protocol %s is not supported", newep.Namespace, newep.Name, p.Name, p.Protocol)
 				continue
 			}
 
-			addresses := append([]v1.EndpointAddress{}, s.Addresses...) // shallow copy
-			sort.Slice(addresses, func(i, j int) bool { return addresses[i].IP < addresses[j].IP })
-
-			lbendpoints := make([]*envoy_api_v2_endpoint.LbEndpoint, 0, len(addresses))
-			for _, a := range addresses {
-				addr := envoy.SocketAddress(a.IP, int(p.Port))
-				lbendpoints = append(lbendpoints, envoy.LBEndpoint(addr))
-			}
-
-			cla := &v2.ClusterLoadAssignment{
-				ClusterName: servicename(newep.ObjectMeta, p.Name),
-				Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
-					LbEndpoints: lbendpoints,
-				}},
+			for _, portname := range e.services.portNames(name, p.Name, p.Port) {
+				cluster := servicename(newep.ObjectMeta, portname)
+				for _, a := range addresses {
+					la := localityAddress{
+						address:      envoy.SocketAddress(a.IP, int(p.Port)),
+						healthStatus: a.healthStatus,
+						weight:       e.weights.weight(a.TargetRef),
+					}
+					if a.NodeName != nil {
+						la.locality = e.topology.locality(*a.NodeName)
+					}
+					clusters[cluster] = append(clusters[cluster], la)
+				}
 			}
-			seen[cla.ClusterName] = true
-			e.Add(cla)
 		}
 	}
 
-	// iterate over the ports in the old spec, remove any were not seen.
-	for _, s := range oldep.Subsets {
-		if len(s.Addresses) == 0 {
-			continue
-		}
-		for _, p := range s.Ports {
-			name := servicename(oldep.ObjectMeta, p.Name)
-			if _, ok := seen[name]; !ok {
-				// port is no longer present, remove it.
-				e.Remove(name)
-			}
+	seen := make(map[string]bool)
+	// add or update endpoints
+	for cluster, addrs := range clusters {
+		sort.SliceStable(addrs, func(i, j int) bool {
+			return addrs[i].address.GetSocketAddress().GetAddress() < addrs[j].address.GetSocketAddress().GetAddress()
+		})
+		seen[cluster] = true
+		e.Add(clusterLoadAssignment(cluster, addrs))
+	}
+
+	// remove the ClusterLoadAssignments of any ports no longer present.
+	for cluster := range e.clusters[name] {
+		if !seen[cluster] {
+			e.Remove(cluster)
 		}
 	}
+	if len(seen) == 0 {
+		delete(e.clusters, name)
+		return
+	}
+	if e.clusters == nil {
+		e.clusters = make(map[types.NamespacedName]map[string]bool)
+	}
+	e.clusters[name] = seen
+}
 
+// endpointAddress is an address of an Endpoints subset
+// and the health status with which it is sent to Envoy.
+type endpointAddress struct {
+	v1.EndpointAddress
+	healthStatus envoy_api_v2_core.HealthStatus
 }
 
 type clusterLoadAssignmentCache struct {
@@ -224,6 +386,26 @@ func (c *clusterLoadAssignmentCache) Contents() []proto.Message {
 	for _, v := range c.entries {
 		values = append(values, v)
 	}
+	sort.Stable(clusterLoadAssignmentsByName(values))
+	return values
+}
+
+// Query returns the named entries of the cache. Entries
+// which are not present are returned with no endpoints.
+func (c *clusterLoadAssignmentCache) Query(names []string) []proto.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var values []proto.Message
+	for _, n := range names {
+		v, ok := c.entries[n]
+		if !ok {
+			v = &v2.ClusterLoadAssignment{
+				ClusterName: n,
+			}
+		}
+		values = append(values, v)
+	}
+	sort.Stable(clusterLoadAssignmentsByName(values))
 	return values
 }
 
@@ -17,10 +17,15 @@ import (
 	"testing"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	"github.com/golang/protobuf/proto"
 	"github.com/google/go-cmp/cmp"
 	"github.com/projectcontour/contour/internal/envoy"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"google.golang.org/protobuf/testing/protocmp"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestEndpointsTranslatorContents(t *testing.T) {
@@ -445,6 +450,290 @@ func TestEndpointsTranslatorScaleToZeroEndpoints(t *testing.T) {
 	}
 }
 
+func TestEndpointsTranslatorNodeLocality(t *testing.T) {
+	var et EndpointsTranslator
+	nodename := "node-1"
+	e1 := endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: []v1.EndpointAddress{
+			{IP: "192.168.183.24", NodeName: &nodename},
+			{IP: "192.168.183.25"},
+		},
+		Ports: ports(
+			port("", 8080),
+		),
+	})
+	et.OnAdd(e1)
+
+	// Assert endpoints have no locality until the node is known.
+	want := []proto.Message{
+		envoy.ClusterLoadAssignment("default/simple",
+			envoy.SocketAddress("192.168.183.24", 8080),
+			envoy.SocketAddress("192.168.183.25", 8080),
+		),
+	}
+	if diff := cmp.Diff(want, et.Contents(), protocmp.Transform()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	n1 := node(nodename, map[string]string{
+		v1.LabelTopologyRegion: "us-east-1",
+		v1.LabelTopologyZone:   "us-east-1a",
+	})
+	et.OnAdd(n1)
+
+	// Assert endpoints are grouped by locality.
+	want = []proto.Message{
+		&v2.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+					envoy.LBEndpoint(envoy.SocketAddress("192.168.183.25", 8080)),
+				},
+				LoadBalancingWeight: protobuf.UInt32(1),
+			}, {
+				Locality: locality("us-east-1", "us-east-1a"),
+				LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+					envoy.LBEndpoint(envoy.SocketAddress("192.168.183.24", 8080)),
+				},
+				LoadBalancingWeight: protobuf.UInt32(1),
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, et.Contents(), protocmp.Transform()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	et.OnDelete(n1)
+
+	// Assert endpoints have no locality once the node is removed.
+	want = []proto.Message{
+		envoy.ClusterLoadAssignment("default/simple",
+			envoy.SocketAddress("192.168.183.24", 8080),
+			envoy.SocketAddress("192.168.183.25", 8080),
+		),
+	}
+	if diff := cmp.Diff(want, et.Contents(), protocmp.Transform()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestEndpointsTranslatorPodWeights(t *testing.T) {
+	var et EndpointsTranslator
+	e1 := endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: []v1.EndpointAddress{
+			{IP: "192.168.183.24", TargetRef: podRef("default", "simple-1")},
+			{IP: "192.168.183.25", TargetRef: podRef("default", "simple-2")},
+		},
+		Ports: ports(
+			port("", 8080),
+		),
+	})
+	et.OnAdd(e1)
+
+	p1 := pod("default", "simple-1", "10")
+	et.OnAdd(p1)
+
+	// Assert the endpoint of the annotated pod is weighted.
+	want := []proto.Message{
+		&v2.ClusterLoadAssignment{
+			ClusterName: "default/simple",
+			Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+					weighted(envoy.LBEndpoint(envoy.SocketAddress("192.168.183.24", 8080)), 10),
+					envoy.LBEndpoint(envoy.SocketAddress("192.168.183.25", 8080)),
+				},
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, et.Contents(), protocmp.Transform()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	et.OnUpdate(p1, pod("default", "simple-1", ""))
+
+	// Assert the endpoint is no longer weighted once the annotation is removed.
+	want = []proto.Message{
+		envoy.ClusterLoadAssignment("default/simple",
+			envoy.SocketAddress("192.168.183.24", 8080),
+			envoy.SocketAddress("192.168.183.25", 8080),
+		),
+	}
+	if diff := cmp.Diff(want, et.Contents(), protocmp.Transform()); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestEndpointsTranslatorNotReadyAddresses(t *testing.T) {
+	e1 := endpoints("default", "simple", v1.EndpointSubset{
+		Addresses:         addresses("192.168.183.24"),
+		NotReadyAddresses: addresses("192.168.183.25"),
+		Ports: ports(
+			port("", 8080),
+		),
+	}, v1.EndpointSubset{
+		NotReadyAddresses: addresses("192.168.183.26"),
+		Ports: ports(
+			port("admin", 9001),
+		),
+	})
+
+	tests := map[string]struct {
+		svc  *v1.Service
+		want []proto.Message
+	}{
+		"not ready addresses are removed": {
+			svc: service("default", "simple"),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple", envoy.SocketAddress("192.168.183.24", 8080)),
+			},
+		},
+		"publish not ready addresses": {
+			svc: func() *v1.Service {
+				s := service("default", "simple")
+				s.Spec.PublishNotReadyAddresses = true
+				return s
+			}(),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple",
+					envoy.SocketAddress("192.168.183.24", 8080),
+					envoy.SocketAddress("192.168.183.25", 8080),
+				),
+				envoy.ClusterLoadAssignment("default/simple/admin", envoy.SocketAddress("192.168.183.26", 9001)),
+			},
+		},
+		"not ready addresses are draining": {
+			svc: func() *v1.Service {
+				s := service("default", "simple")
+				s.Annotations = map[string]string{"projectcontour.io/not-ready-endpoints": "Draining"}
+				return s
+			}(),
+			want: []proto.Message{
+				&v2.ClusterLoadAssignment{
+					ClusterName: "default/simple",
+					Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
+						LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+							envoy.LBEndpoint(envoy.SocketAddress("192.168.183.24", 8080)),
+							healthStatus(envoy.LBEndpoint(envoy.SocketAddress("192.168.183.25", 8080)), envoy_api_v2_core.HealthStatus_DRAINING),
+						},
+					}},
+				},
+				&v2.ClusterLoadAssignment{
+					ClusterName: "default/simple/admin",
+					Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
+						LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+							healthStatus(envoy.LBEndpoint(envoy.SocketAddress("192.168.183.26", 9001)), envoy_api_v2_core.HealthStatus_DRAINING),
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var et EndpointsTranslator
+			et.OnAdd(e1)
+			et.OnAdd(tc.svc)
+			if diff := cmp.Diff(tc.want, et.Contents(), protocmp.Transform()); diff != "" {
+				t.Fatal(diff)
+			}
+
+			// Assert not ready addresses are removed once the service is.
+			et.OnDelete(tc.svc)
+			want := []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple", envoy.SocketAddress("192.168.183.24", 8080)),
+			}
+			if diff := cmp.Diff(want, et.Contents(), protocmp.Transform()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestEndpointsTranslatorPortResolution(t *testing.T) {
+	tests := map[string]struct {
+		svc  *v1.Service
+		ep   *v1.Endpoints
+		want []proto.Message
+	}{
+		"named target port resolved per pod": {
+			ep: endpoints("default", "simple", v1.EndpointSubset{
+				Addresses: addresses("192.168.183.24"),
+				Ports: ports(
+					port("http", 8080),
+				),
+			}, v1.EndpointSubset{
+				Addresses: addresses("192.168.183.25"),
+				Ports: ports(
+					port("http", 9090),
+				),
+			}),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple/http",
+					envoy.SocketAddress("192.168.183.24", 8080),
+					envoy.SocketAddress("192.168.183.25", 9090),
+				),
+			},
+		},
+		"endpoint ports resolved against service ports": {
+			svc: service("default", "simple",
+				v1.ServicePort{
+					Name:       "http",
+					Protocol:   "TCP",
+					Port:       80,
+					TargetPort: intstr.FromString("web"),
+				},
+				v1.ServicePort{
+					Name:       "metrics",
+					Protocol:   "TCP",
+					Port:       9000,
+					TargetPort: intstr.FromInt(9100),
+				},
+			),
+			ep: endpoints("default", "simple", v1.EndpointSubset{
+				Addresses: addresses("192.168.183.24"),
+				Ports: ports(
+					port("web", 8080),
+					port("", 9100),
+				),
+			}),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple/http", envoy.SocketAddress("192.168.183.24", 8080)),
+				envoy.ClusterLoadAssignment("default/simple/metrics", envoy.SocketAddress("192.168.183.24", 9100)),
+			},
+		},
+		"non tcp ports are skipped": {
+			ep: endpoints("default", "simple", v1.EndpointSubset{
+				Addresses: addresses("192.168.183.24"),
+				Ports: ports(
+					port("http", 8080),
+					v1.EndpointPort{Name: "dns", Port: 53, Protocol: "UDP"},
+					v1.EndpointPort{Name: "sctp", Port: 9999, Protocol: "SCTP"},
+				),
+			}),
+			want: []proto.Message{
+				envoy.ClusterLoadAssignment("default/simple/http", envoy.SocketAddress("192.168.183.24", 8080)),
+			},
+		},
+	}
+
+	log := testLogger(t)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			et := &EndpointsTranslator{
+				FieldLogger: log,
+			}
+			if tc.svc != nil {
+				et.OnAdd(tc.svc)
+			}
+			et.OnAdd(tc.ep)
+			if diff := cmp.Diff(tc.want, et.Contents(), protocmp.Transform()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
 func ports(eps ...v1.EndpointPort) []v1.EndpointPort {
 	return eps
 }
@@ -464,3 +753,8 @@ func clusterloadassignments(clas ...*v2.ClusterLoadAssignment) map[string]*v2.Cl
 	}
 	return m
 }
+
+func healthStatus(lbe *envoy_api_v2_endpoint.LbEndpoint, status envoy_api_v2_core.HealthStatus) *envoy_api_v2_endpoint.LbEndpoint {
+	lbe.HealthStatus = status
+	return lbe
+}
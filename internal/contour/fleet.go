@@ -0,0 +1,106 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"sort"
+	"strings"
+
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/projectcontour/contour/internal/dag"
+)
+
+// DEFAULT_FLEET_METADATA_KEY is the Envoy node metadata key which
+// names the fleet of a node if FleetSelector.MetadataKey is not set.
+const DEFAULT_FLEET_METADATA_KEY = "fleet"
+
+// FleetSelector assigns Envoy nodes to fleets. Virtual hosts which
+// are scoped to a fleet are only served to the members of that fleet.
+// Nodes which are not a member of any fleet are only served the
+// virtual hosts which are not scoped to a fleet.
+type FleetSelector struct {
+	// MetadataKey is the node metadata key whose value names
+	// the node's fleet.
+	// If not set, defaults to DEFAULT_FLEET_METADATA_KEY.
+	MetadataKey string
+
+	// NodeIDPrefixes maps a fleet name to the node ID prefix of
+	// its members. It is consulted if a node's metadata does not
+	// name its fleet. If more than one prefix matches, the longest wins.
+	NodeIDPrefixes map[string]string
+}
+
+// Fleet returns the name of the fleet node is a member of, or
+// the empty string if node is not a member of any fleet.
+func (fs *FleetSelector) Fleet(node *envoy_api_v2_core.Node) string {
+	if fs == nil || node == nil {
+		return ""
+	}
+	if v, ok := node.GetMetadata().GetFields()[fs.metadataKey()]; ok {
+		if fleet := v.GetStringValue(); fleet != "" {
+			return fleet
+		}
+	}
+	var fleet, prefix string
+	for f, p := range fs.NodeIDPrefixes {
+		if p == "" || !strings.HasPrefix(node.Id, p) {
+			continue
+		}
+		if len(p) > len(prefix) || (len(p) == len(prefix) && f < fleet) {
+			fleet, prefix = f, p
+		}
+	}
+	return fleet
+}
+
+func (fs *FleetSelector) metadataKey() string {
+	if fs.MetadataKey != "" {
+		return fs.MetadataKey
+	}
+	return DEFAULT_FLEET_METADATA_KEY
+}
+
+// servedTo returns true if vh should be served to the members of fleet.
+func servedTo(vh *dag.VirtualHost, fleet string) bool {
+	return vh.Fleet == "" || vh.Fleet == fleet
+}
+
+// visitFleets returns the sorted names of the fleets to which
+// at least one virtual host in the DAG is scoped.
+func visitFleets(root dag.Vertex) []string {
+	seen := make(map[string]bool)
+	var visit func(dag.Vertex)
+	visit = func(vertex dag.Vertex) {
+		switch vh := vertex.(type) {
+		case *dag.VirtualHost:
+			if vh.Fleet != "" {
+				seen[vh.Fleet] = true
+			}
+		case *dag.SecureVirtualHost:
+			if vh.Fleet != "" {
+				seen[vh.Fleet] = true
+			}
+		default:
+			vertex.Visit(visit)
+		}
+	}
+	visit(root)
+
+	fleets := make([]string, 0, len(seen))
+	for f := range seen {
+		fleets = append(fleets, f)
+	}
+	sort.Strings(fleets)
+	return fleets
+}
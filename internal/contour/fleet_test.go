@@ -0,0 +1,105 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"testing"
+
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFleetSelector(t *testing.T) {
+	metadata := func(key, value string) *_struct.Struct {
+		return &_struct.Struct{
+			Fields: map[string]*_struct.Value{
+				key: {Kind: &_struct.Value_StringValue{StringValue: value}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		selector *FleetSelector
+		node     *envoy_api_v2_core.Node
+		want     string
+	}{
+		"nil selector": {
+			selector: nil,
+			node:     &envoy_api_v2_core.Node{Metadata: metadata("fleet", "internal")},
+			want:     "",
+		},
+		"nil node": {
+			selector: &FleetSelector{},
+			node:     nil,
+			want:     "",
+		},
+		"default metadata key": {
+			selector: &FleetSelector{},
+			node:     &envoy_api_v2_core.Node{Metadata: metadata("fleet", "internal")},
+			want:     "internal",
+		},
+		"custom metadata key": {
+			selector: &FleetSelector{MetadataKey: "envoy-fleet"},
+			node:     &envoy_api_v2_core.Node{Metadata: metadata("envoy-fleet", "internal")},
+			want:     "internal",
+		},
+		"custom metadata key, default key ignored": {
+			selector: &FleetSelector{MetadataKey: "envoy-fleet"},
+			node:     &envoy_api_v2_core.Node{Metadata: metadata("fleet", "internal")},
+			want:     "",
+		},
+		"node id prefix": {
+			selector: &FleetSelector{
+				NodeIDPrefixes: map[string]string{
+					"internal": "envoy-internal-",
+					"external": "envoy-external-",
+				},
+			},
+			node: &envoy_api_v2_core.Node{Id: "envoy-external-7d9f"},
+			want: "external",
+		},
+		"longest node id prefix wins": {
+			selector: &FleetSelector{
+				NodeIDPrefixes: map[string]string{
+					"all":      "envoy-",
+					"internal": "envoy-internal-",
+				},
+			},
+			node: &envoy_api_v2_core.Node{Id: "envoy-internal-7d9f"},
+			want: "internal",
+		},
+		"metadata takes precedence over node id": {
+			selector: &FleetSelector{
+				NodeIDPrefixes: map[string]string{
+					"internal": "envoy-internal-",
+				},
+			},
+			node: &envoy_api_v2_core.Node{
+				Id:       "envoy-internal-7d9f",
+				Metadata: metadata("fleet", "external"),
+			},
+			want: "external",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tc.selector.Fleet(tc.node)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
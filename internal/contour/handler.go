@@ -17,6 +17,7 @@
 package contour
 
 import (
+	"reflect"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
@@ -28,6 +29,8 @@ import (
 	"github.com/projectcontour/contour/internal/metrics"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha1"
 )
 
 // EventHandler implements cache.ResourceEventHandler, filters k8s events towards
@@ -42,6 +45,9 @@ type EventHandler struct {
 
 	CRDStatus *k8s.CRDStatus
 
+	// GatewayAPIStatus, if set, updates the status of Gateway API objects.
+	GatewayAPIStatus *k8s.GatewayAPIStatus
+
 	*metrics.Metrics
 
 	logrus.FieldLogger
@@ -161,6 +167,7 @@ func (e *EventHandler) run(stop <-chan struct{}) error {
 			if e.onUpdate(op) {
 				enqueue()
 			} else {
+				e.Metrics.IncEventHandlerIgnored(kindOf(op))
 				// notify any watchers that we received the event but chose
 				// not to process it.
 				e.incSequence()
@@ -186,6 +193,10 @@ func (e *EventHandler) onUpdate(op interface{}) bool {
 	case opUpdate:
 		if cmp.Equal(op.oldObj, op.newObj,
 			cmpopts.IgnoreFields(ingressroutev1.IngressRoute{}, "Status"),
+			cmpopts.IgnoreFields(gatewayapi.GatewayClass{}, "Status"),
+			cmpopts.IgnoreFields(gatewayapi.Gateway{}, "Status"),
+			cmpopts.IgnoreFields(gatewayapi.HTTPRoute{}, "Status"),
+			cmpopts.IgnoreFields(gatewayapi.TLSRoute{}, "Status"),
 			cmpopts.IgnoreFields(metav1.ObjectMeta{}, "ResourceVersion")) {
 			e.WithField("op", "update").Debugf("%T skipping update, only status has changed", op.newObj)
 			return false
@@ -200,6 +211,31 @@ func (e *EventHandler) onUpdate(op interface{}) bool {
 	}
 }
 
+// kindOf returns the kind of the object of op, such as
+// "Secret", for use as a metric label.
+func kindOf(op interface{}) string {
+	var obj interface{}
+	switch op := op.(type) {
+	case opAdd:
+		obj = op.obj
+	case opUpdate:
+		obj = op.newObj
+	case opDelete:
+		obj = op.obj
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	t := reflect.TypeOf(obj)
+	if t == nil {
+		return "unknown"
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
 // incSequence bumps the sequence counter and sends it to e.Sequence.
 func (e *EventHandler) incSequence() {
 	e.seq++
@@ -218,6 +254,9 @@ func (e *EventHandler) updateDAG() {
 	e.CacheHandler.OnChange(dag)
 	statuses := dag.Statuses()
 	e.setStatus(statuses)
+	if e.GatewayAPIStatus != nil {
+		e.setGatewayAPIStatus(dag.GatewayAPIStatuses())
+	}
 
 	metrics := calculateIngressRouteMetric(statuses)
 	e.Metrics.SetIngressRouteMetric(metrics)
@@ -230,6 +269,7 @@ func (e *EventHandler) setStatus(statuses map[dag.Meta]dag.Status) {
 	for _, st := range statuses {
 		switch obj := st.Object.(type) {
 		case *ingressroutev1.IngressRoute:
+			e.logWarnings(st, obj.Status, obj)
 			err := e.CRDStatus.SetStatus(st.Status, st.Description, obj)
 			if err != nil {
 				e.WithError(err).
@@ -240,6 +280,7 @@ func (e *EventHandler) setStatus(statuses map[dag.Meta]dag.Status) {
 					Error("failed to set status")
 			}
 		case *projcontour.HTTPProxy:
+			e.logWarnings(st, obj.Status, obj)
 			err := e.CRDStatus.SetStatus(st.Status, st.Description, obj)
 			if err != nil {
 				e.WithError(err).
@@ -256,3 +297,43 @@ func (e *EventHandler) setStatus(statuses map[dag.Meta]dag.Status) {
 		}
 	}
 }
+
+// logWarnings logs the warnings of st if they change the status of obj,
+// so each warning is logged once rather than on every rebuild.
+func (e *EventHandler) logWarnings(st dag.Status, current projcontour.Status, obj metav1.Object) {
+	if current.CurrentStatus == st.Status && current.Description == st.Description {
+		return
+	}
+	for _, w := range st.Warnings {
+		e.WithField("name", obj.GetName()).
+			WithField("namespace", obj.GetNamespace()).
+			Warn(w)
+	}
+}
+
+// setGatewayAPIStatus updates the status of Gateway API objects.
+func (e *EventHandler) setGatewayAPIStatus(statuses map[dag.Object]*dag.GatewayAPIStatus) {
+	for _, st := range statuses {
+		var err error
+		switch obj := st.Object.(type) {
+		case *gatewayapi.GatewayClass:
+			err = e.GatewayAPIStatus.SetGatewayClassStatus(obj, st.Conditions)
+		case *gatewayapi.Gateway:
+			err = e.GatewayAPIStatus.SetGatewayStatus(obj, st.Conditions, st.Listeners)
+		case *gatewayapi.HTTPRoute:
+			err = e.GatewayAPIStatus.SetHTTPRouteStatus(obj, st.Gateways)
+		case *gatewayapi.TLSRoute:
+			err = e.GatewayAPIStatus.SetTLSRouteStatus(obj, st.Gateways)
+		default:
+			e.WithField("namespace", obj.GetObjectMeta().GetNamespace()).
+				WithField("name", obj.GetObjectMeta().GetName()).
+				Error("set status: unknown object type")
+		}
+		if err != nil {
+			e.WithError(err).
+				WithField("name", st.Object.GetObjectMeta().GetName()).
+				WithField("namespace", st.Object.GetObjectMeta().GetNamespace()).
+				Error("failed to set status")
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestKindOf(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+	}
+
+	tests := map[string]struct {
+		op   interface{}
+		want string
+	}{
+		"add": {
+			op:   opAdd{obj: secret},
+			want: "Secret",
+		},
+		"update": {
+			op:   opUpdate{oldObj: secret, newObj: &v1.Service{}},
+			want: "Service",
+		},
+		"delete": {
+			op:   opDelete{obj: secret},
+			want: "Secret",
+		},
+		"delete tombstone": {
+			op:   opDelete{obj: cache.DeletedFinalStateUnknown{Key: "default/secret", Obj: secret}},
+			want: "Secret",
+		},
+		"delete nil": {
+			op:   opDelete{},
+			want: "unknown",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := kindOf(tc.op)
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLogWarnings(t *testing.T) {
+	proxy := func(status projcontour.Status) *projcontour.HTTPProxy {
+		return &projcontour.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dns",
+				Namespace: "default",
+			},
+			Status: status,
+		}
+	}
+
+	st := dag.Status{
+		Status:      "valid",
+		Description: "valid HTTPProxy; warning: port 53 has no TCP endpoints",
+		Warnings:    []string{"port 53 has no TCP endpoints"},
+	}
+
+	tests := map[string]struct {
+		current projcontour.Status
+		want    bool
+	}{
+		"status changed": {
+			current: projcontour.Status{
+				CurrentStatus: "valid",
+				Description:   "valid HTTPProxy",
+			},
+			want: true,
+		},
+		"status unchanged": {
+			current: projcontour.Status{
+				CurrentStatus: st.Status,
+				Description:   st.Description,
+			},
+			want: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := logrus.New()
+			log.Out = &buf
+			e := EventHandler{FieldLogger: log}
+
+			obj := proxy(tc.current)
+			e.logWarnings(st, obj.Status, obj)
+
+			got := strings.Contains(buf.String(), "port 53 has no TCP endpoints")
+			if got != tc.want {
+				t.Fatalf("expected warning logged: %v, got %v: %q", tc.want, got, buf.String())
+			}
+		})
+	}
+}
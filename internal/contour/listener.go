@@ -18,11 +18,12 @@ import (
 	"sync"
 
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_listener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
 	envoy_api_v2_accesslog "github.com/envoyproxy/go-control-plane/envoy/config/filter/accesslog/v2"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
-	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
 	"github.com/golang/protobuf/proto"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
@@ -188,7 +189,12 @@ func (lvc *ListenerVisitorConfig) minProtoVersion() envoy_api_v2_auth.TlsParamet
 type ListenerCache struct {
 	mu           sync.Mutex
 	values       map[string]*v2.Listener
+	fleetValues  map[string]map[string]*v2.Listener
 	staticValues map[string]*v2.Listener
+
+	// FleetSelector, if set, selects the fleet whose
+	// listeners are served to each Envoy node.
+	FleetSelector *FleetSelector
 	Cond
 }
 
@@ -204,19 +210,36 @@ func NewListenerCache(address string, port int) ListenerCache {
 
 // Update replaces the contents of the cache with the supplied map.
 func (c *ListenerCache) Update(v map[string]*v2.Listener) {
+	c.update(v, nil)
+}
+
+// update replaces the contents of the cache with the supplied maps.
+// fleets holds the listeners for each fleet, keyed by fleet name.
+func (c *ListenerCache) update(v map[string]*v2.Listener, fleets map[string]map[string]*v2.Listener) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	c.values = v
+	c.fleetValues = fleets
 	c.Cond.Notify()
 }
 
 // Contents returns a copy of the cache's contents.
 func (c *ListenerCache) Contents() []proto.Message {
+	return c.contents("")
+}
+
+// NodeContents returns a copy of the cache's contents for
+// the fleet which node is a member of.
+func (c *ListenerCache) NodeContents(node *envoy_api_v2_core.Node) []proto.Message {
+	return c.contents(c.FleetSelector.Fleet(node))
+}
+
+func (c *ListenerCache) contents(fleet string) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	var values []proto.Message
-	for _, v := range c.values {
+	for _, v := range c.valuesFor(fleet) {
 		values = append(values, v)
 	}
 	for _, v := range c.staticValues {
@@ -229,11 +252,22 @@ func (c *ListenerCache) Contents() []proto.Message {
 // Query returns the proto.Messages in the ListenerCache that match
 // a slice of strings
 func (c *ListenerCache) Query(names []string) []proto.Message {
+	return c.query("", names)
+}
+
+// NodeQuery returns the proto.Messages in the ListenerCache for the
+// fleet which node is a member of that match a slice of strings.
+func (c *ListenerCache) NodeQuery(node *envoy_api_v2_core.Node, names []string) []proto.Message {
+	return c.query(c.FleetSelector.Fleet(node), names)
+}
+
+func (c *ListenerCache) query(fleet string, names []string) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	var values []proto.Message
+	values := c.valuesFor(fleet)
+	var result []proto.Message
 	for _, n := range names {
-		v, ok := c.values[n]
+		v, ok := values[n]
 		if !ok {
 			v, ok = c.staticValues[n]
 			if !ok {
@@ -245,10 +279,20 @@ func (c *ListenerCache) Query(names []string) []proto.Message {
 				continue
 			}
 		}
-		values = append(values, v)
+		result = append(result, v)
 	}
-	sort.Stable(listenersByName(values))
-	return values
+	sort.Stable(listenersByName(result))
+	return result
+}
+
+// valuesFor returns the listeners for fleet, or the default
+// listeners if there are none specific to fleet.
+// The caller must hold c.mu.
+func (c *ListenerCache) valuesFor(fleet string) map[string]*v2.Listener {
+	if v, ok := c.fleetValues[fleet]; ok {
+		return v
+	}
+	return c.values
 }
 
 type listenersByName []proto.Message
@@ -259,18 +303,25 @@ func (l listenersByName) Less(i, j int) bool {
 	return l[i].(*v2.Listener).Name < l[j].(*v2.Listener).Name
 }
 
-func (*ListenerCache) TypeURL() string { return cache.ListenerType }
+func (*ListenerCache) TypeURL() string { return resource.ListenerType }
 
 type listenerVisitor struct {
 	*ListenerVisitorConfig
 
+	fleet     string
 	listeners map[string]*v2.Listener
 	http      bool // at least one dag.VirtualHost encountered
 }
 
 func visitListeners(root dag.Vertex, lvc *ListenerVisitorConfig) map[string]*v2.Listener {
+	return visitFleetListeners(root, lvc, "")
+}
+
+// visitFleetListeners returns the listeners for the members of fleet.
+func visitFleetListeners(root dag.Vertex, lvc *ListenerVisitorConfig, fleet string) map[string]*v2.Listener {
 	lv := listenerVisitor{
 		ListenerVisitorConfig: lvc,
+		fleet:                 fleet,
 		listeners: map[string]*v2.Listener{
 			ENVOY_HTTPS_LISTENER: envoy.Listener(
 				ENVOY_HTTPS_LISTENER,
@@ -333,11 +384,17 @@ func (v *listenerVisitor) visit(vertex dag.Vertex) {
 
 	switch vh := vertex.(type) {
 	case *dag.VirtualHost:
+		if !servedTo(vh, v.fleet) {
+			return
+		}
 		// we only create on http listener so record the fact
 		// that we need to then double back at the end and add
 		// the listener properly.
 		v.http = true
 	case *dag.SecureVirtualHost:
+		if !servedTo(&vh.VirtualHost, v.fleet) {
+			return
+		}
 		filters := envoy.Filters(
 			envoy.HTTPConnectionManager(ENVOY_HTTPS_LISTENER, v.ListenerVisitorConfig.newSecureAccessLog()),
 		)
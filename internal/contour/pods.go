@@ -0,0 +1,70 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"github.com/projectcontour/contour/internal/dag"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// podWeights records the endpoint weight of each Pod which has one.
+// podWeights is not safe for concurrent use.
+type podWeights struct {
+	weights map[types.NamespacedName]uint32
+}
+
+// updatePod records the endpoint weight of pod, returning
+// true if the pod's weight has changed.
+func (w *podWeights) updatePod(pod *v1.Pod) bool {
+	name := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
+	weight := dag.EndpointWeight(pod.Annotations)
+	if weight == w.weights[name] {
+		return false
+	}
+	if weight == 0 {
+		delete(w.weights, name)
+		return true
+	}
+	if w.weights == nil {
+		w.weights = make(map[types.NamespacedName]uint32)
+	}
+	w.weights[name] = weight
+	return true
+}
+
+// removePod forgets the endpoint weight of pod, returning
+// true if the pod's weight was known.
+func (w *podWeights) removePod(pod *v1.Pod) bool {
+	name := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
+	if _, ok := w.weights[name]; !ok {
+		return false
+	}
+	delete(w.weights, name)
+	return true
+}
+
+// weight returns the endpoint weight of the Pod ref refers to,
+// or zero if ref does not refer to a Pod with a weight.
+func (w *podWeights) weight(ref *v1.ObjectReference) uint32 {
+	if ref == nil || ref.Kind != "Pod" {
+		return 0
+	}
+	return w.weights[types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}]
+}
+
+// isPod returns true if ref refers to the named Pod.
+func isPod(ref *v1.ObjectReference, name types.NamespacedName) bool {
+	return ref != nil && ref.Kind == "Pod" && ref.Namespace == name.Namespace && ref.Name == name.Name
+}
@@ -0,0 +1,90 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"testing"
+
+	envoy_api_v2_endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/projectcontour/contour/internal/protobuf"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodWeightsUpdatePod(t *testing.T) {
+	var w podWeights
+
+	p1 := pod("default", "kuard-1", "10")
+	if !w.updatePod(p1) {
+		t.Fatal("expected weight of new pod to have changed")
+	}
+	if w.updatePod(p1) {
+		t.Fatal("expected weight of unchanged pod not to have changed")
+	}
+	if got := w.weight(podRef("default", "kuard-1")); got != 10 {
+		t.Fatalf("expected weight 10, got %d", got)
+	}
+
+	p2 := pod("default", "kuard-1", "")
+	if !w.updatePod(p2) {
+		t.Fatal("expected weight of unannotated pod to have changed")
+	}
+	if got := w.weight(podRef("default", "kuard-1")); got != 0 {
+		t.Fatalf("expected weight 0, got %d", got)
+	}
+
+	if w.updatePod(pod("default", "kuard-2", "")) {
+		t.Fatal("expected unannotated pod not to have a weight")
+	}
+
+	p3 := pod("default", "kuard-3", "5")
+	w.updatePod(p3)
+	if got := w.weight(&v1.ObjectReference{Kind: "Node", Namespace: "default", Name: "kuard-3"}); got != 0 {
+		t.Fatalf("expected weight of non pod reference to be 0, got %d", got)
+	}
+	if !w.removePod(p3) {
+		t.Fatal("expected weight of removed pod to have changed")
+	}
+	if w.removePod(p3) {
+		t.Fatal("expected weight of unknown pod not to have changed")
+	}
+}
+
+func pod(ns, name, weight string) *v1.Pod {
+	p := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+	}
+	if weight != "" {
+		p.Annotations = map[string]string{
+			"projectcontour.io/endpoint-weight": weight,
+		}
+	}
+	return p
+}
+
+func podRef(ns, name string) *v1.ObjectReference {
+	return &v1.ObjectReference{
+		Kind:      "Pod",
+		Namespace: ns,
+		Name:      name,
+	}
+}
+
+func weighted(lbe *envoy_api_v2_endpoint.LbEndpoint, weight uint32) *envoy_api_v2_endpoint.LbEndpoint {
+	lbe.LoadBalancingWeight = protobuf.UInt32(weight)
+	return lbe
+}
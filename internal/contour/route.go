@@ -18,8 +18,9 @@ import (
 	"sync"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
-	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
 	"github.com/golang/protobuf/proto"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
@@ -27,26 +28,48 @@ import (
 
 // RouteCache manages the contents of the gRPC RDS cache.
 type RouteCache struct {
-	mu     sync.Mutex
-	values map[string]*v2.RouteConfiguration
+	mu          sync.Mutex
+	values      map[string]*v2.RouteConfiguration
+	fleetValues map[string]map[string]*v2.RouteConfiguration
+
+	// FleetSelector, if set, selects the fleet whose
+	// routes are served to each Envoy node.
+	FleetSelector *FleetSelector
 	Cond
 }
 
 // Update replaces the contents of the cache with the supplied map.
 func (c *RouteCache) Update(v map[string]*v2.RouteConfiguration) {
+	c.update(v, nil)
+}
+
+// update replaces the contents of the cache with the supplied maps.
+// fleets holds the route configurations for each fleet, keyed by fleet name.
+func (c *RouteCache) update(v map[string]*v2.RouteConfiguration, fleets map[string]map[string]*v2.RouteConfiguration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	c.values = v
+	c.fleetValues = fleets
 	c.Cond.Notify()
 }
 
 // Contents returns a copy of the cache's contents.
 func (c *RouteCache) Contents() []proto.Message {
+	return c.contents("")
+}
+
+// NodeContents returns a copy of the cache's contents for
+// the fleet which node is a member of.
+func (c *RouteCache) NodeContents(node *envoy_api_v2_core.Node) []proto.Message {
+	return c.contents(c.FleetSelector.Fleet(node))
+}
+
+func (c *RouteCache) contents(fleet string) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	var values []proto.Message
-	for _, v := range c.values {
+	for _, v := range c.valuesFor(fleet) {
 		values = append(values, v)
 	}
 	sort.Stable(routeConfigurationsByName(values))
@@ -54,11 +77,22 @@ func (c *RouteCache) Contents() []proto.Message {
 }
 
 func (c *RouteCache) Query(names []string) []proto.Message {
+	return c.query("", names)
+}
+
+// NodeQuery returns the route configurations for the fleet which
+// node is a member of that match a slice of strings.
+func (c *RouteCache) NodeQuery(node *envoy_api_v2_core.Node, names []string) []proto.Message {
+	return c.query(c.FleetSelector.Fleet(node), names)
+}
+
+func (c *RouteCache) query(fleet string, names []string) []proto.Message {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	fv := c.valuesFor(fleet)
 	var values []proto.Message
 	for _, n := range names {
-		v, ok := c.values[n]
+		v, ok := fv[n]
 		if !ok {
 			// if there is no route registered with the cache
 			// we return a blank route configuration. This is
@@ -75,6 +109,16 @@ func (c *RouteCache) Query(names []string) []proto.Message {
 	return values
 }
 
+// valuesFor returns the route configurations for fleet, or the
+// default route configurations if there are none specific to fleet.
+// The caller must hold c.mu.
+func (c *RouteCache) valuesFor(fleet string) map[string]*v2.RouteConfiguration {
+	if v, ok := c.fleetValues[fleet]; ok {
+		return v
+	}
+	return c.values
+}
+
 type routeConfigurationsByName []proto.Message
 
 func (r routeConfigurationsByName) Len() int      { return len(r) }
@@ -83,14 +127,21 @@ func (r routeConfigurationsByName) Less(i, j int) bool {
 	return r[i].(*v2.RouteConfiguration).Name < r[j].(*v2.RouteConfiguration).Name
 }
 
-func (*RouteCache) TypeURL() string { return cache.RouteType }
+func (*RouteCache) TypeURL() string { return resource.RouteType }
 
 type routeVisitor struct {
+	fleet  string
 	routes map[string]*v2.RouteConfiguration
 }
 
 func visitRoutes(root dag.Vertex) map[string]*v2.RouteConfiguration {
+	return visitFleetRoutes(root, "")
+}
+
+// visitFleetRoutes returns the route configurations for the members of fleet.
+func visitFleetRoutes(root dag.Vertex, fleet string) map[string]*v2.RouteConfiguration {
 	rv := routeVisitor{
+		fleet: fleet,
 		routes: map[string]*v2.RouteConfiguration{
 			"ingress_http": {
 				Name: "ingress_http",
@@ -113,6 +164,9 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 		l.Visit(func(vertex dag.Vertex) {
 			switch vh := vertex.(type) {
 			case *dag.VirtualHost:
+				if !servedTo(vh, v.fleet) {
+					return
+				}
 				var routes []*envoy_api_v2_route.Route
 				vh.Visit(func(v dag.Vertex) {
 					switch r := v.(type) {
@@ -141,6 +195,9 @@ func (v *routeVisitor) visit(vertex dag.Vertex) {
 				vhost := envoy.VirtualHost(vh.Name, routes...)
 				v.routes["ingress_http"].VirtualHosts = append(v.routes["ingress_http"].VirtualHosts, vhost)
 			case *dag.SecureVirtualHost:
+				if !servedTo(&vh.VirtualHost, v.fleet) {
+					return
+				}
 				var routes []*envoy_api_v2_route.Route
 				vh.Visit(func(v dag.Vertex) {
 					switch r := v.(type) {
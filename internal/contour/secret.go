@@ -18,7 +18,7 @@ import (
 	"sync"
 
 	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
-	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
 	"github.com/golang/protobuf/proto"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/envoy"
@@ -76,7 +76,7 @@ func (s secretsByName) Less(i, j int) bool {
 	return s[i].(*envoy_api_v2_auth.Secret).Name < s[j].(*envoy_api_v2_auth.Secret).Name
 }
 
-func (*SecretCache) TypeURL() string { return cache.SecretType }
+func (*SecretCache) TypeURL() string { return resource.SecretType }
 
 type secretVisitor struct {
 	secrets map[string]*envoy_api_v2_auth.Secret
@@ -92,16 +92,13 @@ func visitSecrets(root dag.Vertex) map[string]*envoy_api_v2_auth.Secret {
 }
 
 func (v *secretVisitor) visit(vertex dag.Vertex) {
-	switch svh := vertex.(type) {
-	case *dag.SecureVirtualHost:
-		if svh.Secret != nil {
-			name := envoy.Secretname(svh.Secret)
-			if _, ok := v.secrets[name]; !ok {
-				s := envoy.Secret(svh.Secret)
-				v.secrets[s.Name] = s
-			}
+	if secret, ok := vertex.(*dag.Secret); ok {
+		name := envoy.Secretname(secret)
+		if _, ok := v.secrets[name]; !ok {
+			s := envoy.Secret(secret)
+			v.secrets[s.Name] = s
 		}
-	default:
-		vertex.Visit(v.visit)
+		return
 	}
+	vertex.Visit(v.visit)
 }
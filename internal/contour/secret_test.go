@@ -456,6 +456,47 @@ func TestSecretVisit(t *testing.T) {
 				secret("default/secret-b/0a068be4ba", "cert-b", "key-b"),
 			),
 		},
+		"httpproxy with client certificate": {
+			objs: []interface{}{
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:       "https",
+							Protocol:   "TCP",
+							Port:       8443,
+							TargetPort: intstr.FromInt(8443),
+						}},
+					},
+				},
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "www.example.com",
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name:              "kuard",
+								Port:              8443,
+								Protocol:          "tls",
+								ClientCertificate: "clientcert",
+							}},
+						}},
+					},
+				},
+				tlssecret("default", "clientcert", secretdata("cert", "key")),
+			},
+			want: secretmap(
+				secret("default/clientcert/cd1b506996", "cert", "key"),
+			),
+		},
 	}
 
 	for name, tc := range tests {
@@ -0,0 +1,138 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/projectcontour/contour/internal/dag"
+	v1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// notReadyPolicy describes how the not ready endpoints
+// of a service are translated.
+type notReadyPolicy struct {
+	// publish is true if the service publishes its not ready
+	// addresses, which are then treated as ready.
+	publish bool
+
+	// status is the health status with which not ready endpoints
+	// are sent to Envoy, if include is true. Otherwise not ready
+	// endpoints are removed.
+	include bool
+	status  envoy_api_v2_core.HealthStatus
+}
+
+// serviceNotReadyPolicy returns the notReadyPolicy of svc.
+func serviceNotReadyPolicy(svc *v1.Service) notReadyPolicy {
+	p := notReadyPolicy{
+		publish: svc.Spec.PublishNotReadyAddresses,
+	}
+	switch dag.NotReadyEndpoints(svc.Annotations) {
+	case "Draining":
+		p.include, p.status = true, envoy_api_v2_core.HealthStatus_DRAINING
+	case "Unhealthy":
+		p.include, p.status = true, envoy_api_v2_core.HealthStatus_UNHEALTHY
+	}
+	return p
+}
+
+// serviceRecord records the parts of a Service
+// which affect the translation of its endpoints.
+type serviceRecord struct {
+	notReady notReadyPolicy
+	ports    []v1.ServicePort
+}
+
+// endpointServices records the Services whose endpoints are translated.
+// endpointServices is not safe for concurrent use.
+type endpointServices struct {
+	services map[types.NamespacedName]serviceRecord
+}
+
+// updateService records svc, returning true if the
+// translation of its endpoints may have changed.
+func (s *endpointServices) updateService(svc *v1.Service) bool {
+	name := types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}
+	rec := serviceRecord{
+		notReady: serviceNotReadyPolicy(svc),
+		ports:    svc.Spec.Ports,
+	}
+	if old, ok := s.services[name]; ok && old.notReady == rec.notReady && apiequality.Semantic.DeepEqual(old.ports, rec.ports) {
+		return false
+	}
+	if s.services == nil {
+		s.services = make(map[types.NamespacedName]serviceRecord)
+	}
+	s.services[name] = rec
+	return true
+}
+
+// removeService forgets svc, returning true if it was known.
+func (s *endpointServices) removeService(svc *v1.Service) bool {
+	name := types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}
+	if _, ok := s.services[name]; !ok {
+		return false
+	}
+	delete(s.services, name)
+	return true
+}
+
+// notReadyPolicy returns the notReadyPolicy of the named service.
+func (s *endpointServices) notReadyPolicy(name types.NamespacedName) notReadyPolicy {
+	return s.services[name].notReady
+}
+
+// portNames returns the names of the ports of the named service which
+// the endpoint port, portname and port, resolves to. Endpoint ports
+// managed by Kubernetes share the name of their service port, but those
+// of manually managed endpoints may instead be named after a service
+// port's named targetPort, or match its numeric targetPort. If the
+// service is not known, or no service port matches, portname is returned.
+func (s *endpointServices) portNames(name types.NamespacedName, portname string, port int32) []string {
+	rec, ok := s.services[name]
+	if !ok {
+		return []string{portname}
+	}
+	for _, sp := range rec.ports {
+		if sp.Name == portname {
+			return []string{portname}
+		}
+	}
+
+	var names []string
+	for _, sp := range rec.ports {
+		switch sp.TargetPort.Type {
+		case intstr.String:
+			if sp.TargetPort.StrVal == portname {
+				names = append(names, sp.Name)
+			}
+		default:
+			target := int32(sp.TargetPort.IntValue())
+			if target == 0 {
+				// targetPort defaults to port.
+				target = sp.Port
+			}
+			if target == port {
+				names = append(names, sp.Name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return []string{portname}
+	}
+	return names
+}
@@ -0,0 +1,130 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestEndpointServicesPortNames(t *testing.T) {
+	svc := service("default", "simple",
+		v1.ServicePort{
+			Name:       "http",
+			Protocol:   "TCP",
+			Port:       80,
+			TargetPort: intstr.FromString("web"),
+		},
+		v1.ServicePort{
+			Name:       "metrics",
+			Protocol:   "TCP",
+			Port:       9000,
+			TargetPort: intstr.FromInt(9100),
+		},
+		v1.ServicePort{
+			Name:     "admin",
+			Protocol: "TCP",
+			Port:     9001,
+		},
+	)
+
+	tests := map[string]struct {
+		service  types.NamespacedName
+		portname string
+		port     int32
+		want     []string
+	}{
+		"unknown service": {
+			service:  types.NamespacedName{Namespace: "default", Name: "other"},
+			portname: "web",
+			port:     8080,
+			want:     []string{"web"},
+		},
+		"service port name": {
+			service:  types.NamespacedName{Namespace: "default", Name: "simple"},
+			portname: "http",
+			port:     8080,
+			want:     []string{"http"},
+		},
+		"named target port": {
+			service:  types.NamespacedName{Namespace: "default", Name: "simple"},
+			portname: "web",
+			port:     8080,
+			want:     []string{"http"},
+		},
+		"numeric target port": {
+			service:  types.NamespacedName{Namespace: "default", Name: "simple"},
+			portname: "",
+			port:     9100,
+			want:     []string{"metrics"},
+		},
+		"default target port": {
+			service:  types.NamespacedName{Namespace: "default", Name: "simple"},
+			portname: "",
+			port:     9001,
+			want:     []string{"admin"},
+		},
+		"no match": {
+			service:  types.NamespacedName{Namespace: "default", Name: "simple"},
+			portname: "debug",
+			port:     6060,
+			want:     []string{"debug"},
+		},
+	}
+
+	var s endpointServices
+	s.updateService(svc)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := s.portNames(tc.service, tc.portname, tc.port)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestEndpointServicesUpdateService(t *testing.T) {
+	var s endpointServices
+
+	s1 := service("default", "simple", v1.ServicePort{Name: "http", Protocol: "TCP", Port: 80})
+	if !s.updateService(s1) {
+		t.Fatal("expected new service to have changed")
+	}
+	if s.updateService(s1) {
+		t.Fatal("expected unchanged service not to have changed")
+	}
+
+	s2 := service("default", "simple", v1.ServicePort{Name: "http", Protocol: "TCP", Port: 8080})
+	if !s.updateService(s2) {
+		t.Fatal("expected service with changed ports to have changed")
+	}
+
+	s3 := service("default", "simple", v1.ServicePort{Name: "http", Protocol: "TCP", Port: 8080})
+	s3.Spec.PublishNotReadyAddresses = true
+	if !s.updateService(s3) {
+		t.Fatal("expected service with changed not ready policy to have changed")
+	}
+
+	if !s.removeService(s3) {
+		t.Fatal("expected removed service to have changed")
+	}
+	if s.removeService(s3) {
+		t.Fatal("expected unknown service not to have changed")
+	}
+}
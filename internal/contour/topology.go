@@ -0,0 +1,165 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"sort"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/golang/protobuf/proto"
+	"github.com/projectcontour/contour/internal/envoy"
+	"github.com/projectcontour/contour/internal/protobuf"
+	v1 "k8s.io/api/core/v1"
+)
+
+// nodeTopology records the locality of each node in the cluster.
+// nodeTopology is not safe for concurrent use.
+type nodeTopology struct {
+	localities map[string]*envoy_api_v2_core.Locality
+}
+
+// updateNode records the locality of node, returning
+// true if the node's locality has changed.
+func (t *nodeTopology) updateNode(node *v1.Node) bool {
+	locality := nodeLocality(node)
+	if proto.Equal(locality, t.localities[node.Name]) {
+		return false
+	}
+	if locality == nil {
+		delete(t.localities, node.Name)
+		return true
+	}
+	if t.localities == nil {
+		t.localities = make(map[string]*envoy_api_v2_core.Locality)
+	}
+	t.localities[node.Name] = locality
+	return true
+}
+
+// removeNode forgets the locality of node, returning
+// true if the node's locality was known.
+func (t *nodeTopology) removeNode(node *v1.Node) bool {
+	if _, ok := t.localities[node.Name]; !ok {
+		return false
+	}
+	delete(t.localities, node.Name)
+	return true
+}
+
+// locality returns the locality of the named node, or nil if
+// the node is unknown or does not carry any topology labels.
+func (t *nodeTopology) locality(nodename string) *envoy_api_v2_core.Locality {
+	return t.localities[nodename]
+}
+
+// nodeLocality returns the locality of node from its well known
+// topology labels, falling back to the deprecated failure domain
+// labels, or nil if node carries neither.
+func nodeLocality(node *v1.Node) *envoy_api_v2_core.Locality {
+	label := func(key, deprecated string) string {
+		if v, ok := node.Labels[key]; ok {
+			return v
+		}
+		return node.Labels[deprecated]
+	}
+	region := label(v1.LabelTopologyRegion, v1.LabelFailureDomainBetaRegion)
+	zone := label(v1.LabelTopologyZone, v1.LabelFailureDomainBetaZone)
+	if region == "" && zone == "" {
+		return nil
+	}
+	return &envoy_api_v2_core.Locality{
+		Region: region,
+		Zone:   zone,
+	}
+}
+
+// localityAddress is the address of an endpoint and its locality, if known.
+type localityAddress struct {
+	address  *envoy_api_v2_core.Address
+	locality *envoy_api_v2_core.Locality
+
+	// healthStatus is the health status of the endpoint,
+	// or HealthStatus_UNKNOWN if the endpoint is ready.
+	healthStatus envoy_api_v2_core.HealthStatus
+
+	// weight is the load balancing weight of the endpoint,
+	// or zero if the endpoint is not weighted.
+	weight uint32
+}
+
+// clusterLoadAssignment returns a *v2.ClusterLoadAssignment for the
+// supplied addresses. If the locality of any address is known, the
+// addresses are grouped by locality, each weighted by the sum of the
+// weights of its addresses, otherwise they are returned in a single group.
+// Addresses without a weight count as a weight of one.
+func clusterLoadAssignment(name string, addrs []localityAddress) *v2.ClusterLoadAssignment {
+	groups := make(map[string]*envoy_api_v2_endpoint.LocalityLbEndpoints)
+	weights := make(map[string]uint32)
+	for _, a := range addrs {
+		key := localityKey(a.locality)
+		g, ok := groups[key]
+		if !ok {
+			g = &envoy_api_v2_endpoint.LocalityLbEndpoints{
+				Locality: a.locality,
+			}
+			groups[key] = g
+		}
+		lbe := envoy.LBEndpoint(a.address)
+		lbe.HealthStatus = a.healthStatus
+		g.LbEndpoints = append(g.LbEndpoints, lbe)
+
+		weight := uint32(1)
+		if a.weight > 0 {
+			weight = a.weight
+			lbe.LoadBalancingWeight = protobuf.UInt32(a.weight)
+		}
+		weights[key] += weight
+	}
+
+	if _, ok := groups[""]; ok && len(groups) == 1 {
+		// no localities are known, don't weight the endpoints
+		// so that locality weighted load balancing is not
+		// applied to them.
+		return &v2.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints:   []*envoy_api_v2_endpoint.LocalityLbEndpoints{groups[""]},
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cla := &v2.ClusterLoadAssignment{
+		ClusterName: name,
+	}
+	for _, k := range keys {
+		g := groups[k]
+		g.LoadBalancingWeight = protobuf.UInt32(weights[k])
+		cla.Endpoints = append(cla.Endpoints, g)
+	}
+	return cla
+}
+
+// localityKey returns a key which sorts l by region, zone, and sub zone.
+func localityKey(l *envoy_api_v2_core.Locality) string {
+	if l == nil {
+		return ""
+	}
+	return l.Region + "\x00" + l.Zone + "\x00" + l.SubZone
+}
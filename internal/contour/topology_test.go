@@ -0,0 +1,228 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contour
+
+import (
+	"testing"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/google/go-cmp/cmp"
+	"github.com/projectcontour/contour/internal/envoy"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"google.golang.org/protobuf/testing/protocmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeLocality(t *testing.T) {
+	tests := map[string]struct {
+		labels map[string]string
+		want   *envoy_api_v2_core.Locality
+	}{
+		"no labels": {
+			labels: nil,
+			want:   nil,
+		},
+		"topology labels": {
+			labels: map[string]string{
+				v1.LabelTopologyRegion: "us-east-1",
+				v1.LabelTopologyZone:   "us-east-1a",
+			},
+			want: locality("us-east-1", "us-east-1a"),
+		},
+		"deprecated failure domain labels": {
+			labels: map[string]string{
+				v1.LabelFailureDomainBetaRegion: "us-east-1",
+				v1.LabelFailureDomainBetaZone:   "us-east-1b",
+			},
+			want: locality("us-east-1", "us-east-1b"),
+		},
+		"topology labels take precedence": {
+			labels: map[string]string{
+				v1.LabelTopologyZone:          "us-east-1a",
+				v1.LabelFailureDomainBetaZone: "us-east-1b",
+			},
+			want: locality("", "us-east-1a"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := nodeLocality(node("node-1", tc.labels))
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestNodeTopologyUpdateNode(t *testing.T) {
+	var topo nodeTopology
+
+	n1 := node("node-1", map[string]string{v1.LabelTopologyZone: "us-east-1a"})
+	if !topo.updateNode(n1) {
+		t.Fatal("expected locality of new node to have changed")
+	}
+	if topo.updateNode(n1) {
+		t.Fatal("expected locality of unchanged node not to have changed")
+	}
+
+	n2 := node("node-1", map[string]string{v1.LabelTopologyZone: "us-east-1b"})
+	if !topo.updateNode(n2) {
+		t.Fatal("expected locality of relabelled node to have changed")
+	}
+	if diff := cmp.Diff(locality("", "us-east-1b"), topo.locality("node-1"), protocmp.Transform()); diff != "" {
+		t.Fatal(diff)
+	}
+
+	if !topo.removeNode(n2) {
+		t.Fatal("expected locality of removed node to have changed")
+	}
+	if topo.removeNode(n2) {
+		t.Fatal("expected locality of unknown node not to have changed")
+	}
+
+	if topo.updateNode(node("node-2", nil)) {
+		t.Fatal("expected unlabelled node not to have a locality")
+	}
+}
+
+func TestClusterLoadAssignment(t *testing.T) {
+	tests := map[string]struct {
+		addrs []localityAddress
+		want  *v2.ClusterLoadAssignment
+	}{
+		"no localities": {
+			addrs: []localityAddress{{
+				address: envoy.SocketAddress("10.0.0.1", 8080),
+			}, {
+				address: envoy.SocketAddress("10.0.0.2", 8080),
+			}},
+			want: envoy.ClusterLoadAssignment("default/simple",
+				envoy.SocketAddress("10.0.0.1", 8080),
+				envoy.SocketAddress("10.0.0.2", 8080),
+			),
+		},
+		"localities": {
+			addrs: []localityAddress{{
+				address:  envoy.SocketAddress("10.0.0.1", 8080),
+				locality: locality("us-east-1", "us-east-1b"),
+			}, {
+				address: envoy.SocketAddress("10.0.0.2", 8080),
+			}, {
+				address:  envoy.SocketAddress("10.0.0.3", 8080),
+				locality: locality("us-east-1", "us-east-1a"),
+			}, {
+				address:  envoy.SocketAddress("10.0.0.4", 8080),
+				locality: locality("us-east-1", "us-east-1b"),
+			}},
+			want: &v2.ClusterLoadAssignment{
+				ClusterName: "default/simple",
+				Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
+					LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+						envoy.LBEndpoint(envoy.SocketAddress("10.0.0.2", 8080)),
+					},
+					LoadBalancingWeight: protobuf.UInt32(1),
+				}, {
+					Locality: locality("us-east-1", "us-east-1a"),
+					LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+						envoy.LBEndpoint(envoy.SocketAddress("10.0.0.3", 8080)),
+					},
+					LoadBalancingWeight: protobuf.UInt32(1),
+				}, {
+					Locality: locality("us-east-1", "us-east-1b"),
+					LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+						envoy.LBEndpoint(envoy.SocketAddress("10.0.0.1", 8080)),
+						envoy.LBEndpoint(envoy.SocketAddress("10.0.0.4", 8080)),
+					},
+					LoadBalancingWeight: protobuf.UInt32(2),
+				}},
+			},
+		},
+		"weights": {
+			addrs: []localityAddress{{
+				address: envoy.SocketAddress("10.0.0.1", 8080),
+				weight:  10,
+			}, {
+				address: envoy.SocketAddress("10.0.0.2", 8080),
+			}},
+			want: &v2.ClusterLoadAssignment{
+				ClusterName: "default/simple",
+				Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
+					LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+						weighted(envoy.LBEndpoint(envoy.SocketAddress("10.0.0.1", 8080)), 10),
+						envoy.LBEndpoint(envoy.SocketAddress("10.0.0.2", 8080)),
+					},
+				}},
+			},
+		},
+		"weighted localities": {
+			addrs: []localityAddress{{
+				address:  envoy.SocketAddress("10.0.0.1", 8080),
+				locality: locality("us-east-1", "us-east-1a"),
+				weight:   4,
+			}, {
+				address:  envoy.SocketAddress("10.0.0.2", 8080),
+				locality: locality("us-east-1", "us-east-1b"),
+			}, {
+				address:  envoy.SocketAddress("10.0.0.3", 8080),
+				locality: locality("us-east-1", "us-east-1b"),
+			}},
+			want: &v2.ClusterLoadAssignment{
+				ClusterName: "default/simple",
+				Endpoints: []*envoy_api_v2_endpoint.LocalityLbEndpoints{{
+					Locality: locality("us-east-1", "us-east-1a"),
+					LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+						weighted(envoy.LBEndpoint(envoy.SocketAddress("10.0.0.1", 8080)), 4),
+					},
+					LoadBalancingWeight: protobuf.UInt32(4),
+				}, {
+					Locality: locality("us-east-1", "us-east-1b"),
+					LbEndpoints: []*envoy_api_v2_endpoint.LbEndpoint{
+						envoy.LBEndpoint(envoy.SocketAddress("10.0.0.2", 8080)),
+						envoy.LBEndpoint(envoy.SocketAddress("10.0.0.3", 8080)),
+					},
+					LoadBalancingWeight: protobuf.UInt32(2),
+				}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := clusterLoadAssignment("default/simple", tc.addrs)
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func node(name string, labels map[string]string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+	}
+}
+
+func locality(region, zone string) *envoy_api_v2_core.Locality {
+	return &envoy_api_v2_core.Locality{
+		Region: region,
+		Zone:   zone,
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package convert converts IngressRoute objects to HTTPProxy objects.
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	ingressroutev1 "github.com/projectcontour/contour/apis/contour/v1beta1"
+	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// lastAppliedConfiguration is the annotation kubectl apply records
+// the applied object in, which is not copied to the HTTPProxy.
+const lastAppliedConfiguration = "kubectl.kubernetes.io/last-applied-configuration"
+
+// A Warning describes a part of an IngressRoute
+// which does not translate cleanly to HTTPProxy.
+type Warning struct {
+	Namespace string
+	Name      string
+	Message   string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("IngressRoute %s/%s: %s", w.Namespace, w.Name, w.Message)
+}
+
+type meta struct {
+	name, namespace string
+}
+
+func toMeta(ir *ingressroutev1.IngressRoute) meta {
+	return meta{name: ir.Name, namespace: ir.Namespace}
+}
+
+// delegateMeta returns the meta of the IngressRoute d, a delegate of ir, refers to.
+func delegateMeta(ir *ingressroutev1.IngressRoute, d *ingressroutev1.Delegate) meta {
+	namespace := d.Namespace
+	if namespace == "" {
+		namespace = ir.Namespace
+	}
+	return meta{name: d.Name, namespace: namespace}
+}
+
+// converter holds the state of a conversion of a set of IngressRoutes.
+type converter struct {
+	ingressroutes map[meta]*ingressroutev1.IngressRoute
+
+	// prefixes holds the path prefixes each IngressRoute
+	// is delegated to with by its parents.
+	prefixes map[meta][]string
+
+	// tcpproxies records the IngressRoutes delegated to by a tcpproxy.
+	tcpproxies map[meta]bool
+
+	warnings []Warning
+}
+
+// IngressRoutes converts irs to HTTPProxies, returned in the same order,
+// and returns Warnings for the parts of irs which do not translate cleanly.
+//
+// The delegates of irs are converted to includes. The routes of an
+// IngressRoute delegated to by another of irs are converted relative to
+// the path prefix it is delegated with, so that the included HTTPProxy
+// serves the same paths.
+func IngressRoutes(irs []*ingressroutev1.IngressRoute) ([]*projcontour.HTTPProxy, []Warning) {
+	c := converter{
+		ingressroutes: make(map[meta]*ingressroutev1.IngressRoute),
+		prefixes:      make(map[meta][]string),
+		tcpproxies:    make(map[meta]bool),
+	}
+	for _, ir := range irs {
+		c.ingressroutes[toMeta(ir)] = ir
+	}
+	for _, ir := range irs {
+		if ir.Spec.VirtualHost != nil {
+			c.walk(ir, nil)
+		}
+	}
+
+	var proxies []*projcontour.HTTPProxy
+	for _, ir := range irs {
+		proxies = append(proxies, c.convert(ir))
+	}
+	return proxies, c.warnings
+}
+
+func (c *converter) warn(ir *ingressroutev1.IngressRoute, format string, args ...interface{}) {
+	c.warnings = append(c.warnings, Warning{
+		Namespace: ir.Namespace,
+		Name:      ir.Name,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// walk records the path prefixes of the delegates of ir and their
+// delegates in turn. visited holds the IngressRoutes delegating to ir.
+func (c *converter) walk(ir *ingressroutev1.IngressRoute, visited []meta) {
+	visited = append(visited, toMeta(ir))
+
+	follow := func(d *ingressroutev1.Delegate, record func(meta) bool) {
+		m := delegateMeta(ir, d)
+		dest, ok := c.ingressroutes[m]
+		if !ok {
+			return
+		}
+		for _, v := range visited {
+			if v == m {
+				c.warn(ir, "delegate %s/%s creates a delegation cycle", m.namespace, m.name)
+				return
+			}
+		}
+		if record(m) {
+			c.walk(dest, visited)
+		}
+	}
+
+	for _, route := range ir.Spec.Routes {
+		if route.Delegate == nil {
+			continue
+		}
+		prefix := route.Match
+		follow(route.Delegate, func(m meta) bool {
+			for _, p := range c.prefixes[m] {
+				if p == prefix {
+					// already walked.
+					return false
+				}
+			}
+			c.prefixes[m] = append(c.prefixes[m], prefix)
+			return true
+		})
+	}
+
+	if tcpproxy := ir.Spec.TCPProxy; tcpproxy != nil && tcpproxy.Delegate != nil {
+		follow(tcpproxy.Delegate, func(m meta) bool {
+			if c.tcpproxies[m] {
+				return false
+			}
+			c.tcpproxies[m] = true
+			return true
+		})
+	}
+}
+
+// convert returns the HTTPProxy equivalent to ir.
+func (c *converter) convert(ir *ingressroutev1.IngressRoute) *projcontour.HTTPProxy {
+	proxy := &projcontour.HTTPProxy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: projcontour.SchemeGroupVersion.String(),
+			Kind:       "HTTPProxy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ir.Name,
+			Namespace:   ir.Namespace,
+			Labels:      ir.Labels,
+			Annotations: annotations(ir.Annotations),
+		},
+	}
+	if ir.Spec.VirtualHost != nil {
+		proxy.Spec.VirtualHost = ir.Spec.VirtualHost.DeepCopy()
+	}
+
+	prefix := c.prefix(ir)
+	for _, route := range ir.Spec.Routes {
+		switch {
+		case route.Delegate != nil:
+			if len(route.Services) > 0 {
+				c.warn(ir, "route %q: cannot specify services and delegate in the same route, the services are not converted", route.Match)
+			}
+			if route.EnableWebsockets || route.PermitInsecure || route.PrefixRewrite != "" || route.TimeoutPolicy != nil || route.RetryPolicy != nil {
+				c.warn(ir, "route %q: the policies of a delegating route are ignored and are not converted", route.Match)
+			}
+			proxy.Spec.Includes = append(proxy.Spec.Includes, projcontour.Include{
+				Name:      route.Delegate.Name,
+				Namespace: route.Delegate.Namespace,
+				Condition: projcontour.Condition{
+					Prefix: strings.TrimSuffix(c.relative(ir, route.Match, prefix), "/"),
+				},
+			})
+		case len(route.Services) > 0:
+			r := projcontour.Route{
+				Services:         services(route.Services),
+				EnableWebsockets: route.EnableWebsockets,
+				PermitInsecure:   route.PermitInsecure,
+				PrefixRewrite:    route.PrefixRewrite,
+				TimeoutPolicy:    route.TimeoutPolicy.DeepCopy(),
+				RetryPolicy:      route.RetryPolicy.DeepCopy(),
+			}
+			if p := c.relative(ir, route.Match, prefix); p != "" {
+				r.Condition = &projcontour.Condition{
+					Prefix: p,
+				}
+			}
+			proxy.Spec.Routes = append(proxy.Spec.Routes, r)
+		default:
+			c.warn(ir, "route %q: specifies neither services nor a delegate and is not converted", route.Match)
+		}
+	}
+
+	if tcpproxy := ir.Spec.TCPProxy; tcpproxy != nil {
+		c.warn(ir, "tcpproxy is converted, but is not yet supported by HTTPProxy")
+		proxy.Spec.TCPProxy = &projcontour.TCPProxy{
+			Services: services(tcpproxy.Services),
+		}
+		if tcpproxy.Delegate != nil {
+			proxy.Spec.TCPProxy.Include = projcontour.Include{
+				Name:      tcpproxy.Delegate.Name,
+				Namespace: tcpproxy.Delegate.Namespace,
+			}
+		}
+	}
+
+	return proxy
+}
+
+// prefix returns the path prefix ir's routes are converted relative to.
+func (c *converter) prefix(ir *ingressroutev1.IngressRoute) string {
+	if ir.Spec.VirtualHost != nil {
+		return ""
+	}
+	m := toMeta(ir)
+	prefixes := append([]string{}, c.prefixes[m]...)
+	sort.Strings(prefixes)
+	switch len(prefixes) {
+	case 0:
+		if !c.tcpproxies[m] {
+			c.warn(ir, "no converted IngressRoute delegates to this IngressRoute, its routes are converted with their full path prefix")
+		}
+		return ""
+	case 1:
+		return prefixes[0]
+	default:
+		c.warn(ir, "delegated to with the path prefixes %s, its routes are converted relative to %q", strings.Join(prefixes, ", "), prefixes[0])
+		return prefixes[0]
+	}
+}
+
+// relative returns match relative to the path prefix
+// the IngressRoute ir is delegated with.
+func (c *converter) relative(ir *ingressroutev1.IngressRoute, match, prefix string) string {
+	if !matchesPathPrefix(match, prefix) {
+		c.warn(ir, "route %q: does not match the path prefix %q this IngressRoute is delegated with", match, prefix)
+		return match
+	}
+	return strings.TrimPrefix(match, strings.TrimSuffix(prefix, "/"))
+}
+
+// matchesPathPrefix returns true if path is equal to, or a
+// path below, prefix, as IngressRoute delegation requires.
+func matchesPathPrefix(path, prefix string) bool {
+	if len(prefix) == 0 {
+		return true
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if prefix[len(prefix)-1] != '/' {
+		prefix += "/"
+	}
+	if path[len(path)-1] != '/' {
+		path += "/"
+	}
+	return strings.HasPrefix(path, prefix)
+}
+
+func services(services []ingressroutev1.Service) []projcontour.Service {
+	var converted []projcontour.Service
+	for _, s := range services {
+		converted = append(converted, projcontour.Service{
+			Name:               s.Name,
+			Port:               s.Port,
+			Weight:             s.Weight,
+			HealthCheck:        s.HealthCheck.DeepCopy(),
+			Strategy:           s.Strategy,
+			UpstreamValidation: s.UpstreamValidation.DeepCopy(),
+		})
+	}
+	return converted
+}
+
+func annotations(annotations map[string]string) map[string]string {
+	var copied map[string]string
+	for k, v := range annotations {
+		if k == lastAppliedConfiguration {
+			continue
+		}
+		if copied == nil {
+			copied = make(map[string]string)
+		}
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,420 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ingressroutev1 "github.com/projectcontour/contour/apis/contour/v1beta1"
+	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIngressRoutes(t *testing.T) {
+	ingressroute := func(name string, vhost *projcontour.VirtualHost, routes ...ingressroutev1.Route) *ingressroutev1.IngressRoute {
+		return &ingressroutev1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.IngressRouteSpec{
+				VirtualHost: vhost,
+				Routes:      routes,
+			},
+		}
+	}
+
+	httpproxy := func(name string, vhost *projcontour.VirtualHost, includes []projcontour.Include, routes ...projcontour.Route) *projcontour.HTTPProxy {
+		return &projcontour.HTTPProxy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "projectcontour.io/v1alpha1",
+				Kind:       "HTTPProxy",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: projcontour.HTTPProxySpec{
+				VirtualHost: vhost,
+				Includes:    includes,
+				Routes:      routes,
+			},
+		}
+	}
+
+	vhost := &projcontour.VirtualHost{
+		Fqdn: "example.com",
+		TLS: &projcontour.TLS{
+			SecretName: "secret",
+		},
+	}
+
+	irService := []ingressroutev1.Service{{
+		Name:   "kuard",
+		Port:   8080,
+		Weight: 10,
+	}}
+
+	proxyService := []projcontour.Service{{
+		Name:   "kuard",
+		Port:   8080,
+		Weight: 10,
+	}}
+
+	delegate := func(match, name string) ingressroutev1.Route {
+		return ingressroutev1.Route{
+			Match: match,
+			Delegate: &ingressroutev1.Delegate{
+				Name: name,
+			},
+		}
+	}
+
+	route := func(match string) ingressroutev1.Route {
+		return ingressroutev1.Route{
+			Match:    match,
+			Services: irService,
+		}
+	}
+
+	include := func(prefix, name string) projcontour.Include {
+		return projcontour.Include{
+			Name: name,
+			Condition: projcontour.Condition{
+				Prefix: prefix,
+			},
+		}
+	}
+
+	prefixRoute := func(prefix string) projcontour.Route {
+		r := projcontour.Route{
+			Services: proxyService,
+		}
+		if prefix != "" {
+			r.Condition = &projcontour.Condition{
+				Prefix: prefix,
+			}
+		}
+		return r
+	}
+
+	tests := map[string]struct {
+		irs          []*ingressroutev1.IngressRoute
+		want         []*projcontour.HTTPProxy
+		wantWarnings []string
+	}{
+		"root with routes": {
+			irs: []*ingressroutev1.IngressRoute{
+				ingressroute("root", vhost, ingressroutev1.Route{
+					Match:            "/",
+					Services:         irService,
+					EnableWebsockets: true,
+					PermitInsecure:   true,
+					PrefixRewrite:    "/app",
+					TimeoutPolicy: &projcontour.TimeoutPolicy{
+						Request: "1s",
+					},
+				}),
+			},
+			want: []*projcontour.HTTPProxy{
+				httpproxy("root", vhost, nil, projcontour.Route{
+					Condition: &projcontour.Condition{
+						Prefix: "/",
+					},
+					Services:         proxyService,
+					EnableWebsockets: true,
+					PermitInsecure:   true,
+					PrefixRewrite:    "/app",
+					TimeoutPolicy: &projcontour.TimeoutPolicy{
+						Request: "1s",
+					},
+				}),
+			},
+		},
+		"delegation tree": {
+			irs: []*ingressroutev1.IngressRoute{
+				ingressroute("root", vhost,
+					delegate("/", "all"),
+					delegate("/blog", "blog"),
+				),
+				ingressroute("all", nil, route("/")),
+				ingressroute("blog", nil,
+					route("/blog"),
+					route("/blog/admin"),
+					delegate("/blog/static/", "static"),
+				),
+				ingressroute("static", nil, route("/blog/static/css")),
+			},
+			want: []*projcontour.HTTPProxy{
+				httpproxy("root", vhost, []projcontour.Include{
+					include("", "all"),
+					include("/blog", "blog"),
+				}),
+				httpproxy("all", nil, nil, prefixRoute("/")),
+				httpproxy("blog", nil, []projcontour.Include{
+					include("/static", "static"),
+				},
+					prefixRoute(""),
+					prefixRoute("/admin"),
+				),
+				httpproxy("static", nil, nil, prefixRoute("/css")),
+			},
+		},
+		"delegated to with multiple prefixes": {
+			irs: []*ingressroutev1.IngressRoute{
+				ingressroute("root", vhost,
+					delegate("/a", "child"),
+					delegate("/b", "child"),
+				),
+				ingressroute("child", nil, route("/a/app")),
+			},
+			want: []*projcontour.HTTPProxy{
+				httpproxy("root", vhost, []projcontour.Include{
+					include("/a", "child"),
+					include("/b", "child"),
+				}),
+				httpproxy("child", nil, nil, prefixRoute("/app")),
+			},
+			wantWarnings: []string{
+				`IngressRoute default/child: delegated to with the path prefixes /a, /b, its routes are converted relative to "/a"`,
+			},
+		},
+		"orphaned ingressroute": {
+			irs: []*ingressroutev1.IngressRoute{
+				ingressroute("child", nil, route("/app")),
+			},
+			want: []*projcontour.HTTPProxy{
+				httpproxy("child", nil, nil, prefixRoute("/app")),
+			},
+			wantWarnings: []string{
+				"IngressRoute default/child: no converted IngressRoute delegates to this IngressRoute, its routes are converted with their full path prefix",
+			},
+		},
+		"route not matching the delegated prefix": {
+			irs: []*ingressroutev1.IngressRoute{
+				ingressroute("root", vhost, delegate("/a", "child")),
+				ingressroute("child", nil, route("/b")),
+			},
+			want: []*projcontour.HTTPProxy{
+				httpproxy("root", vhost, []projcontour.Include{
+					include("/a", "child"),
+				}),
+				httpproxy("child", nil, nil, prefixRoute("/b")),
+			},
+			wantWarnings: []string{
+				`IngressRoute default/child: route "/b": does not match the path prefix "/a" this IngressRoute is delegated with`,
+			},
+		},
+		"delegation cycle": {
+			irs: []*ingressroutev1.IngressRoute{
+				ingressroute("root", vhost, delegate("/", "a")),
+				ingressroute("a", nil, delegate("/", "b")),
+				ingressroute("b", nil, delegate("/", "a")),
+			},
+			want: []*projcontour.HTTPProxy{
+				httpproxy("root", vhost, []projcontour.Include{include("", "a")}),
+				httpproxy("a", nil, []projcontour.Include{include("", "b")}),
+				httpproxy("b", nil, []projcontour.Include{include("", "a")}),
+			},
+			wantWarnings: []string{
+				"IngressRoute default/b: delegate default/a creates a delegation cycle",
+			},
+		},
+		"policies of a delegating route": {
+			irs: []*ingressroutev1.IngressRoute{
+				ingressroute("root", vhost, ingressroutev1.Route{
+					Match:          "/",
+					PermitInsecure: true,
+					Delegate: &ingressroutev1.Delegate{
+						Name:      "child",
+						Namespace: "other",
+					},
+				}),
+			},
+			want: []*projcontour.HTTPProxy{
+				httpproxy("root", vhost, []projcontour.Include{{
+					Name:      "child",
+					Namespace: "other",
+				}}),
+			},
+			wantWarnings: []string{
+				`IngressRoute default/root: route "/": the policies of a delegating route are ignored and are not converted`,
+			},
+		},
+		"tcpproxy": {
+			irs: []*ingressroutev1.IngressRoute{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "root",
+					Namespace: "default",
+				},
+				Spec: ingressroutev1.IngressRouteSpec{
+					VirtualHost: vhost,
+					TCPProxy: &ingressroutev1.TCPProxy{
+						Services: irService,
+					},
+				},
+			}},
+			want: []*projcontour.HTTPProxy{{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "projectcontour.io/v1alpha1",
+					Kind:       "HTTPProxy",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "root",
+					Namespace: "default",
+				},
+				Spec: projcontour.HTTPProxySpec{
+					VirtualHost: vhost,
+					TCPProxy: &projcontour.TCPProxy{
+						Services: proxyService,
+					},
+				},
+			}},
+			wantWarnings: []string{
+				"IngressRoute default/root: tcpproxy is converted, but is not yet supported by HTTPProxy",
+			},
+		},
+		"annotations": {
+			irs: []*ingressroutev1.IngressRoute{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "root",
+					Namespace: "default",
+					Annotations: map[string]string{
+						"kubernetes.io/ingress.class":                      "contour",
+						"kubectl.kubernetes.io/last-applied-configuration": "{}",
+					},
+				},
+				Spec: ingressroutev1.IngressRouteSpec{
+					VirtualHost: vhost,
+				},
+			}},
+			want: []*projcontour.HTTPProxy{{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "projectcontour.io/v1alpha1",
+					Kind:       "HTTPProxy",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "root",
+					Namespace: "default",
+					Annotations: map[string]string{
+						"kubernetes.io/ingress.class": "contour",
+					},
+				},
+				Spec: projcontour.HTTPProxySpec{
+					VirtualHost: vhost,
+				},
+			}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, warnings := IngressRoutes(tc.irs)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+			var gotWarnings []string
+			for _, w := range warnings {
+				gotWarnings = append(gotWarnings, w.String())
+			}
+			if diff := cmp.Diff(tc.wantWarnings, gotWarnings); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestDecodeEncode(t *testing.T) {
+	const input = `apiVersion: contour.heptio.com/v1beta1
+kind: IngressRoute
+metadata:
+  name: root
+  namespace: default
+spec:
+  virtualhost:
+    fqdn: example.com
+  routes:
+  - match: /
+    delegate:
+      name: child
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: kuard
+  namespace: default
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: contour.heptio.com/v1beta1
+  kind: IngressRoute
+  metadata:
+    name: child
+    namespace: default
+  spec:
+    routes:
+    - match: /app
+      services:
+      - name: kuard
+        port: 80
+`
+
+	const want = `---
+apiVersion: projectcontour.io/v1alpha1
+kind: HTTPProxy
+metadata:
+  name: root
+  namespace: default
+spec:
+  includes:
+  - conditions: {}
+    name: child
+  virtualhost:
+    fqdn: example.com
+---
+apiVersion: projectcontour.io/v1alpha1
+kind: HTTPProxy
+metadata:
+  name: child
+  namespace: default
+spec:
+  routes:
+  - condition:
+      prefix: /app
+    services:
+    - name: kuard
+      port: 80
+`
+
+	irs, err := Decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxies, warnings := IngressRoutes(irs)
+	if len(warnings) > 0 {
+		t.Fatalf("expected no warnings, got %v", warnings)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, proxies); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatal(diff)
+	}
+}
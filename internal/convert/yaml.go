@@ -0,0 +1,114 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	ingressroutev1 "github.com/projectcontour/contour/apis/contour/v1beta1"
+	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// Decode returns the IngressRoutes in the YAML or JSON documents read
+// from r, including the items of Lists. Other objects are ignored.
+func Decode(r io.Reader) ([]*ingressroutev1.IngressRoute, error) {
+	dec := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	var irs []*ingressroutev1.IngressRoute
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			return irs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		irs, err = decodeObject(irs, raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// decodeObject appends the IngressRoutes in raw to irs.
+func decodeObject(irs []*ingressroutev1.IngressRoute, raw json.RawMessage) ([]*ingressroutev1.IngressRoute, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		// empty document.
+		return irs, nil
+	}
+	var obj struct {
+		APIVersion string            `json:"apiVersion"`
+		Kind       string            `json:"kind"`
+		Items      []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	gv, err := schema.ParseGroupVersion(obj.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case obj.Kind == "List" || obj.Kind == "IngressRouteList":
+		for _, item := range obj.Items {
+			irs, err = decodeObject(irs, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case gv.Group == ingressroutev1.GroupName && obj.Kind == "IngressRoute":
+		var ir ingressroutev1.IngressRoute
+		if err := json.Unmarshal(raw, &ir); err != nil {
+			return nil, fmt.Errorf("cannot decode IngressRoute: %v", err)
+		}
+		irs = append(irs, &ir)
+	}
+	return irs, nil
+}
+
+// Encode writes proxies to w as YAML documents. The status
+// and other fields set by the API server are omitted.
+func Encode(w io.Writer, proxies []*projcontour.HTTPProxy) error {
+	for _, proxy := range proxies {
+		data, err := json.Marshal(proxy)
+		if err != nil {
+			return err
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		delete(obj, "status")
+		if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+			delete(metadata, "creationTimestamp")
+		}
+		if spec, ok := obj["spec"].(map[string]interface{}); ok && spec["routes"] == nil {
+			delete(spec, "routes")
+		}
+
+		data, err = yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
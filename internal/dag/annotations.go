@@ -18,7 +18,7 @@ import (
 	"strconv"
 	"strings"
 
-	"k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
 )
 
 const (
@@ -37,8 +37,15 @@ const (
 	annotationRetryOn            = "contour.heptio.com/retry-on"
 	annotationNumRetries         = "contour.heptio.com/num-retries"
 	annotationPerTryTimeout      = "contour.heptio.com/per-try-timeout"
+
+	annotationLocalityLBPolicy  = "projectcontour.io/locality-lb-policy"
+	annotationNotReadyEndpoints = "projectcontour.io/not-ready-endpoints"
+	annotationEndpointWeight    = "projectcontour.io/endpoint-weight"
 )
 
+// MaxEndpointWeight is the largest weight which may be given to an endpoint.
+const MaxEndpointWeight = 100
+
 // parseUInt32 parses the supplied string as if it were a uint32.
 // If the value is not present, or malformed, or outside uint32's range, zero is returned.
 func parseUInt32(s string) uint32 {
@@ -66,19 +73,54 @@ func parseUpstreamProtocols(annotations map[string]string, annotation string, pr
 	return up
 }
 
+// localityLBPolicy parses the annotations map for a projectcontour.io/locality-lb-policy
+// of either "ZoneAware" or "LocalityWeighted". If the value is not present, or not
+// recognised, an empty string is returned.
+func localityLBPolicy(annotations map[string]string) string {
+	switch policy := annotations[annotationLocalityLBPolicy]; policy {
+	case "ZoneAware", "LocalityWeighted":
+		return policy
+	default:
+		return ""
+	}
+}
+
+// NotReadyEndpoints parses the annotations map for a projectcontour.io/not-ready-endpoints
+// of either "Draining" or "Unhealthy". If the value is not present, or not recognised,
+// an empty string is returned.
+func NotReadyEndpoints(annotations map[string]string) string {
+	switch status := annotations[annotationNotReadyEndpoints]; status {
+	case "Draining", "Unhealthy":
+		return status
+	default:
+		return ""
+	}
+}
+
+// EndpointWeight parses the annotations map of a Pod for a projectcontour.io/endpoint-weight
+// between 1 and MaxEndpointWeight. If the value is not present, malformed, or out of range,
+// zero is returned.
+func EndpointWeight(annotations map[string]string) uint32 {
+	w := parseUInt32(annotations[annotationEndpointWeight])
+	if w > MaxEndpointWeight {
+		return 0
+	}
+	return w
+}
+
 // httpAllowed returns true unless the kubernetes.io/ingress.allow-http annotation is
 // present and set to false.
-func httpAllowed(i *v1beta1.Ingress) bool {
+func httpAllowed(i *networking_v1.Ingress) bool {
 	return !(i.Annotations["kubernetes.io/ingress.allow-http"] == "false")
 }
 
 // tlsRequired returns true if the ingress.kubernetes.io/force-ssl-redirect annotation is
 // present and set to true.
-func tlsRequired(i *v1beta1.Ingress) bool {
+func tlsRequired(i *networking_v1.Ingress) bool {
 	return i.Annotations["ingress.kubernetes.io/force-ssl-redirect"] == "true"
 }
 
-func websocketRoutes(i *v1beta1.Ingress) map[string]bool {
+func websocketRoutes(i *networking_v1.Ingress) map[string]bool {
 	routes := make(map[string]bool)
 	for _, v := range strings.Split(i.Annotations[annotationWebsocketRoutes], ",") {
 		route := strings.TrimSpace(v)
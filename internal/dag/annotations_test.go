@@ -18,7 +18,7 @@ import (
 	"reflect"
 	"testing"
 
-	"k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -113,11 +113,11 @@ func TestParseUpstreamProtocols(t *testing.T) {
 
 func TestWebsocketRoutes(t *testing.T) {
 	tests := map[string]struct {
-		a    *v1beta1.Ingress
+		a    *networking_v1.Ingress
 		want map[string]bool
 	}{
 		"empty": {
-			a: &v1beta1.Ingress{
+			a: &networking_v1.Ingress{
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: map[string]string{annotationWebsocketRoutes: ""},
 				},
@@ -125,7 +125,7 @@ func TestWebsocketRoutes(t *testing.T) {
 			want: map[string]bool{},
 		},
 		"empty with spaces": {
-			a: &v1beta1.Ingress{
+			a: &networking_v1.Ingress{
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: map[string]string{annotationWebsocketRoutes: ", ,"},
 				},
@@ -133,7 +133,7 @@ func TestWebsocketRoutes(t *testing.T) {
 			want: map[string]bool{},
 		},
 		"single value": {
-			a: &v1beta1.Ingress{
+			a: &networking_v1.Ingress{
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: map[string]string{annotationWebsocketRoutes: "/ws1"},
 				},
@@ -143,7 +143,7 @@ func TestWebsocketRoutes(t *testing.T) {
 			},
 		},
 		"multiple values": {
-			a: &v1beta1.Ingress{
+			a: &networking_v1.Ingress{
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: map[string]string{annotationWebsocketRoutes: "/ws1,/ws2"},
 				},
@@ -154,7 +154,7 @@ func TestWebsocketRoutes(t *testing.T) {
 			},
 		},
 		"multiple values with spaces and invalid entries": {
-			a: &v1beta1.Ingress{
+			a: &networking_v1.Ingress{
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: map[string]string{annotationWebsocketRoutes: " /ws1, , /ws2 "},
 				},
@@ -178,27 +178,27 @@ func TestWebsocketRoutes(t *testing.T) {
 
 func TestHttpAllowed(t *testing.T) {
 	tests := map[string]struct {
-		i     *v1beta1.Ingress
+		i     *networking_v1.Ingress
 		valid bool
 	}{
 		"basic ingress": {
-			i: &v1beta1.Ingress{
+			i: &networking_v1.Ingress{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "simple",
 					Namespace: "default",
 				},
-				Spec: v1beta1.IngressSpec{
-					TLS: []v1beta1.IngressTLS{{
+				Spec: networking_v1.IngressSpec{
+					TLS: []networking_v1.IngressTLS{{
 						Hosts:      []string{"whatever.example.com"},
 						SecretName: "secret",
 					}},
-					Backend: backend("backend", intstr.FromInt(80)),
+					DefaultBackend: ingressBackend("backend", intstr.FromInt(80)),
 				},
 			},
 			valid: true,
 		},
 		"kubernetes.io/ingress.allow-http: \"false\"": {
-			i: &v1beta1.Ingress{
+			i: &networking_v1.Ingress{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "simple",
 					Namespace: "default",
@@ -206,12 +206,12 @@ func TestHttpAllowed(t *testing.T) {
 						"kubernetes.io/ingress.allow-http": "false",
 					},
 				},
-				Spec: v1beta1.IngressSpec{
-					TLS: []v1beta1.IngressTLS{{
+				Spec: networking_v1.IngressSpec{
+					TLS: []networking_v1.IngressTLS{{
 						Hosts:      []string{"whatever.example.com"},
 						SecretName: "secret",
 					}},
-					Backend: backend("backend", intstr.FromInt(80)),
+					DefaultBackend: ingressBackend("backend", intstr.FromInt(80)),
 				},
 			},
 			valid: false,
@@ -228,3 +228,110 @@ func TestHttpAllowed(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalityLBPolicy(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		want        string
+	}{
+		"nada": {
+			annotations: nil,
+			want:        "",
+		},
+		"zone aware": {
+			annotations: map[string]string{annotationLocalityLBPolicy: "ZoneAware"},
+			want:        "ZoneAware",
+		},
+		"locality weighted": {
+			annotations: map[string]string{annotationLocalityLBPolicy: "LocalityWeighted"},
+			want:        "LocalityWeighted",
+		},
+		"unknown": {
+			annotations: map[string]string{annotationLocalityLBPolicy: "zoneaware"},
+			want:        "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := localityLBPolicy(tc.annotations)
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNotReadyEndpoints(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		want        string
+	}{
+		"nada": {
+			annotations: nil,
+			want:        "",
+		},
+		"draining": {
+			annotations: map[string]string{annotationNotReadyEndpoints: "Draining"},
+			want:        "Draining",
+		},
+		"unhealthy": {
+			annotations: map[string]string{annotationNotReadyEndpoints: "Unhealthy"},
+			want:        "Unhealthy",
+		},
+		"unknown": {
+			annotations: map[string]string{annotationNotReadyEndpoints: "Remove"},
+			want:        "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NotReadyEndpoints(tc.annotations)
+			if got != tc.want {
+				t.Fatalf("expected: %q, got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEndpointWeight(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		want        uint32
+	}{
+		"nada": {
+			annotations: nil,
+			want:        0,
+		},
+		"weight": {
+			annotations: map[string]string{annotationEndpointWeight: "10"},
+			want:        10,
+		},
+		"max weight": {
+			annotations: map[string]string{annotationEndpointWeight: "100"},
+			want:        100,
+		},
+		"too heavy": {
+			annotations: map[string]string{annotationEndpointWeight: "101"},
+			want:        0,
+		},
+		"negative": {
+			annotations: map[string]string{annotationEndpointWeight: "-1"},
+			want:        0,
+		},
+		"malformed": {
+			annotations: map[string]string{annotationEndpointWeight: "heavy"},
+			want:        0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := EndpointWeight(tc.annotations)
+			if got != tc.want {
+				t.Fatalf("expected: %d, got: %d", tc.want, got)
+			}
+		})
+	}
+}
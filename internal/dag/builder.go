@@ -15,12 +15,15 @@ package dag
 
 import (
 	"fmt"
+	"net"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
@@ -39,6 +42,32 @@ type Builder struct {
 	// permitInsecure field in IngressRoute.
 	DisablePermitInsecure bool
 
+	// EnableExternalNameService permits routing to Services of type
+	// ExternalName. If false, such Services are treated as missing.
+	EnableExternalNameService bool
+
+	// ExternalNameDNSRefreshRate is how often Envoy resolves the
+	// names of ExternalName Services. If zero, Envoy's default applies.
+	ExternalNameDNSRefreshRate time.Duration
+
+	// ExternalNameDNSLookupFamily is the IP family Envoy resolves the
+	// names of ExternalName Services to, one of "auto", "v4", or "v6".
+	// If empty, defaults to "auto".
+	ExternalNameDNSLookupFamily string
+
+	// ExternalNameDeniedNames are names, in addition to the built in
+	// list, which ExternalName Services may not point at. A name also
+	// denies its subdomains.
+	ExternalNameDeniedNames []string
+
+	// HTTPPort is the port of Envoy's HTTP listener, which the HTTP
+	// listeners of Gateways must use. If zero, defaults to 8080.
+	HTTPPort int
+
+	// HTTPSPort is the port of Envoy's HTTPS listener, which the HTTPS
+	// and TLS listeners of Gateways must use. If zero, defaults to 8443.
+	HTTPSPort int
+
 	services map[servicemeta]*Service
 	secrets  map[Meta]*Secret
 
@@ -47,6 +76,13 @@ type Builder struct {
 
 	orphaned map[Meta]bool
 
+	// claimedHosts records the owner of each host configured so far,
+	// so the routes of the Gateway API may not add to the hosts of
+	// Ingresses, IngressRoutes, HTTPProxies, or other routes.
+	claimedHosts map[string]hostClaim
+
+	gatewayAPIStatuses map[Object]*GatewayAPIStatus
+
 	StatusWriter
 }
 
@@ -65,6 +101,8 @@ func (b *Builder) Build() *DAG {
 
 	b.computeHTTPProxies()
 
+	b.computeGatewayAPI()
+
 	return b.buildDAG()
 }
 
@@ -78,6 +116,7 @@ func (b *Builder) reset() {
 	b.securevirtualhosts = make(map[string]*SecureVirtualHost)
 
 	b.statuses = make(map[Meta]Status, len(b.statuses))
+	b.gatewayAPIStatuses = make(map[Object]*GatewayAPIStatus, len(b.gatewayAPIStatuses))
 }
 
 // lookupService returns a Service that matches the Meta and Port of the Kubernetes' Service.
@@ -103,14 +142,26 @@ func (b *Builder) lookupService(m Meta, port intstr.IntOrString) *Service {
 	if !ok {
 		return nil
 	}
+	if !b.externalNamePermitted(svc) {
+		return nil
+	}
+	// A port number may be exposed for several protocols, for example
+	// 53/UDP and 53/TCP, so prefer a TCP port, which Envoy can proxy to.
+	var match *v1.ServicePort
 	for i := range svc.Spec.Ports {
 		p := &svc.Spec.Ports[i]
-		if int(p.Port) == port.IntValue() {
-			return b.addService(svc, p)
+		if int(p.Port) != port.IntValue() && port.String() != p.Name {
+			continue
 		}
-		if port.String() == p.Name {
+		if isTCPPort(p) {
 			return b.addService(svc, p)
 		}
+		if match == nil {
+			match = p
+		}
+	}
+	if match != nil {
+		return b.addService(svc, match)
 	}
 	return nil
 }
@@ -127,11 +178,91 @@ func (b *Builder) addService(svc *v1.Service, port *v1.ServicePort) *Service {
 		MaxRequests:        parseUInt32(svc.Annotations[annotationMaxRequests]),
 		MaxRetries:         parseUInt32(svc.Annotations[annotationMaxRetries]),
 		ExternalName:       externalName(svc),
+		LocalityLBPolicy:   localityLBPolicy(svc.Annotations),
+	}
+	if s.ExternalName != "" {
+		s.DNSRefreshRate = b.ExternalNameDNSRefreshRate
+		s.DNSLookupFamily = b.ExternalNameDNSLookupFamily
 	}
 	b.services[s.toMeta()] = s
 	return s
 }
 
+// externalNamePermitted returns true if svc is not of type ExternalName,
+// or if ExternalName Services are enabled and its external name does not
+// point Envoy at itself or another link local address.
+func (b *Builder) externalNamePermitted(svc *v1.Service) bool {
+	if svc.Spec.Type != v1.ServiceTypeExternalName {
+		return true
+	}
+	return b.EnableExternalNameService && !deniedExternalName(svc.Spec.ExternalName, b.ExternalNameDeniedNames)
+}
+
+// deniedExternalNames are the well known names of cloud providers'
+// instance metadata services, and the metadata addresses which are
+// not link local.
+var deniedExternalNames = []string{
+	"metadata",
+	"metadata.google.internal",
+	"instance-data",
+	"instance-data.ec2.internal",
+	"100.100.100.200", // Alibaba Cloud
+	"fd00:ec2::254",   // AWS over IPv6
+}
+
+// deniedExternalName returns true if name is localhost, a loopback,
+// link local, or unspecified IP address, or one of the well known
+// metadata service names, which would let a Service route to Envoy's
+// admin interface or the node's metadata service. Names in denied,
+// and their subdomains, are also denied.
+//
+// The check is made on the name alone and is best effort: Envoy
+// resolves the name itself, so a name whose DNS records point at
+// a loopback or link local address is not denied.
+func deniedExternalName(name string, denied []string) bool {
+	name = normalizeExternalName(name)
+	switch {
+	case name == "localhost", strings.HasSuffix(name, ".localhost"), name == "localhost.localdomain":
+		return true
+	}
+	ip := net.ParseIP(name)
+	if ip != nil && (ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()) {
+		return true
+	}
+	for _, list := range [][]string{deniedExternalNames, denied} {
+		for _, d := range list {
+			d = normalizeExternalName(d)
+			if d == "" {
+				continue
+			}
+			if name == d || strings.HasSuffix(name, "."+d) {
+				return true
+			}
+			if dip := net.ParseIP(d); dip != nil && ip != nil && dip.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeExternalName lowercases name and strips any trailing
+// dot, or brackets around an IPv6 address.
+func normalizeExternalName(name string) string {
+	return strings.Trim(strings.TrimSuffix(strings.ToLower(name), "."), "[]")
+}
+
+// isTCPPort returns true if port carries TCP traffic. Envoy can only
+// proxy to TCP endpoints, so UDP and SCTP ports will never be used.
+func isTCPPort(port *v1.ServicePort) bool {
+	return port.Protocol == "" || port.Protocol == v1.ProtocolTCP
+}
+
+// isTLSProtocol returns true if protocol connects to the upstream with TLS.
+func isTLSProtocol(protocol string) bool {
+	return protocol == "tls" || protocol == "h2" || protocol == "auto"
+}
+
 func upstreamProtocol(svc *v1.Service, port *v1.ServicePort) string {
 	up := parseUpstreamProtocols(svc.Annotations, annotationUpstreamProtocol, "h2", "h2c", "tls")
 	protocol := up[port.Name]
@@ -261,11 +392,11 @@ func (b *Builder) validHTTPProxies() []*projcontour.HTTPProxy {
 // computeSecureVirtualhosts populates tls parameters of
 // secure virtual hosts.
 func (b *Builder) computeSecureVirtualhosts() {
-	for _, ing := range b.Source.ingresses {
+	for _, ing := range b.Source.validIngresses() {
 		for _, tls := range ing.Spec.TLS {
 			m := splitSecret(tls.SecretName, ing.Namespace)
 			sec := b.lookupSecret(m, validSecret)
-			if sec != nil && b.delegationPermitted(m, ing.Namespace) {
+			if sec != nil && b.Source.delegationPermitted(m, ing.Namespace) {
 				for _, host := range tls.Hosts {
 					svhost := b.lookupSecureVirtualHost(host)
 					svhost.Secret = sec
@@ -277,32 +408,20 @@ func (b *Builder) computeSecureVirtualhosts() {
 	}
 }
 
-func (b *Builder) delegationPermitted(secret Meta, to string) bool {
-	contains := func(haystack []string, needle string) bool {
-		if len(haystack) == 1 && haystack[0] == "*" {
-			return true
-		}
-		for _, h := range haystack {
-			if h == needle {
-				return true
-			}
-		}
-		return false
-	}
-
-	if secret.namespace == to {
-		// secret is in the same namespace as target
+// serviceDelegationPermitted returns true if the service may be referenced
+// from the namespace to, either because it is in that namespace, or because
+// a ServiceDelegation in its namespace delegates it to that namespace.
+func (b *Builder) serviceDelegationPermitted(service Meta, to string) bool {
+	if service.namespace == to {
 		return true
 	}
-	for _, d := range b.Source.irdelegations {
-		if d.Namespace != secret.namespace {
+	for _, d := range b.Source.servicedelegations {
+		if d.Namespace != service.namespace {
 			continue
 		}
 		for _, d := range d.Spec.Delegations {
-			if contains(d.TargetNamespaces, to) {
-				if secret.name == d.SecretName {
-					return true
-				}
+			if service.name == d.ServiceName && b.Source.delegatesTo(d.TargetNamespaces, d.TargetNamespaceSelector, to) {
+				return true
 			}
 		}
 	}
@@ -311,7 +430,7 @@ func (b *Builder) delegationPermitted(secret Meta, to string) bool {
 
 func (b *Builder) computeIngresses() {
 	// deconstruct each ingress into routes and virtualhost entries
-	for _, ing := range b.Source.ingresses {
+	for _, ing := range b.Source.validIngresses() {
 
 		// rewrite the default ingress to a stock ingress rule.
 		rules := rulesFromSpec(ing.Spec)
@@ -327,27 +446,30 @@ func (b *Builder) computeIngresses() {
 				host = "*"
 			}
 			for _, httppath := range httppaths(rule) {
-				path := stringOrDefault(httppath.Path, "/")
-				be := httppath.Backend
-				m := Meta{name: be.ServiceName, namespace: ing.Namespace}
-				s := b.lookupService(m, be.ServicePort)
+				be := httppath.Backend.Service
+				if be == nil {
+					// resource backends are not supported.
+					continue
+				}
+				m := Meta{name: be.Name, namespace: ing.Namespace}
+				s := b.lookupService(m, servicePort(be.Port))
 				if s == nil {
 					continue
 				}
 
-				r := route(ing, path, s)
-
-				// should we create port 80 routes for this ingress
-				if tlsRequired(ing) || httpAllowed(ing) {
-					b.lookupVirtualHost(host).addRoute(r)
-				}
+				for _, r := range ingressRoutes(ing, httppath, s) {
+					// should we create port 80 routes for this ingress
+					if tlsRequired(ing) || httpAllowed(ing) {
+						b.lookupVirtualHost(host).addRoute(r)
+					}
 
-				// computeSecureVirtualhosts will have populated b.securevirtualhosts
-				// with the names of tls enabled ingress objects. If host exists then
-				// it is correctly configured for TLS.
-				svh, ok := b.securevirtualhosts[host]
-				if ok && host != "*" {
-					svh.addRoute(r)
+					// computeSecureVirtualhosts will have populated b.securevirtualhosts
+					// with the names of tls enabled ingress objects. If host exists then
+					// it is correctly configured for TLS.
+					svh, ok := b.securevirtualhosts[host]
+					if ok && host != "*" {
+						svh.addRoute(r)
+					}
 				}
 			}
 		}
@@ -393,7 +515,7 @@ func (b *Builder) computeIngressRoute(ir *ingressroutev1.IngressRoute) {
 		m := splitSecret(tls.SecretName, ir.Namespace)
 		sec := b.lookupSecret(m, validSecret)
 		if sec != nil {
-			if !b.delegationPermitted(m, ir.Namespace) {
+			if !b.Source.delegationPermitted(m, ir.Namespace) {
 				sw.SetInvalid(fmt.Sprintf("%s: certificate delegation not permitted", tls.SecretName))
 				return
 			}
@@ -457,10 +579,11 @@ func (b *Builder) computeHTTPProxy(proxy *projcontour.HTTPProxy) {
 		// attach secrets to TLS enabled vhosts
 		m := splitSecret(tls.SecretName, proxy.Namespace)
 		sec := b.lookupSecret(m, validSecret)
-		if sec != nil && b.delegationPermitted(m, proxy.Namespace) {
+		if sec != nil && b.Source.delegationPermitted(m, proxy.Namespace) {
 			svhost := b.lookupSecureVirtualHost(host)
 			svhost.Secret = sec
 			svhost.MinProtoVersion = MinProtoVersion(proxy.Spec.VirtualHost.TLS.MinimumProtocolVersion)
+			svhost.Fleet = proxy.Spec.Fleet
 			enforceTLS = true
 		}
 		// passthrough is true if tls.secretName is not present, and
@@ -474,6 +597,12 @@ func (b *Builder) computeHTTPProxy(proxy *projcontour.HTTPProxy) {
 		}
 	}
 
+	// scope the vhost to a single fleet of Envoys, if requested.
+	// The secure vhost, if any, was scoped above.
+	if fleet := proxy.Spec.Fleet; fleet != "" {
+		b.lookupVirtualHost(host).Fleet = fleet
+	}
+
 	// Set default status
 	sw.SetValid()
 
@@ -512,6 +641,14 @@ func (b *Builder) processIncludes(sw *ObjectStatusWriter, proxy *projcontour.HTT
 				return
 			}
 
+			if delegatedProxy.Spec.Fleet != "" {
+				sw, commit := sw.WithObject(delegatedProxy)
+				sw.SetInvalid("Spec.Fleet may only be set on a root HTTPProxy")
+				commit()
+				delete(b.orphaned, Meta{name: delegatedProxy.Name, namespace: delegatedProxy.Namespace})
+				continue
+			}
+
 			var path []string
 			for _, vproxy := range visited {
 				path = append(path, fmt.Sprintf("%s/%s", vproxy.Namespace, vproxy.Name))
@@ -578,10 +715,44 @@ func (b *Builder) buildDAG() *DAG {
 			commit()
 		}
 	}
+	b.setClientCertificate(&dag)
+
 	dag.statuses = b.statuses
+	dag.gatewayAPIStatuses = b.gatewayAPIStatuses
 	return &dag
 }
 
+// setClientCertificate sets the configured client certificate, if any,
+// on each cluster connecting to its upstream with TLS which does not
+// specify its own.
+func (b *Builder) setClientCertificate(dag *DAG) {
+	cc := b.Source.ClientCertificate
+	if cc == nil {
+		return
+	}
+	sec := b.lookupSecret(Meta{name: cc.Name, namespace: cc.Namespace}, validSecret)
+	if sec == nil {
+		b.Source.WithField("secret", cc.String()).Error("client certificate secret not found or misconfigured")
+		return
+	}
+
+	var visit func(Vertex)
+	visit = func(v Vertex) {
+		if c, ok := v.(*Cluster); ok {
+			protocol := c.Upstream.Protocol
+			if c.Protocol != "" {
+				protocol = c.Protocol
+			}
+			if c.ClientCertificate == nil && isTLSProtocol(protocol) {
+				c.ClientCertificate = sec
+			}
+			return
+		}
+		v.Visit(visit)
+	}
+	dag.Visit(visit)
+}
+
 // buildHTTPListener builds a *dag.Listener for the vhosts bound to port 80.
 // The list of virtual hosts will attached to the listener will be sorted
 // by hostname.
@@ -699,11 +870,16 @@ func (b *Builder) processIngressRoutes(sw *ObjectStatusWriter, ir *ingressroutev
 						sw.SetInvalid(err.Error())
 					}
 				}
+				hc, err := healthCheckPolicy(service.HealthCheck, s.Protocol)
+				if err != nil {
+					sw.SetInvalid(fmt.Sprintf("route %q: service %q: %s", route.Match, service.Name, err))
+					return
+				}
 				r.Clusters = append(r.Clusters, &Cluster{
 					Upstream:             s,
 					LoadBalancerStrategy: service.Strategy,
 					Weight:               service.Weight,
-					HealthCheckPolicy:    healthCheckPolicy(service.HealthCheck),
+					HealthCheckPolicy:    hc,
 					UpstreamValidation:   uv,
 				})
 			}
@@ -786,29 +962,79 @@ func (b *Builder) processRoutes(sw *ObjectStatusWriter, proxy *projcontour.HTTPP
 					sw.SetInvalid(fmt.Sprintf("route %q: service %q: port must be in the range 1-65535", routePath, service.Name))
 					return
 				}
-				m := Meta{name: service.Name, namespace: proxy.Namespace}
+				m := Meta{name: service.Name, namespace: serviceNamespace(service, proxy.Namespace)}
+				if !b.serviceDelegationPermitted(m, proxy.Namespace) {
+					sw.SetInvalid(fmt.Sprintf("route %q: service \"%s/%s\": not delegated to namespace %q", routePath, m.namespace, m.name, proxy.Namespace))
+					return
+				}
 				s := b.lookupService(m, intstr.FromInt(service.Port))
 
 				if s == nil {
 					sw.SetInvalid(fmt.Sprintf("Service [%s:%d] is invalid or missing", service.Name, service.Port))
 					return
 				}
+				if !isTCPPort(s.ServicePort) {
+					sw.AddWarning(fmt.Sprintf("route %q: service %q: port %d has no TCP endpoints", routePath, service.Name, service.Port))
+				}
+
+				protocol := s.Protocol
+				switch service.Protocol {
+				case "":
+					// use the protocol of the service's annotations
+				case "h2", "h2c", "tls", "auto":
+					protocol = service.Protocol
+				default:
+					sw.SetInvalid(fmt.Sprintf("route %q: service %q: unsupported protocol %q", routePath, service.Name, service.Protocol))
+					return
+				}
 
 				var uv *UpstreamValidation
 				var err error
-				if s.Protocol == "tls" {
+				if isTLSProtocol(protocol) {
 					// we can only validate TLS connections to services that talk TLS
 					uv, err = b.lookupUpstreamValidation(routePath, service.Name, service.UpstreamValidation, proxy.Namespace)
 					if err != nil {
 						sw.SetInvalid(err.Error())
 					}
 				}
+				var cc *Secret
+				if service.ClientCertificate != "" {
+					if !isTLSProtocol(protocol) {
+						sw.SetInvalid(fmt.Sprintf("route %q: service %q: clientCertificate requires the upstream protocol \"tls\", \"h2\", or \"auto\"", routePath, service.Name))
+						return
+					}
+					cc = b.lookupSecret(Meta{name: service.ClientCertificate, namespace: proxy.Namespace}, validSecret)
+					if cc == nil {
+						sw.SetInvalid(fmt.Sprintf("route %q: service %q: clientCertificate secret %q not found or misconfigured", routePath, service.Name, service.ClientCertificate))
+						return
+					}
+				}
+				hc, err := healthCheckPolicy(service.HealthCheck, protocol)
+				if err != nil {
+					sw.SetInvalid(fmt.Sprintf("route %q: service %q: %s", routePath, service.Name, err))
+					return
+				}
+				po, err := protocolOptions(service.ProtocolOptions, protocol)
+				if err != nil {
+					sw.SetInvalid(fmt.Sprintf("route %q: service %q: %s", routePath, service.Name, err))
+					return
+				}
+				od, err := outlierDetectionPolicy(service.OutlierDetection)
+				if err != nil {
+					sw.SetInvalid(fmt.Sprintf("route %q: service %q: %s", routePath, service.Name, err))
+					return
+				}
 				r.Clusters = append(r.Clusters, &Cluster{
-					Upstream:             s,
-					LoadBalancerStrategy: service.Strategy,
-					Weight:               service.Weight,
-					HealthCheckPolicy:    healthCheckPolicy(service.HealthCheck),
-					UpstreamValidation:   uv,
+					Upstream:               s,
+					LoadBalancerStrategy:   service.Strategy,
+					Weight:                 service.Weight,
+					HealthCheckPolicy:      hc,
+					OutlierDetectionPolicy: od,
+					CircuitBreakerPolicy:   circuitBreakerPolicy(service.CircuitBreakers),
+					UpstreamValidation:     uv,
+					Protocol:               service.Protocol,
+					ProtocolOptions:        po,
+					ClientCertificate:      cc,
 				})
 			}
 
@@ -860,9 +1086,18 @@ func (b *Builder) processTCPProxy(sw *ObjectStatusWriter, ir *ingressroutev1.Ing
 				sw.SetInvalid(fmt.Sprintf("tcpproxy: service %s/%s/%d: not found", ir.Namespace, service.Name, service.Port))
 				return
 			}
+			if !isTCPPort(s.ServicePort) {
+				sw.AddWarning(fmt.Sprintf("tcpproxy: service %s/%s/%d: port has no TCP endpoints", ir.Namespace, service.Name, service.Port))
+			}
+			hc, err := healthCheckPolicy(service.HealthCheck, s.Protocol)
+			if err != nil {
+				sw.SetInvalid(fmt.Sprintf("tcpproxy: service %s/%s/%d: %s", ir.Namespace, service.Name, service.Port, err))
+				return
+			}
 			proxy.Clusters = append(proxy.Clusters, &Cluster{
 				Upstream:             s,
 				LoadBalancerStrategy: service.Strategy,
+				HealthCheckPolicy:    hc,
 			})
 		}
 		b.lookupSecureVirtualHost(host).TCPProxy = &proxy
@@ -931,8 +1166,57 @@ func externalName(svc *v1.Service) string {
 	return svc.Spec.ExternalName
 }
 
+// ingressRoutes returns the routes for the supplied Ingress path to service,
+// matching requests according to the type of the path.
+func ingressRoutes(ingress *networking_v1.Ingress, httppath networking_v1.HTTPIngressPath, service *Service) []Vertex {
+	path := stringOrDefault(httppath.Path, "/")
+	r := route(ingress, path, service)
+
+	pathType := networking_v1.PathTypeImplementationSpecific
+	if httppath.PathType != nil {
+		pathType = *httppath.PathType
+	}
+	switch pathType {
+	case networking_v1.PathTypeExact:
+		return []Vertex{&RegexRoute{
+			Regex: regexp.QuoteMeta(path),
+			Route: r,
+		}}
+	case networking_v1.PathTypePrefix:
+		// Prefixes match whole path elements, so /foo matches
+		// /foo and /foo/bar, but not /foobar. A trailing slash
+		// is ignored.
+		prefix := strings.TrimRight(path, "/")
+		if prefix == "" {
+			return []Vertex{&PrefixRoute{
+				Prefix: "/",
+				Route:  r,
+			}}
+		}
+		return []Vertex{&RegexRoute{
+			Regex: regexp.QuoteMeta(prefix),
+			Route: r,
+		}, &PrefixRoute{
+			Prefix: prefix + "/",
+			Route:  r,
+		}}
+	default:
+		if strings.ContainsAny(path, "^+*[]%") {
+			// path smells like a regex
+			return []Vertex{&RegexRoute{
+				Regex: path,
+				Route: r,
+			}}
+		}
+		return []Vertex{&PrefixRoute{
+			Prefix: path,
+			Route:  r,
+		}}
+	}
+}
+
 // route returns a dag.Route for the supplied Ingress.
-func route(ingress *v1beta1.Ingress, path string, service *Service) Vertex {
+func route(ingress *networking_v1.Ingress, path string, service *Service) Route {
 	var retry *RetryPolicy
 	if retryOn, ok := ingress.Annotations[annotationRetryOn]; ok && len(retryOn) > 0 {
 		// if there is a non empty retry-on annotation, build a RetryPolicy manually.
@@ -958,7 +1242,7 @@ func route(ingress *v1beta1.Ingress, path string, service *Service) Vertex {
 	}
 
 	wr := websocketRoutes(ingress)
-	r := Route{
+	return Route{
 		HTTPSUpgrade:  tlsRequired(ingress),
 		Websocket:     wr[path],
 		TimeoutPolicy: timeout,
@@ -967,20 +1251,6 @@ func route(ingress *v1beta1.Ingress, path string, service *Service) Vertex {
 			Upstream: service,
 		}},
 	}
-
-	if strings.ContainsAny(path, "^+*[]%") {
-		// path smells like a regex
-		return &RegexRoute{
-			Regex: path,
-			Route: r,
-		}
-	}
-
-	return &PrefixRoute{
-		Prefix: path,
-		Route:  r,
-	}
-
 }
 
 // isBlank indicates if a string contains nothing but blank characters.
@@ -1002,6 +1272,12 @@ func MinProtoVersion(version string) envoy_api_v2_auth.TlsParameters_TlsProtocol
 	}
 }
 
+// serviceNamespace returns the namespace of the service,
+// or defns if the service does not specify one.
+func serviceNamespace(service projcontour.Service, defns string) string {
+	return stringOrDefault(service.Namespace, defns)
+}
+
 // splitSecret splits a secretName into its namespace and name components.
 // If there is no namespace prefix, the default namespace is returned.
 func splitSecret(secret, defns string) Meta {
@@ -1030,9 +1306,9 @@ func stringOrDefault(s, def string) string {
 
 // rulesFromSpec merges the IngressSpec's Rules with a synthetic
 // rule representing the default backend.
-func rulesFromSpec(spec v1beta1.IngressSpec) []v1beta1.IngressRule {
+func rulesFromSpec(spec networking_v1.IngressSpec) []networking_v1.IngressRule {
 	rules := spec.Rules
-	if backend := spec.Backend; backend != nil {
+	if backend := spec.DefaultBackend; backend != nil {
 		rule := defaultBackendRule(backend)
 		rules = append(rules, rule)
 	}
@@ -1040,15 +1316,12 @@ func rulesFromSpec(spec v1beta1.IngressSpec) []v1beta1.IngressRule {
 }
 
 // defaultBackendRule returns an IngressRule that represents the IngressBackend.
-func defaultBackendRule(be *v1beta1.IngressBackend) v1beta1.IngressRule {
-	return v1beta1.IngressRule{
-		IngressRuleValue: v1beta1.IngressRuleValue{
-			HTTP: &v1beta1.HTTPIngressRuleValue{
-				Paths: []v1beta1.HTTPIngressPath{{
-					Backend: v1beta1.IngressBackend{
-						ServiceName: be.ServiceName,
-						ServicePort: be.ServicePort,
-					},
+func defaultBackendRule(be *networking_v1.IngressBackend) networking_v1.IngressRule {
+	return networking_v1.IngressRule{
+		IngressRuleValue: networking_v1.IngressRuleValue{
+			HTTP: &networking_v1.HTTPIngressRuleValue{
+				Paths: []networking_v1.HTTPIngressPath{{
+					Backend: *be,
 				}},
 			},
 		},
@@ -1072,7 +1345,7 @@ func routeEnforceTLS(enforceTLS, permitInsecure bool) bool {
 // httppaths returns a slice of HTTPIngressPath values for a given IngressRule.
 // In the case that the IngressRule contains no valid HTTPIngressPaths, a
 // nil slice is returned.
-func httppaths(rule v1beta1.IngressRule) []v1beta1.HTTPIngressPath {
+func httppaths(rule networking_v1.IngressRule) []networking_v1.HTTPIngressPath {
 	if rule.IngressRuleValue.HTTP == nil {
 		// rule.IngressRuleValue.HTTP value is optional.
 		return nil
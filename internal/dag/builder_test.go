@@ -24,7 +24,9 @@ import (
 	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -634,6 +636,37 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// i17 is a networking.k8s.io/v1 ingress with typed paths
+	exact := networking_v1.PathTypeExact
+	prefix := networking_v1.PathTypePrefix
+	i17 := &networking_v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pathtypes",
+			Namespace: "default",
+		},
+		Spec: networking_v1.IngressSpec{
+			Rules: []networking_v1.IngressRule{{
+				IngressRuleValue: networking_v1.IngressRuleValue{
+					HTTP: &networking_v1.HTTPIngressRuleValue{
+						Paths: []networking_v1.HTTPIngressPath{{
+							Path:     "/exact",
+							PathType: &exact,
+							Backend:  *ingressBackend("kuard", intstr.FromString("http")),
+						}, {
+							Path:     "/prefix/",
+							PathType: &prefix,
+							Backend:  *ingressBackend("kuard", intstr.FromInt(8080)),
+						}, {
+							Path:     "/",
+							PathType: &prefix,
+							Backend:  *ingressBackend("kuard", intstr.FromInt(8080)),
+						}},
+					},
+				},
+			}},
+		},
+	}
+
 	// s3a and b have http/2 protocol annotations
 	s3a := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -858,6 +891,32 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// ir1f tcp forwards traffic to default/kuard:8080 by TLS pass-throughing
+	// it, health checking the upstream with a TCP health check.
+	ir1f := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard-tcp",
+			Namespace: "default",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "kuard.example.com",
+				TLS: &projcontour.TLS{
+					Passthrough: true,
+				},
+			},
+			TCPProxy: &ingressroutev1.TCPProxy{
+				Services: []ingressroutev1.Service{{
+					Name: "kuard",
+					Port: 8080,
+					HealthCheck: &projcontour.HealthCheck{
+						Type: "tcp",
+					},
+				}},
+			},
+		},
+	}
+
 	// ir2 is like ir1 but refers to two backend services
 	ir2 := &ingressroutev1.IngressRoute{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1655,6 +1714,86 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	proxy1f := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/",
+				},
+				Services: []projcontour.Service{{
+					Name: "kuard",
+					Port: 8080,
+					OutlierDetection: &projcontour.OutlierDetection{
+						Consecutive5xxErrors: 3,
+						IntervalSeconds:      5,
+					},
+					CircuitBreakers: &projcontour.CircuitBreakers{
+						MaxConnections: 100,
+					},
+				}},
+			}},
+		},
+	}
+
+	// proxy1g overrides the upstream protocol of kuard
+	proxy1g := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/",
+				},
+				Services: []projcontour.Service{{
+					Name:     "kuard",
+					Port:     8080,
+					Protocol: "h2c",
+					ProtocolOptions: &projcontour.ProtocolOptions{
+						HTTP2: &projcontour.HTTP2ProtocolOptions{
+							MaxConcurrentStreams: 100,
+						},
+					},
+				}},
+			}},
+		},
+	}
+
+	// proxy1h presents a client certificate to kuard
+	proxy1h := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/",
+				},
+				Services: []projcontour.Service{{
+					Name:              "kuard",
+					Port:              8080,
+					Protocol:          "tls",
+					ClientCertificate: sec1.Name,
+				}},
+			}},
+		},
+	}
+
 	// proxy6 has TLS and does not specify min tls version
 	proxy6 := &projcontour.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1699,6 +1838,30 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	// proxy17h2 validates an h2 upstream
+	proxy17h2 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name:     "kuard",
+					Port:     8080,
+					Protocol: "h2",
+					UpstreamValidation: &projcontour.UpstreamValidation{
+						CACertificate: cert1.Name,
+						SubjectName:   "example.com",
+					},
+				}},
+			}},
+		},
+	}
+
 	// proxy10 has a websocket route
 	proxy10 := &projcontour.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1895,6 +2058,7 @@ func TestDAGInsert(t *testing.T) {
 	tests := map[string]struct {
 		objs                  []interface{}
 		disablePermitInsecure bool
+		clientCertificate     *types.NamespacedName
 		want                  []Vertex
 	}{
 		"insert ingress w/ default backend w/o matching service": {
@@ -2385,6 +2549,7 @@ func TestDAGInsert(t *testing.T) {
 							routeCluster("/", &Cluster{
 								Upstream: service(s1),
 								HealthCheckPolicy: &HealthCheckPolicy{
+									Type: "http",
 									Path: "/healthz",
 								},
 							}),
@@ -2455,6 +2620,31 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert ingressroute with tcp forward w/ tcp health check": {
+			objs: []interface{}{
+				ir1f, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name: "kuard.example.com",
+							},
+							TCPProxy: &TCPProxy{
+								Clusters: []*Cluster{{
+									Upstream: service(s1),
+									HealthCheckPolicy: &HealthCheckPolicy{
+										Type: "tcp",
+									},
+								}},
+							},
+						},
+					),
+				},
+			),
+		},
 		"insert root ingress route and delegate ingress route for a tcp proxy": {
 			objs: []interface{}{
 				ir1d, s6, ir1c,
@@ -3088,6 +3278,35 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert networking.k8s.io/v1 ingress with path types": {
+			objs: []interface{}{
+				i17,
+				s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("*",
+							&RegexRoute{
+								Regex: "/exact",
+								Route: Route{
+									Clusters: clustermap(s1),
+								},
+							},
+							&RegexRoute{
+								Regex: "/prefix",
+								Route: Route{
+									Clusters: clustermap(s1),
+								},
+							},
+							prefixroute("/prefix/", service(s1)),
+							prefixroute("/", service(s1)),
+						),
+					),
+				},
+			),
+		},
 		// issue 1234
 		"insert ingress with wildcard hostnames": {
 			objs: []interface{}{
@@ -3589,6 +3808,7 @@ func TestDAGInsert(t *testing.T) {
 							routeCluster("/", &Cluster{
 								Upstream: service(s1),
 								HealthCheckPolicy: &HealthCheckPolicy{
+									Type: "http",
 									Path: "/healthz",
 								},
 							}),
@@ -3597,6 +3817,53 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert httpproxy w/ outlier detection and circuit breakers": {
+			objs: []interface{}{
+				proxy1f, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/", &Cluster{
+								Upstream: service(s1),
+								OutlierDetectionPolicy: &OutlierDetectionPolicy{
+									Consecutive5xxErrors: 3,
+									Interval:             5 * time.Second,
+								},
+								CircuitBreakerPolicy: &CircuitBreakerPolicy{
+									MaxConnections: 100,
+								},
+							}),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy w/ upstream protocol": {
+			objs: []interface{}{
+				proxy1g, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/", &Cluster{
+								Upstream: service(s1),
+								Protocol: "h2c",
+								ProtocolOptions: &ProtocolOptions{
+									HTTP2: &HTTP2ProtocolOptions{
+										MaxConcurrentStreams: 100,
+									},
+								},
+							}),
+						),
+					),
+				},
+			),
+		},
 		"insert httpproxy with websocket route": {
 			objs: []interface{}{
 				proxy11, s1,
@@ -3703,6 +3970,85 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert httpproxy expecting verification of an h2 upstream": {
+			objs: []interface{}{
+				cert1, proxy17h2, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/",
+								&Cluster{
+									Upstream: service(s1),
+									Protocol: "h2",
+									UpstreamValidation: &UpstreamValidation{
+										CACertificate: secret(cert1),
+										SubjectName:   "example.com",
+									},
+								},
+							),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy expecting verification w/ default client certificate": {
+			objs: []interface{}{
+				cert1, sec1, proxy17, s1a,
+			},
+			clientCertificate: &types.NamespacedName{Namespace: sec1.Namespace, Name: sec1.Name},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/",
+								&Cluster{
+									Upstream: &Service{
+										Name:        s1a.Name,
+										Namespace:   s1a.Namespace,
+										ServicePort: &s1a.Spec.Ports[0],
+										Protocol:    "tls",
+									},
+									UpstreamValidation: &UpstreamValidation{
+										CACertificate: secret(cert1),
+										SubjectName:   "example.com",
+									},
+									ClientCertificate: secret(sec1),
+								},
+							),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy w/ client certificate": {
+			objs: []interface{}{
+				sec1, proxy1h, s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com",
+							routeCluster("/", &Cluster{
+								Upstream:          service(s1),
+								Protocol:          "tls",
+								ClientCertificate: secret(sec1),
+							}),
+						),
+					),
+				},
+			),
+		},
+		"insert httpproxy w/o client certificate secret": {
+			objs: []interface{}{
+				proxy1h, s1,
+			},
+			want: listeners(),
+		},
 		"insert httpproxy with pathPrefix include": {
 			objs: []interface{}{
 				proxy100, proxy100a, s1, s4,
@@ -3824,7 +4170,8 @@ func TestDAGInsert(t *testing.T) {
 			builder := Builder{
 				DisablePermitInsecure: tc.disablePermitInsecure,
 				Source: KubernetesCache{
-					FieldLogger: testLogger(t),
+					ClientCertificate: tc.clientCertificate,
+					FieldLogger:       testLogger(t),
 				},
 			}
 			for _, o := range tc.objs {
@@ -3901,8 +4248,31 @@ func TestBuilderLookupService(t *testing.T) {
 			}},
 		},
 	}
+	// s2 exposes port 53 for UDP before TCP, as DNS services do.
+	s2 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "coredns",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "dns",
+				Protocol: "UDP",
+				Port:     53,
+			}, {
+				Name:     "dns-tcp",
+				Protocol: "TCP",
+				Port:     53,
+			}, {
+				Name:     "metrics",
+				Protocol: "UDP",
+				Port:     9153,
+			}},
+		},
+	}
 	services := map[Meta]*v1.Service{
 		{name: "service1", namespace: "default"}: s1,
+		{name: "coredns", namespace: "default"}:  s2,
 	}
 
 	tests := map[string]struct {
@@ -3930,6 +4300,29 @@ func TestBuilderLookupService(t *testing.T) {
 			port: intstr.FromString("8080"),
 			want: service(s1),
 		},
+		"lookup service by port number prefers tcp": {
+			Meta: Meta{name: "coredns", namespace: "default"},
+			port: intstr.FromInt(53),
+			want: &Service{
+				Name:        "coredns",
+				Namespace:   "default",
+				ServicePort: &s2.Spec.Ports[1],
+			},
+		},
+		"lookup service by port name of udp port": {
+			Meta: Meta{name: "coredns", namespace: "default"},
+			port: intstr.FromString("dns"),
+			want: service(s2),
+		},
+		"lookup service by port number without tcp port": {
+			Meta: Meta{name: "coredns", namespace: "default"},
+			port: intstr.FromInt(9153),
+			want: &Service{
+				Name:        "coredns",
+				Namespace:   "default",
+				ServicePort: &s2.Spec.Ports[2],
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -3949,6 +4342,233 @@ func TestBuilderLookupService(t *testing.T) {
 	}
 }
 
+func TestBuilderLookupExternalNameService(t *testing.T) {
+	externalName := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kuard",
+				Namespace: "default",
+			},
+			Spec: v1.ServiceSpec{
+				Type:         v1.ServiceTypeExternalName,
+				ExternalName: name,
+				Ports: []v1.ServicePort{{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     80,
+				}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		svc    *v1.Service
+		enable bool
+		want   *Service
+	}{
+		"not enabled": {
+			svc:    externalName("foo.io"),
+			enable: false,
+			want:   nil,
+		},
+		"enabled": {
+			svc:    externalName("foo.io"),
+			enable: true,
+			want: &Service{
+				Name:            "kuard",
+				Namespace:       "default",
+				ServicePort:     &externalName("foo.io").Spec.Ports[0],
+				ExternalName:    "foo.io",
+				DNSRefreshRate:  time.Minute,
+				DNSLookupFamily: "v6",
+			},
+		},
+		"localhost": {
+			svc:    externalName("localhost"),
+			enable: true,
+			want:   nil,
+		},
+		"loopback address": {
+			svc:    externalName("127.0.0.2"),
+			enable: true,
+			want:   nil,
+		},
+		"metadata service": {
+			svc:    externalName("metadata.google.internal"),
+			enable: true,
+			want:   nil,
+		},
+		"configured denied name": {
+			svc:    externalName("db.internal.example.com"),
+			enable: true,
+			want:   nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := Builder{
+				Source: KubernetesCache{
+					services: map[Meta]*v1.Service{
+						{name: "kuard", namespace: "default"}: tc.svc,
+					},
+					FieldLogger: testLogger(t),
+				},
+				EnableExternalNameService:   tc.enable,
+				ExternalNameDNSRefreshRate:  time.Minute,
+				ExternalNameDNSLookupFamily: "v6",
+				ExternalNameDeniedNames:     []string{"internal.example.com"},
+			}
+			b.reset()
+			got := b.lookupService(Meta{name: "kuard", namespace: "default"}, intstr.FromInt(80))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestDeniedExternalName(t *testing.T) {
+	tests := map[string]bool{
+		"foo.io":                     false,
+		"kuard.default.svc":          false,
+		"10.0.0.1":                   false,
+		"2001:db8::1":                false,
+		"localhost":                  true,
+		"LOCALHOST.":                 true,
+		"foo.localhost":              true,
+		"localhost.localdomain":      true,
+		"127.0.0.1":                  true,
+		"127.1.2.3":                  true,
+		"::1":                        true,
+		"[::1]":                      true,
+		"0.0.0.0":                    true,
+		"::":                         true,
+		"169.254.169.254":            true,
+		"fe80::1":                    true,
+		"224.0.0.1":                  true,
+		"metadata":                   true,
+		"metadata.google.internal.":  true,
+		"METADATA.GOOGLE.INTERNAL":   true,
+		"instance-data.ec2.internal": true,
+		"100.100.100.200":            true,
+		"fd00:ec2::254":              true,
+		"fd00:ec2:0::254":            true,
+		"metadata.example.com":       false,
+		"google.internal":            false,
+	}
+
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := deniedExternalName(name, nil)
+			if got != want {
+				t.Fatalf("deniedExternalName(%q): expected %v, got %v", name, want, got)
+			}
+		})
+	}
+
+	denied := []string{"internal.example.com.", "10.0.0.1", ""}
+	configured := map[string]bool{
+		"internal.example.com":     true,
+		"api.internal.example.com": true,
+		"Internal.Example.Com":     true,
+		"10.0.0.1":                 true,
+		"example.com":              false,
+		"notinternal.example.com":  false,
+		"10.0.0.2":                 false,
+	}
+
+	for name, want := range configured {
+		t.Run("configured "+name, func(t *testing.T) {
+			got := deniedExternalName(name, denied)
+			if got != want {
+				t.Fatalf("deniedExternalName(%q, %q): expected %v, got %v", name, denied, want, got)
+			}
+		})
+	}
+}
+
+func TestDAGFleet(t *testing.T) {
+	sec := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata("certificate", "key"),
+	}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	proxy := func(name, fqdn string, tls *projcontour.TLS) *projcontour.HTTPProxy {
+		return &projcontour.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: projcontour.HTTPProxySpec{
+				VirtualHost: &projcontour.VirtualHost{
+					Fqdn: fqdn,
+					TLS:  tls,
+				},
+				Fleet: "internal",
+				Routes: []projcontour.Route{{
+					Services: []projcontour.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: testLogger(t),
+		},
+	}
+	for _, o := range []interface{}{
+		sec, svc,
+		proxy("secure", "secure.example.com", &projcontour.TLS{SecretName: sec.Name}),
+		proxy("insecure", "insecure.example.com", nil),
+	} {
+		builder.Source.Insert(o)
+	}
+	builder.Build()
+
+	got := make(map[string]string)
+	for name, vh := range builder.virtualhosts {
+		got["http/"+name] = vh.Fleet
+	}
+	for name, svh := range builder.securevirtualhosts {
+		got["https/"+name] = svh.Fleet
+	}
+
+	// the secure vhost of insecure.example.com holds its routes,
+	// but has no secret, so is not scoped to the fleet.
+	want := map[string]string{
+		"http/secure.example.com":    "internal",
+		"https/secure.example.com":   "internal",
+		"http/insecure.example.com":  "internal",
+		"https/insecure.example.com": "",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
 func TestDAGRootNamespaces(t *testing.T) {
 	ir1 := &ingressroutev1.IngressRoute{
 		ObjectMeta: metav1.ObjectMeta{
@@ -4257,49 +4877,48 @@ func TestMatchesPathPrefix(t *testing.T) {
 
 func TestHttpPaths(t *testing.T) {
 	tests := map[string]struct {
-		rule v1beta1.IngressRule
-		want []v1beta1.HTTPIngressPath
+		rule networking_v1.IngressRule
+		want []networking_v1.HTTPIngressPath
 	}{
 		"zero value": {
-			rule: v1beta1.IngressRule{},
+			rule: networking_v1.IngressRule{},
 			want: nil,
 		},
 		"empty paths": {
-			rule: v1beta1.IngressRule{
-				IngressRuleValue: v1beta1.IngressRuleValue{
-					HTTP: &v1beta1.HTTPIngressRuleValue{},
+			rule: networking_v1.IngressRule{
+				IngressRuleValue: networking_v1.IngressRuleValue{
+					HTTP: &networking_v1.HTTPIngressRuleValue{},
 				},
 			},
 			want: nil,
 		},
 		"several paths": {
-			rule: v1beta1.IngressRule{
-				IngressRuleValue: v1beta1.IngressRuleValue{
-					HTTP: &v1beta1.HTTPIngressRuleValue{
-						Paths: []v1beta1.HTTPIngressPath{{
-							Backend: v1beta1.IngressBackend{
-								ServiceName: "kuard",
-								ServicePort: intstr.FromString("http"),
-							},
+			rule: networking_v1.IngressRule{
+				IngressRuleValue: networking_v1.IngressRuleValue{
+					HTTP: &networking_v1.HTTPIngressRuleValue{
+						Paths: []networking_v1.HTTPIngressPath{{
+							Backend: *ingressBackend("kuard", intstr.FromString("http")),
 						}, {
-							Path: "/kuarder",
-							Backend: v1beta1.IngressBackend{
-								ServiceName: "kuarder",
-								ServicePort: intstr.FromInt(8080),
-							},
+							Path:    "/kuarder",
+							Backend: *ingressBackend("kuarder", intstr.FromInt(8080)),
 						}},
 					},
 				},
 			},
-			want: []v1beta1.HTTPIngressPath{{
-				Backend: v1beta1.IngressBackend{
-					ServiceName: "kuard",
-					ServicePort: intstr.FromString("http"),
+			want: []networking_v1.HTTPIngressPath{{
+				Backend: networking_v1.IngressBackend{
+					Service: &networking_v1.IngressServiceBackend{
+						Name: "kuard",
+						Port: networking_v1.ServiceBackendPort{Name: "http"},
+					},
 				},
 			}, {
 				Path: "/kuarder",
-				Backend: v1beta1.IngressBackend{ServiceName: "kuarder",
-					ServicePort: intstr.FromInt(8080),
+				Backend: networking_v1.IngressBackend{
+					Service: &networking_v1.IngressServiceBackend{
+						Name: "kuarder",
+						Port: networking_v1.ServiceBackendPort{Number: 8080},
+					},
 				},
 			}},
 		},
@@ -4314,6 +4933,7 @@ func TestHttpPaths(t *testing.T) {
 		})
 	}
 }
+
 func TestEnforceRoute(t *testing.T) {
 	tests := map[string]struct {
 		tlsEnabled     bool
@@ -4414,7 +5034,7 @@ func routes(v ...Vertex) map[string]Vertex {
 		case *PrefixRoute:
 			m[r.Prefix] = r
 		case *RegexRoute:
-			m[r.Regex] = r
+			m["regex:"+r.Regex] = r
 		default:
 			panic(fmt.Sprintf("unexpected route type: %T %#v", r, r))
 		}
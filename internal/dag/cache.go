@@ -16,7 +16,13 @@ package dag
 import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
+	networking_v1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha1"
 
 	ingressroutev1 "github.com/projectcontour/contour/apis/contour/v1beta1"
 	projectcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
@@ -25,6 +31,14 @@ import (
 
 const DEFAULT_INGRESS_CLASS = "contour"
 
+// DEFAULT_INGRESS_CLASS_CONTROLLER is the controller name of
+// the IngressClasses whose Ingresses Contour serves.
+const DEFAULT_INGRESS_CLASS_CONTROLLER = "projectcontour.io/contour"
+
+// DEFAULT_GATEWAY_CONTROLLER is the controller name of
+// the GatewayClasses whose Gateways Contour serves.
+const DEFAULT_GATEWAY_CONTROLLER = "projectcontour.io/contour"
+
 // A KubernetesCache holds Kubernetes objects and associated configuration and produces
 // DAG values.
 type KubernetesCache struct {
@@ -33,17 +47,47 @@ type KubernetesCache struct {
 	// namespace.
 	RootNamespaces []string
 
+	// WatchNamespaces specifies the namespaces Contour watches.
+	// Objects in other namespaces are not accepted by the cache.
+	// If empty, objects in every namespace are accepted.
+	WatchNamespaces []string
+
 	// Contour's IngressClass.
 	// If not set, defaults to DEFAULT_INGRESS_CLASS.
 	IngressClass string
 
-	ingresses            map[Meta]*v1beta1.Ingress
+	// The controller name of Contour's IngressClass objects.
+	// If not set, defaults to DEFAULT_INGRESS_CLASS_CONTROLLER.
+	IngressClassController string
+
+	// ClientCertificate is the Secret Envoy presents to upstream
+	// services connected to with TLS which do not specify their own.
+	// If not set, no client certificate is presented.
+	ClientCertificate *types.NamespacedName
+
+	// The controller name of Contour's GatewayClass objects.
+	// If not set, defaults to DEFAULT_GATEWAY_CONTROLLER.
+	GatewayController string
+
+	ingresses            map[Meta]*networking_v1.Ingress
+	ingressclasses       map[string]*networking_v1.IngressClass
 	ingressroutes        map[Meta]*ingressroutev1.IngressRoute
 	httpproxies          map[Meta]*projectcontour.HTTPProxy
 	secrets              map[Meta]*v1.Secret
 	irdelegations        map[Meta]*ingressroutev1.TLSCertificateDelegation
 	httpproxydelegations map[Meta]*projectcontour.TLSCertificateDelegation
+	servicedelegations   map[Meta]*projectcontour.ServiceDelegation
 	services             map[Meta]*v1.Service
+	namespaces           map[string]*v1.Namespace
+	gatewayclasses       map[string]*gatewayapi.GatewayClass
+	gateways             map[Meta]*gatewayapi.Gateway
+	httproutes           map[Meta]*gatewayapi.HTTPRoute
+	tlsroutes            map[Meta]*gatewayapi.TLSRoute
+
+	// secretrefs indexes the secrets referenced by the objects in the
+	// cache. It is built by secretTriggersRebuild when nil, and reset
+	// when an object which may reference secrets is inserted or removed.
+	secretrefs map[Meta]bool
 
 	logrus.FieldLogger
 }
@@ -66,6 +110,12 @@ func toMeta(obj Object) Meta {
 // is not interesting to the cache. If an object with a matching type, name,
 // and namespace exists, it will be overwritten.
 func (kc *KubernetesCache) Insert(obj interface{}) bool {
+	if o, ok := obj.(metav1.Object); ok && !kc.watchesNamespace(o.GetNamespace()) {
+		// ignore objects in namespaces contour is not watching.
+		return false
+	}
+	kc.resetSecretRefs(obj)
+
 	switch obj := obj.(type) {
 	case *v1.Secret:
 		if obj.Type == v1.SecretTypeServiceAccountToken {
@@ -91,16 +141,15 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 		kc.services[m] = obj
 		return kc.serviceTriggersRebuild(obj)
 	case *v1beta1.Ingress:
-		class := ingressClass(obj)
-		if class != "" && class != kc.ingressClass() {
-			return false
-		}
-		m := toMeta(obj)
-		if kc.ingresses == nil {
-			kc.ingresses = make(map[Meta]*v1beta1.Ingress)
-		}
-		kc.ingresses[m] = obj
-		return true
+		return kc.insertIngress(extensionsIngress(obj))
+	case *networking_v1beta1.Ingress:
+		return kc.insertIngress(networkingV1beta1Ingress(obj))
+	case *networking_v1.Ingress:
+		return kc.insertIngress(obj)
+	case *networking_v1beta1.IngressClass:
+		return kc.insertIngressClass(networkingV1beta1IngressClass(obj))
+	case *networking_v1.IngressClass:
+		return kc.insertIngressClass(obj)
 	case *ingressroutev1.IngressRoute:
 		class := ingressClass(obj)
 		if class != "" && class != kc.ingressClass() {
@@ -137,6 +186,46 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 		}
 		kc.httpproxydelegations[m] = obj
 		return true
+	case *projectcontour.ServiceDelegation:
+		m := toMeta(obj)
+		if kc.servicedelegations == nil {
+			kc.servicedelegations = make(map[Meta]*projectcontour.ServiceDelegation)
+		}
+		kc.servicedelegations[m] = obj
+		return true
+	case *v1.Namespace:
+		if kc.namespaces == nil {
+			kc.namespaces = make(map[string]*v1.Namespace)
+		}
+		kc.namespaces[obj.Name] = obj
+		return kc.namespaceTriggersRebuild()
+	case *gatewayapi.GatewayClass:
+		if kc.gatewayclasses == nil {
+			kc.gatewayclasses = make(map[string]*gatewayapi.GatewayClass)
+		}
+		kc.gatewayclasses[obj.Name] = obj
+		return true
+	case *gatewayapi.Gateway:
+		m := toMeta(obj)
+		if kc.gateways == nil {
+			kc.gateways = make(map[Meta]*gatewayapi.Gateway)
+		}
+		kc.gateways[m] = obj
+		return true
+	case *gatewayapi.HTTPRoute:
+		m := toMeta(obj)
+		if kc.httproutes == nil {
+			kc.httproutes = make(map[Meta]*gatewayapi.HTTPRoute)
+		}
+		kc.httproutes[m] = obj
+		return true
+	case *gatewayapi.TLSRoute:
+		m := toMeta(obj)
+		if kc.tlsroutes == nil {
+			kc.tlsroutes = make(map[Meta]*gatewayapi.TLSRoute)
+		}
+		kc.tlsroutes[m] = obj
+		return true
 
 	default:
 		// not an interesting object
@@ -145,12 +234,105 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 	}
 }
 
+// watchesNamespace returns true if objects in namespace are watched.
+// Cluster scoped objects, which have no namespace, are always watched.
+func (kc *KubernetesCache) watchesNamespace(namespace string) bool {
+	if len(kc.WatchNamespaces) == 0 || namespace == "" {
+		return true
+	}
+	for _, ns := range kc.WatchNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
 // ingressClass returns the IngressClass
 // or DEFAULT_INGRESS_CLASS if not configured.
 func (kc *KubernetesCache) ingressClass() string {
 	return stringOrDefault(kc.IngressClass, DEFAULT_INGRESS_CLASS)
 }
 
+// ingressClassController returns the IngressClassController
+// or DEFAULT_INGRESS_CLASS_CONTROLLER if not configured.
+func (kc *KubernetesCache) ingressClassController() string {
+	return stringOrDefault(kc.IngressClassController, DEFAULT_INGRESS_CLASS_CONTROLLER)
+}
+
+// gatewayController returns the GatewayController
+// or DEFAULT_GATEWAY_CONTROLLER if not configured.
+func (kc *KubernetesCache) gatewayController() string {
+	return stringOrDefault(kc.GatewayController, DEFAULT_GATEWAY_CONTROLLER)
+}
+
+func (kc *KubernetesCache) insertIngress(ing *networking_v1.Ingress) bool {
+	class := ingressClass(ing)
+	if class != "" && class != kc.ingressClass() {
+		return false
+	}
+	m := toMeta(ing)
+	if kc.ingresses == nil {
+		kc.ingresses = make(map[Meta]*networking_v1.Ingress)
+	}
+	kc.ingresses[m] = ing
+	return true
+}
+
+func (kc *KubernetesCache) insertIngressClass(class *networking_v1.IngressClass) bool {
+	if kc.ingressclasses == nil {
+		kc.ingressclasses = make(map[string]*networking_v1.IngressClass)
+	}
+	kc.ingressclasses[class.Name] = class
+	return true
+}
+
+// isContourIngressClass returns true if the named IngressClass is
+// Contour's, either by name, or by the controller of its object.
+func (kc *KubernetesCache) isContourIngressClass(name string) bool {
+	if name == kc.ingressClass() {
+		return true
+	}
+	class, ok := kc.ingressclasses[name]
+	return ok && class.Spec.Controller == kc.ingressClassController()
+}
+
+// matchesIngressClass returns true if Contour should serve the Ingress.
+// Ingresses with a class annotation for another class are never added
+// to the cache. Otherwise an Ingress matches if its ingressClassName is
+// one of Contour's IngressClasses or, if it has no class, when there is
+// no default IngressClass, or the default IngressClass is Contour's.
+func (kc *KubernetesCache) matchesIngressClass(ing *networking_v1.Ingress) bool {
+	if ingressClass(ing) != "" {
+		return true
+	}
+	if name := ing.Spec.IngressClassName; name != nil {
+		return kc.isContourIngressClass(*name)
+	}
+	hasDefault := false
+	for _, class := range kc.ingressclasses {
+		if class.Annotations[annotationDefaultIngressClass] != "true" {
+			continue
+		}
+		if kc.isContourIngressClass(class.Name) {
+			return true
+		}
+		hasDefault = true
+	}
+	return !hasDefault
+}
+
+// validIngresses returns the Ingresses Contour should serve.
+func (kc *KubernetesCache) validIngresses() []*networking_v1.Ingress {
+	var ingresses []*networking_v1.Ingress
+	for _, ing := range kc.ingresses {
+		if kc.matchesIngressClass(ing) {
+			ingresses = append(ingresses, ing)
+		}
+	}
+	return ingresses
+}
+
 // Remove removes obj from the KubernetesCache.
 // Remove returns a boolean indiciating if the cache changed after the remove operation.
 func (kc *KubernetesCache) Remove(obj interface{}) bool {
@@ -163,22 +345,32 @@ func (kc *KubernetesCache) Remove(obj interface{}) bool {
 }
 
 func (kc *KubernetesCache) remove(obj interface{}) bool {
+	kc.resetSecretRefs(obj)
+
 	switch obj := obj.(type) {
 	case *v1.Secret:
+		// removing a secret only changes the DAG if the secret
+		// was referenced, so check before it is deleted.
 		m := toMeta(obj)
 		_, ok := kc.secrets[m]
+		trigger := ok && kc.secretTriggersRebuild(obj)
 		delete(kc.secrets, m)
-		return ok
+		return trigger
 	case *v1.Service:
 		m := toMeta(obj)
 		_, ok := kc.services[m]
 		delete(kc.services, m)
 		return ok
 	case *v1beta1.Ingress:
-		m := toMeta(obj)
-		_, ok := kc.ingresses[m]
-		delete(kc.ingresses, m)
-		return ok
+		return kc.removeIngress(toMeta(obj))
+	case *networking_v1beta1.Ingress:
+		return kc.removeIngress(toMeta(obj))
+	case *networking_v1.Ingress:
+		return kc.removeIngress(toMeta(obj))
+	case *networking_v1beta1.IngressClass:
+		return kc.removeIngressClass(obj.Name)
+	case *networking_v1.IngressClass:
+		return kc.removeIngressClass(obj.Name)
 	case *ingressroutev1.IngressRoute:
 		m := toMeta(obj)
 		_, ok := kc.ingressroutes[m]
@@ -199,6 +391,34 @@ func (kc *KubernetesCache) remove(obj interface{}) bool {
 		_, ok := kc.httpproxydelegations[m]
 		delete(kc.httpproxydelegations, m)
 		return ok
+	case *projectcontour.ServiceDelegation:
+		m := toMeta(obj)
+		_, ok := kc.servicedelegations[m]
+		delete(kc.servicedelegations, m)
+		return ok
+	case *v1.Namespace:
+		_, ok := kc.namespaces[obj.Name]
+		delete(kc.namespaces, obj.Name)
+		return ok && kc.namespaceTriggersRebuild()
+	case *gatewayapi.GatewayClass:
+		_, ok := kc.gatewayclasses[obj.Name]
+		delete(kc.gatewayclasses, obj.Name)
+		return ok
+	case *gatewayapi.Gateway:
+		m := toMeta(obj)
+		_, ok := kc.gateways[m]
+		delete(kc.gateways, m)
+		return ok
+	case *gatewayapi.HTTPRoute:
+		m := toMeta(obj)
+		_, ok := kc.httproutes[m]
+		delete(kc.httproutes, m)
+		return ok
+	case *gatewayapi.TLSRoute:
+		m := toMeta(obj)
+		_, ok := kc.tlsroutes[m]
+		delete(kc.tlsroutes, m)
+		return ok
 	default:
 		// not interesting
 		kc.WithField("object", obj).Error("remove unknown object")
@@ -213,8 +433,8 @@ func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
 		if ingress.Namespace != service.Namespace {
 			continue
 		}
-		if backend := ingress.Spec.Backend; backend != nil {
-			if backend.ServiceName == service.Name {
+		if backend := ingress.Spec.DefaultBackend; backend != nil {
+			if backend.Service != nil && backend.Service.Name == service.Name {
 				return true
 			}
 		}
@@ -225,7 +445,7 @@ func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
 				continue
 			}
 			for _, path := range http.Paths {
-				if path.Backend.ServiceName == service.Name {
+				if path.Backend.Service != nil && path.Backend.Service.Name == service.Name {
 					return true
 				}
 			}
@@ -253,17 +473,14 @@ func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
 	}
 
 	for _, ir := range kc.httpproxies {
-		if ir.Namespace != service.Namespace {
-			continue
-		}
 		for _, route := range ir.Spec.Routes {
 			for _, s := range route.Services {
-				if s.Name == service.Name {
+				if s.Name == service.Name && serviceNamespace(s, ir.Namespace) == service.Namespace {
 					return true
 				}
 			}
 		}
-		if tcpproxy := ir.Spec.TCPProxy; tcpproxy != nil {
+		if tcpproxy := ir.Spec.TCPProxy; tcpproxy != nil && ir.Namespace == service.Namespace {
 			for _, s := range tcpproxy.Services {
 				if s.Name == service.Name {
 					return true
@@ -272,118 +489,227 @@ func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
 		}
 	}
 
-	return false
-}
+	for _, route := range kc.httproutes {
+		if route.Namespace != service.Namespace {
+			continue
+		}
+		for _, rule := range route.Spec.Rules {
+			for _, fwd := range rule.ForwardTo {
+				if fwd.ServiceName != nil && *fwd.ServiceName == service.Name {
+					return true
+				}
+			}
+		}
+	}
 
-// secretTriggersRebuild returns true if this secret is referenced by an Ingress
-// or IngressRoute object in this cache. If the secret is not in the same namespace
-// it must be mentioned by a TLSCertificateDelegation.
-func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
-	if _, isCA := secret.Data["ca.crt"]; isCA {
-		// locating a secret validation usage involves traversing each
-		// ingressroute object, determining if there is a valid delegation,
-		// and if the reference the secret as a certificate. The DAG already
-		// does this so don't reproduce the logic and just assume for the moment
-		// that any change to a CA secret will trigger a rebuild.
-		return true
+	for _, route := range kc.tlsroutes {
+		if route.Namespace != service.Namespace {
+			continue
+		}
+		for _, rule := range route.Spec.Rules {
+			for _, fwd := range rule.ForwardTo {
+				if fwd.ServiceName != nil && *fwd.ServiceName == service.Name {
+					return true
+				}
+			}
+		}
 	}
 
-	delegations := make(map[string]bool) // targetnamespace/secretname to bool
+	return false
+}
 
-	// merge ingressroute.TLSCertificateDelegation and projectcontour.TLSCertificateDelegation.
+// namespaceTriggersRebuild returns true if a Gateway selects the
+// routes it serves, or a TLSCertificateDelegation or ServiceDelegation
+// selects the namespaces it delegates to, by the labels of their namespace.
+func (kc *KubernetesCache) namespaceTriggersRebuild() bool {
+	for _, gw := range kc.gateways {
+		for _, l := range gw.Spec.Listeners {
+			if ns := l.Routes.Namespaces; ns != nil && ns.From != nil && *ns.From == gatewayapi.RouteSelectSelector {
+				return true
+			}
+		}
+	}
 	for _, d := range kc.irdelegations {
 		for _, cd := range d.Spec.Delegations {
-			for _, n := range cd.TargetNamespaces {
-				delegations[n+"/"+cd.SecretName] = true
+			if cd.TargetNamespaceSelector != nil {
+				return true
 			}
 		}
 	}
 	for _, d := range kc.httpproxydelegations {
 		for _, cd := range d.Spec.Delegations {
-			for _, n := range cd.TargetNamespaces {
-				delegations[n+"/"+cd.SecretName] = true
+			if cd.TargetNamespaceSelector != nil {
+				return true
 			}
 		}
 	}
-
-	for _, ingress := range kc.ingresses {
-		if ingress.Namespace == secret.Namespace {
-			for _, tls := range ingress.Spec.TLS {
-				if tls.SecretName == secret.Name {
-					return true
-				}
-			}
-		}
-		if delegations[ingress.Namespace+"/"+secret.Name] {
-			for _, tls := range ingress.Spec.TLS {
-				if tls.SecretName == secret.Namespace+"/"+secret.Name {
-					return true
-				}
+	for _, d := range kc.servicedelegations {
+		for _, sd := range d.Spec.Delegations {
+			if sd.TargetNamespaceSelector != nil {
+				return true
 			}
 		}
+	}
+	return false
+}
 
-		if delegations["*/"+secret.Name] {
-			for _, tls := range ingress.Spec.TLS {
-				if tls.SecretName == secret.Namespace+"/"+secret.Name {
-					return true
-				}
-			}
+// delegatesTo returns true if a CertificateDelegation or DelegatedService
+// with the targetNamespaces and selector delegates to namespace.
+func (kc *KubernetesCache) delegatesTo(targetNamespaces []string, selector *metav1.LabelSelector, namespace string) bool {
+	if len(targetNamespaces) == 1 && targetNamespaces[0] == "*" {
+		return true
+	}
+	for _, n := range targetNamespaces {
+		if n == namespace {
+			return true
 		}
 	}
+	return kc.selectsNamespace(selector, namespace)
+}
 
-	for _, ir := range kc.ingressroutes {
-		vh := ir.Spec.VirtualHost
-		if vh == nil {
-			// not a root ingress
-			continue
+// selectsNamespace returns true if the labels of namespace match
+// selector. A nil or invalid selector selects no namespaces.
+func (kc *KubernetesCache) selectsNamespace(selector *metav1.LabelSelector, namespace string) bool {
+	if selector == nil {
+		return false
+	}
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	ns, ok := kc.namespaces[namespace]
+	return ok && sel.Matches(labels.Set(ns.Labels))
+}
+
+func (kc *KubernetesCache) removeIngress(m Meta) bool {
+	_, ok := kc.ingresses[m]
+	delete(kc.ingresses, m)
+	return ok
+}
+
+func (kc *KubernetesCache) removeIngressClass(name string) bool {
+	_, ok := kc.ingressclasses[name]
+	delete(kc.ingressclasses, name)
+	return ok
+}
+
+// secretTriggersRebuild returns true if this secret is referenced by an
+// object in this cache. If the secret is not in the same namespace as the
+// object referencing it, it must be delegated to the object's namespace by a
+// TLSCertificateDelegation.
+func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
+	if kc.secretrefs == nil {
+		kc.secretrefs = kc.referencedSecrets()
+	}
+	return kc.secretrefs[toMeta(secret)]
+}
+
+// resetSecretRefs discards the index of referenced secrets
+// if obj may reference secrets, or change the namespaces
+// a secret is delegated to.
+func (kc *KubernetesCache) resetSecretRefs(obj interface{}) {
+	switch obj.(type) {
+	case *v1beta1.Ingress, *networking_v1beta1.Ingress, *networking_v1.Ingress,
+		*ingressroutev1.IngressRoute, *projectcontour.HTTPProxy,
+		*ingressroutev1.TLSCertificateDelegation, *projectcontour.TLSCertificateDelegation,
+		*v1.Namespace, *gatewayapi.Gateway:
+		kc.secretrefs = nil
+	}
+}
+
+// referencedSecrets returns the secrets referenced as TLS certificates,
+// client certificates, or upstream validation CAs by the objects in this
+// cache, permitted by a TLSCertificateDelegation if in another namespace.
+func (kc *KubernetesCache) referencedSecrets() map[Meta]bool {
+	refs := make(map[Meta]bool)
+	ref := func(m Meta) {
+		if m.name != "" {
+			refs[m] = true
 		}
-		tls := vh.TLS
-		if tls == nil {
-			// no tls spec
-			continue
+	}
+	delegated := func(secretName, namespace string) {
+		m := splitSecret(secretName, namespace)
+		if kc.delegationPermitted(m, namespace) {
+			ref(m)
 		}
+	}
 
-		if ir.Namespace == secret.Namespace && tls.SecretName == secret.Name {
-			return true
+	for _, ingress := range kc.ingresses {
+		for _, tls := range ingress.Spec.TLS {
+			delegated(tls.SecretName, ingress.Namespace)
 		}
-		if delegations[ir.Namespace+"/"+secret.Name] {
-			if tls.SecretName == secret.Namespace+"/"+secret.Name {
-				return true
-			}
+	}
+
+	for _, ir := range kc.ingressroutes {
+		if vh := ir.Spec.VirtualHost; vh != nil && vh.TLS != nil {
+			delegated(vh.TLS.SecretName, ir.Namespace)
 		}
-		if delegations["*/"+secret.Name] {
-			if tls.SecretName == secret.Namespace+"/"+secret.Name {
-				return true
+		for _, route := range ir.Spec.Routes {
+			for _, service := range route.Services {
+				if uv := service.UpstreamValidation; uv != nil {
+					ref(Meta{name: uv.CACertificate, namespace: ir.Namespace})
+				}
 			}
 		}
 	}
 
 	for _, proxy := range kc.httpproxies {
-		vh := proxy.Spec.VirtualHost
-		if vh == nil {
-			// not a root ingress
-			continue
+		if vh := proxy.Spec.VirtualHost; vh != nil && vh.TLS != nil {
+			delegated(vh.TLS.SecretName, proxy.Namespace)
+		}
+		for _, route := range proxy.Spec.Routes {
+			for _, service := range route.Services {
+				ref(Meta{name: service.ClientCertificate, namespace: proxy.Namespace})
+				if uv := service.UpstreamValidation; uv != nil {
+					ref(Meta{name: uv.CACertificate, namespace: proxy.Namespace})
+				}
+			}
 		}
-		tls := vh.TLS
-		if tls == nil {
-			// no tls spec
-			continue
+	}
+
+	if cc := kc.ClientCertificate; cc != nil {
+		ref(Meta{name: cc.Name, namespace: cc.Namespace})
+	}
+
+	for _, gw := range kc.gateways {
+		for _, l := range gw.Spec.Listeners {
+			if l.TLS != nil && l.TLS.CertificateRef != nil {
+				ref(Meta{name: l.TLS.CertificateRef.Name, namespace: gw.Namespace})
+			}
 		}
+	}
 
-		if proxy.Namespace == secret.Namespace && tls.SecretName == secret.Name {
-			return true
+	return refs
+}
+
+// delegationPermitted returns true if the secret may be referenced from
+// the namespace to, either because it is in that namespace, or because
+// a TLSCertificateDelegation in its namespace delegates it to that
+// namespace, by name or by the labels of the namespace.
+func (kc *KubernetesCache) delegationPermitted(secret Meta, to string) bool {
+	if secret.namespace == to {
+		// secret is in the same namespace as target
+		return true
+	}
+	for _, d := range kc.irdelegations {
+		if d.Namespace != secret.namespace {
+			continue
 		}
-		if delegations[proxy.Namespace+"/"+secret.Name] {
-			if tls.SecretName == secret.Namespace+"/"+secret.Name {
+		for _, d := range d.Spec.Delegations {
+			if secret.name == d.SecretName && kc.delegatesTo(d.TargetNamespaces, d.TargetNamespaceSelector, to) {
 				return true
 			}
 		}
-		if delegations["*/"+secret.Name] {
-			if tls.SecretName == secret.Namespace+"/"+secret.Name {
+	}
+	for _, d := range kc.httpproxydelegations {
+		if d.Namespace != secret.namespace {
+			continue
+		}
+		for _, d := range d.Spec.Delegations {
+			if secret.name == d.SecretName && kc.delegatesTo(d.TargetNamespaces, d.TargetNamespaceSelector, to) {
 				return true
 			}
 		}
 	}
-
 	return false
 }
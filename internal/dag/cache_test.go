@@ -21,14 +21,22 @@ import (
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
+	networking_v1beta1 "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha1"
 )
 
 func TestKubernetesCacheInsert(t *testing.T) {
+	fromSelector := gatewayapi.RouteSelectSelector
+
 	tests := map[string]struct {
-		pre  []interface{}
-		obj  interface{}
-		want bool
+		pre               []interface{}
+		clientCertificate *types.NamespacedName
+		watchNamespaces   []string
+		obj               interface{}
+		want              bool
 	}{
 		"insert secret": {
 			obj: &v1.Secret{
@@ -369,6 +377,55 @@ func TestKubernetesCacheInsert(t *testing.T) {
 			},
 			want: true,
 		},
+		"insert secret referenced by httpproxy via tls delegation selector": {
+			pre: []interface{}{
+				&v1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "extra",
+						Labels: map[string]string{
+							"team": "web",
+						},
+					},
+				},
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "extra",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							TLS: &projcontour.TLS{
+								SecretName: "default/secret",
+							},
+						},
+					},
+				},
+				&projcontour.TLSCertificateDelegation{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "delegation",
+						Namespace: "default",
+					},
+					Spec: projcontour.TLSCertificateDelegationSpec{
+						Delegations: []projcontour.CertificateDelegation{{
+							SecretName: "secret",
+							TargetNamespaceSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{
+									"team": "web",
+								},
+							},
+						}},
+					},
+				},
+			},
+			obj: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: "default",
+				},
+				Type: v1.SecretTypeTLS,
+			},
+			want: true,
+		},
 		"insert certificate secret": {
 			obj: &v1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
@@ -379,11 +436,7 @@ func TestKubernetesCacheInsert(t *testing.T) {
 					"ca.crt": []byte("ca"),
 				},
 			},
-			// TODO(dfc) this should be false because the CA secret is
-			// not referenced, but computing its reference duplicates the
-			// work done rebuilding the dag so for the moment assume that
-			// any CA secret causes a rebuild.
-			want: true,
+			want: false,
 		},
 		"insert certificate secret referenced by ingressroute": {
 			pre: []interface{}{
@@ -421,33 +474,51 @@ func TestKubernetesCacheInsert(t *testing.T) {
 			},
 			want: true,
 		},
-		/*
-			"insert certificate secret referenced by httpproxy": {
-				pre: []interface{}{
-					&projcontour.HTTPProxy{
-						ObjectMeta: metav1.ObjectMeta{
-							Name:      "example-com",
-							Namespace: "default",
+		"insert client certificate secret referenced by httpproxy": {
+			pre: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "example-com",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "example.com",
 						},
-						Spec: projcontour.HTTPProxySpec{
-							VirtualHost: &projcontour.VirtualHost{
-								Fqdn: "example.com",
-							},
-							Routes: []projcontour.Route{{
-								Match: "/",
-								Services: []projcontour.Service{{
-									Name: "kuard",
-									Port: 8080,
-									UpstreamValidation: &projcontour.UpstreamValidation{
-										CACertificate: "ca",
-										SubjectName:   "example.com",
-									},
-								}},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name:              "kuard",
+								Port:              8080,
+								Protocol:          "tls",
+								ClientCertificate: "clientcert",
 							}},
-						},
+						}},
 					},
 				},
-				obj: &v1.Secret{
+			},
+			obj: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "clientcert",
+					Namespace: "default",
+				},
+				Type: v1.SecretTypeTLS,
+			},
+			want: true,
+		},
+		"insert default client certificate secret": {
+			clientCertificate: &types.NamespacedName{Namespace: "projectcontour", Name: "clientcert"},
+			obj: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "clientcert",
+					Namespace: "projectcontour",
+				},
+				Type: v1.SecretTypeTLS,
+			},
+			want: true,
+		},
+		"insert certificate secret referenced by httpproxy inserted after it": {
+			pre: []interface{}{
+				&v1.Secret{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "ca",
 						Namespace: "default",
@@ -456,9 +527,81 @@ func TestKubernetesCacheInsert(t *testing.T) {
 						"ca.crt": []byte("ca"),
 					},
 				},
-				want: true,
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "example-com",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "kuard",
+								Port: 8080,
+								UpstreamValidation: &projcontour.UpstreamValidation{
+									CACertificate: "ca",
+									SubjectName:   "example.com",
+								},
+							}},
+						}},
+					},
+				},
+			},
+			obj: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ca",
+					Namespace: "default",
+				},
+				Data: map[string][]byte{
+					"ca.crt": []byte("ca"),
+				},
+			},
+			want: true,
+		},
+		"insert helm release secret": {
+			obj: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "sh.helm.release.v1.kuard.v1",
+					Namespace: "default",
+				},
+				Type: "helm.sh/release.v1",
 			},
-		*/
+			want: false,
+		},
+		"insert certificate secret referenced by httpproxy": {
+			pre: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "example-com",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "example.com",
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "kuard",
+								Port: 8080,
+								UpstreamValidation: &projcontour.UpstreamValidation{
+									CACertificate: "ca",
+									SubjectName:   "example.com",
+								},
+							}},
+						}},
+					},
+				},
+			},
+			obj: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ca",
+					Namespace: "default",
+				},
+				Data: map[string][]byte{
+					"ca.crt": []byte("ca"),
+				},
+			},
+			want: true,
+		},
 		"insert ingress empty ingress class": {
 			obj: &v1beta1.Ingress{
 				ObjectMeta: metav1.ObjectMeta{
@@ -648,6 +791,15 @@ func TestKubernetesCacheInsert(t *testing.T) {
 			},
 			want: true,
 		},
+		"insert service delegation": {
+			obj: &projcontour.ServiceDelegation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "delegate",
+					Namespace: "default",
+				},
+			},
+			want: true,
+		},
 		"insert httpproxy": {
 			obj: &projcontour.HTTPProxy{
 				ObjectMeta: metav1.ObjectMeta{
@@ -786,6 +938,56 @@ func TestKubernetesCacheInsert(t *testing.T) {
 			},
 			want: true,
 		},
+		"insert service in another namespace referenced by httpproxy": {
+			pre: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name:      "service",
+								Namespace: "shared",
+							}},
+						}},
+					},
+				},
+			},
+			obj: &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service",
+					Namespace: "shared",
+				},
+			},
+			want: true,
+		},
+		"insert service in namespace of httpproxy referencing another namespace": {
+			pre: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name:      "service",
+								Namespace: "shared",
+							}},
+						}},
+					},
+				},
+			},
+			obj: &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service",
+					Namespace: "default",
+				},
+			},
+			want: false,
+		},
 		"insert service referenced by httpproxy tcpproxy": {
 			pre: []interface{}{
 				&projcontour.HTTPProxy{
@@ -810,12 +1012,256 @@ func TestKubernetesCacheInsert(t *testing.T) {
 			},
 			want: true,
 		},
+		"insert networking.k8s.io/v1beta1 ingress": {
+			obj: &networking_v1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "www",
+					Namespace: "default",
+				},
+			},
+			want: true,
+		},
+		"insert networking.k8s.io/v1 ingress": {
+			obj: &networking_v1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "www",
+					Namespace: "default",
+				},
+			},
+			want: true,
+		},
+		"insert networking.k8s.io/v1 ingress incorrect ingressclass": {
+			obj: &networking_v1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "www",
+					Namespace: "default",
+					Annotations: map[string]string{
+						"kubernetes.io/ingress.class": "nginx",
+					},
+				},
+			},
+			want: false,
+		},
+		"insert ingressclass": {
+			obj: &networking_v1.IngressClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "contour",
+				},
+				Spec: networking_v1.IngressClassSpec{
+					Controller: "projectcontour.io/contour",
+				},
+			},
+			want: true,
+		},
+		"insert namespace": {
+			obj: &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "teama",
+				},
+			},
+			want: false,
+		},
+		"insert namespace selected by tls delegation": {
+			pre: []interface{}{
+				&projcontour.TLSCertificateDelegation{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "delegation",
+						Namespace: "default",
+					},
+					Spec: projcontour.TLSCertificateDelegationSpec{
+						Delegations: []projcontour.CertificateDelegation{{
+							SecretName: "secret",
+							TargetNamespaceSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{
+									"team": "web",
+								},
+							},
+						}},
+					},
+				},
+			},
+			obj: &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "teama",
+				},
+			},
+			want: true,
+		},
+		"insert namespace selected by gateway": {
+			pre: []interface{}{
+				&gatewayapi.Gateway{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "contour",
+						Namespace: "default",
+					},
+					Spec: gatewayapi.GatewaySpec{
+						Listeners: []gatewayapi.Listener{{
+							Routes: gatewayapi.RouteBindingSelector{
+								Namespaces: &gatewayapi.RouteNamespaces{
+									From: &fromSelector,
+								},
+							},
+						}},
+					},
+				},
+			},
+			obj: &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "teama",
+				},
+			},
+			want: true,
+		},
+		"insert secret referenced by gateway": {
+			pre: []interface{}{
+				&gatewayapi.Gateway{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "contour",
+						Namespace: "default",
+					},
+					Spec: gatewayapi.GatewaySpec{
+						Listeners: []gatewayapi.Listener{{
+							TLS: &gatewayapi.GatewayTLSConfig{
+								CertificateRef: &gatewayapi.LocalObjectReference{
+									Name: "secret",
+								},
+							},
+						}},
+					},
+				},
+			},
+			obj: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: "default",
+				},
+				Type: v1.SecretTypeTLS,
+				Data: secretdata("certificate", "key"),
+			},
+			want: true,
+		},
+		"insert service referenced by httproute": {
+			pre: []interface{}{
+				&gatewayapi.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: gatewayapi.HTTPRouteSpec{
+						Rules: []gatewayapi.HTTPRouteRule{{
+							ForwardTo: []gatewayapi.HTTPRouteForwardTo{{
+								ServiceName: stringptr("kuard"),
+							}},
+						}},
+					},
+				},
+			},
+			obj: &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kuard",
+					Namespace: "default",
+				},
+			},
+			want: true,
+		},
+		"insert gatewayclass": {
+			obj: &gatewayapi.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "contour",
+				},
+				Spec: gatewayapi.GatewayClassSpec{
+					Controller: "projectcontour.io/contour",
+				},
+			},
+			want: true,
+		},
+		"insert httproute": {
+			obj: &gatewayapi.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kuard",
+					Namespace: "default",
+				},
+			},
+			want: true,
+		},
+		"insert httpproxy in watched namespace": {
+			watchNamespaces: []string{"default", "tenant"},
+			obj: &projcontour.HTTPProxy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "simple",
+					Namespace: "tenant",
+				},
+			},
+			want: true,
+		},
+		"insert httpproxy in unwatched namespace": {
+			watchNamespaces: []string{"default", "tenant"},
+			obj: &projcontour.HTTPProxy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "simple",
+					Namespace: "other",
+				},
+			},
+			want: false,
+		},
+		"insert secret referenced by httpproxy in unwatched namespace": {
+			watchNamespaces: []string{"default"},
+			pre: []interface{}{
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "example.com",
+							TLS: &projcontour.TLS{
+								SecretName: "other/secret",
+							},
+						},
+					},
+				},
+			},
+			obj: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: "other",
+				},
+				Type: v1.SecretTypeTLS,
+				Data: secretdata("certificate", "key"),
+			},
+			want: false,
+		},
+		"insert service in unwatched namespace": {
+			watchNamespaces: []string{"default"},
+			obj: &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kuard",
+					Namespace: "other",
+				},
+			},
+			want: false,
+		},
+		"insert cluster scoped ingressclass with watched namespaces": {
+			watchNamespaces: []string{"default"},
+			obj: &networking_v1.IngressClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "contour",
+				},
+				Spec: networking_v1.IngressClassSpec{
+					Controller: "projectcontour.io/contour",
+				},
+			},
+			want: true,
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			cache := KubernetesCache{
-				FieldLogger: testLogger(t),
+				ClientCertificate: tc.clientCertificate,
+				WatchNamespaces:   tc.watchNamespaces,
+				FieldLogger:       testLogger(t),
 			}
 			for _, p := range tc.pre {
 				cache.Insert(p)
@@ -859,8 +1305,61 @@ func TestKubernetesCacheRemove(t *testing.T) {
 				},
 				Type: v1.SecretTypeTLS,
 			},
+			want: false,
+		},
+		"remove secret referenced by ingress": {
+			cache: cache(
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "www",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						TLS: []v1beta1.IngressTLS{{
+							SecretName: "secret",
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: v1.SecretTypeTLS,
+				},
+			),
+			obj: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: "default",
+				},
+				Type: v1.SecretTypeTLS,
+			},
 			want: true,
 		},
+		"remove secret not in cache": {
+			cache: cache(
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "www",
+						Namespace: "default",
+					},
+					Spec: v1beta1.IngressSpec{
+						TLS: []v1beta1.IngressTLS{{
+							SecretName: "secret",
+						}},
+					},
+				},
+			),
+			obj: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: "default",
+				},
+				Type: v1.SecretTypeTLS,
+			},
+			want: false,
+		},
 		"remove service": {
 			cache: cache(&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
@@ -912,6 +1411,34 @@ func TestKubernetesCacheRemove(t *testing.T) {
 			},
 			want: false,
 		},
+		"remove networking.k8s.io/v1 ingress": {
+			cache: cache(&networking_v1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ingress",
+					Namespace: "default",
+				},
+			}),
+			obj: &networking_v1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ingress",
+					Namespace: "default",
+				},
+			},
+			want: true,
+		},
+		"remove ingressclass": {
+			cache: cache(&networking_v1beta1.IngressClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "contour",
+				},
+			}),
+			obj: &networking_v1.IngressClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "contour",
+				},
+			},
+			want: true,
+		},
 		"remove ingressroute": {
 			cache: cache(&ingressroutev1.IngressRoute{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1015,3 +1542,244 @@ func (t *testWriter) Write(buf []byte) (int, error) {
 	t.Logf("%s", buf)
 	return len(buf), nil
 }
+
+func TestKubernetesCacheDelegationPermitted(t *testing.T) {
+	namespaces := map[string]*v1.Namespace{
+		"teama": {
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "teama",
+				Labels: map[string]string{
+					"team": "web",
+				},
+			},
+		},
+		"teamb": {
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "teamb",
+			},
+		},
+	}
+	httpproxydelegations := map[Meta]*projcontour.TLSCertificateDelegation{
+		{name: "delegation", namespace: "default"}: {
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "delegation",
+				Namespace: "default",
+			},
+			Spec: projcontour.TLSCertificateDelegationSpec{
+				Delegations: []projcontour.CertificateDelegation{{
+					SecretName:       "named",
+					TargetNamespaces: []string{"teamb"},
+				}, {
+					SecretName: "selected",
+					TargetNamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"team": "web",
+						},
+					},
+				}},
+			},
+		},
+	}
+	irdelegations := map[Meta]*ingressroutev1.TLSCertificateDelegation{
+		{name: "delegation", namespace: "default"}: {
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "delegation",
+				Namespace: "default",
+			},
+			Spec: ingressroutev1.TLSCertificateDelegationSpec{
+				Delegations: []ingressroutev1.CertificateDelegation{{
+					SecretName: "wildcard",
+					TargetNamespaces: []string{
+						"*",
+					},
+				}},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		secret Meta
+		to     string
+		want   bool
+	}{
+		"same namespace": {
+			secret: Meta{name: "other", namespace: "teamb"},
+			to:     "teamb",
+			want:   true,
+		},
+		"not delegated": {
+			secret: Meta{name: "other", namespace: "default"},
+			to:     "teamb",
+			want:   false,
+		},
+		"delegated by name": {
+			secret: Meta{name: "named", namespace: "default"},
+			to:     "teamb",
+			want:   true,
+		},
+		"not delegated by name": {
+			secret: Meta{name: "named", namespace: "default"},
+			to:     "teama",
+			want:   false,
+		},
+		"delegated by selector": {
+			secret: Meta{name: "selected", namespace: "default"},
+			to:     "teama",
+			want:   true,
+		},
+		"not selected": {
+			secret: Meta{name: "selected", namespace: "default"},
+			to:     "teamb",
+			want:   false,
+		},
+		"unknown namespace not selected": {
+			secret: Meta{name: "selected", namespace: "default"},
+			to:     "teamc",
+			want:   false,
+		},
+		"delegated by wildcard": {
+			secret: Meta{name: "wildcard", namespace: "default"},
+			to:     "teamc",
+			want:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kc := KubernetesCache{
+				namespaces:           namespaces,
+				irdelegations:        irdelegations,
+				httpproxydelegations: httpproxydelegations,
+				FieldLogger:          testLogger(t),
+			}
+			got := kc.delegationPermitted(tc.secret, tc.to)
+			if got != tc.want {
+				t.Fatalf("delegationPermitted(%v, %q): expected %v, got %v", tc.secret, tc.to, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestKubernetesCacheValidIngresses(t *testing.T) {
+	ingress := func(class *string, annotations map[string]string) *networking_v1.Ingress {
+		return &networking_v1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "ingress",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: networking_v1.IngressSpec{
+				IngressClassName: class,
+			},
+		}
+	}
+	ingressclass := func(name, controller string, isDefault bool) *networking_v1.IngressClass {
+		class := &networking_v1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: networking_v1.IngressClassSpec{
+				Controller: controller,
+			},
+		}
+		if isDefault {
+			class.Annotations = map[string]string{
+				"ingressclass.kubernetes.io/is-default-class": "true",
+			}
+		}
+		return class
+	}
+	name := func(s string) *string { return &s }
+
+	tests := map[string]struct {
+		controller string
+		objs       []interface{}
+		want       int
+	}{
+		"no class": {
+			objs: []interface{}{
+				ingress(nil, nil),
+			},
+			want: 1,
+		},
+		"class annotation": {
+			objs: []interface{}{
+				ingress(name("nginx"), map[string]string{
+					"kubernetes.io/ingress.class": "contour",
+				}),
+			},
+			want: 1,
+		},
+		"ingressClassName matches default ingress class": {
+			objs: []interface{}{
+				ingress(name("contour"), nil),
+			},
+			want: 1,
+		},
+		"ingressClassName does not match": {
+			objs: []interface{}{
+				ingress(name("nginx"), nil),
+			},
+			want: 0,
+		},
+		"ingressClassName matches ingressclass controller": {
+			objs: []interface{}{
+				ingressclass("public", "projectcontour.io/contour", false),
+				ingress(name("public"), nil),
+			},
+			want: 1,
+		},
+		"ingressClassName matches configured ingressclass controller": {
+			controller: "example.com/ingress",
+			objs: []interface{}{
+				ingressclass("public", "example.com/ingress", false),
+				ingress(name("public"), nil),
+			},
+			want: 1,
+		},
+		"ingressClassName matches another controller": {
+			objs: []interface{}{
+				ingressclass("public", "k8s.io/ingress-nginx", false),
+				ingress(name("public"), nil),
+			},
+			want: 0,
+		},
+		"no class, default ingressclass is contour's": {
+			objs: []interface{}{
+				ingressclass("public", "projectcontour.io/contour", true),
+				ingress(nil, nil),
+			},
+			want: 1,
+		},
+		"no class, default ingressclass is another controller's": {
+			objs: []interface{}{
+				ingressclass("nginx", "k8s.io/ingress-nginx", true),
+				ingress(nil, nil),
+			},
+			want: 0,
+		},
+		"no class, non default ingressclass": {
+			objs: []interface{}{
+				ingressclass("nginx", "k8s.io/ingress-nginx", false),
+				ingress(nil, nil),
+			},
+			want: 1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cache := KubernetesCache{
+				IngressClassController: tc.controller,
+				FieldLogger:            testLogger(t),
+			}
+			for _, o := range tc.objs {
+				cache.Insert(o)
+			}
+			got := len(cache.validIngresses())
+			if tc.want != got {
+				t.Fatalf("validIngresses(): expected %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
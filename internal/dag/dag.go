@@ -32,6 +32,9 @@ type DAG struct {
 
 	// status computed while building this dag.
 	statuses map[Meta]Status
+
+	// status of the Gateway API objects computed while building this dag.
+	gatewayAPIStatuses map[Object]*GatewayAPIStatus
 }
 
 // Visit calls fn on each root of this DAG.
@@ -47,6 +50,12 @@ func (d *DAG) Statuses() map[Meta]Status {
 	return d.statuses
 }
 
+// GatewayAPIStatuses returns the status of the Gateway API objects
+// associated with the computation of this DAG.
+func (d *DAG) GatewayAPIStatuses() map[Object]*GatewayAPIStatus {
+	return d.gatewayAPIStatuses
+}
+
 // PrefixRoute defines a Route that matches a path prefix.
 type PrefixRoute struct {
 
@@ -131,6 +140,10 @@ type VirtualHost struct {
 	// as defined by RFC 3986.
 	Name string
 
+	// Fleet, if set, restricts this virtual host to the
+	// Envoy nodes which are members of the named fleet.
+	Fleet string
+
 	routes map[string]Vertex
 }
 
@@ -142,7 +155,9 @@ func (v *VirtualHost) addRoute(route Vertex) {
 	case *PrefixRoute:
 		v.routes[r.Prefix] = r
 	case *RegexRoute:
-		v.routes[r.Regex] = r
+		// regex routes are keyed separately so a regex which is
+		// also a valid prefix does not replace the prefix route.
+		v.routes["regex:"+r.Regex] = r
 	default:
 		panic(fmt.Sprintf("unexpected route type: %T %#v", r, r))
 	}
@@ -262,6 +277,18 @@ type Service struct {
 
 	// ExternalName is an optional field referencing a dns entry for Service type "ExternalName"
 	ExternalName string
+
+	// DNSRefreshRate is how often Envoy resolves ExternalName.
+	// If zero, Envoy's default applies.
+	DNSRefreshRate time.Duration
+
+	// DNSLookupFamily is the IP family Envoy resolves ExternalName
+	// to, one of "auto", "v4", or "v6". If empty, defaults to "auto".
+	DNSLookupFamily string
+
+	// LocalityLBPolicy is the locality aware load balancing policy
+	// of this service. One of "", "ZoneAware", or "LocalityWeighted".
+	LocalityLBPolicy string
 }
 
 type servicemeta struct {
@@ -302,10 +329,32 @@ type Cluster struct {
 
 	// Cluster health check policy.
 	*HealthCheckPolicy
+
+	// Cluster outlier detection policy.
+	OutlierDetectionPolicy *OutlierDetectionPolicy
+
+	// Cluster circuit breaker policy. Its non zero limits take
+	// precedence over the limits of the Upstream service.
+	CircuitBreakerPolicy *CircuitBreakerPolicy
+
+	// Protocol is the layer 7 protocol of this cluster. If set, it
+	// takes precedence over the Protocol of the Upstream service.
+	// One of "", "h2", "h2c", "tls", or "auto".
+	Protocol string
+
+	// Cluster upstream HTTP protocol options.
+	*ProtocolOptions
+
+	// ClientCertificate is the certificate Envoy presents
+	// to the upstream service when connecting with TLS.
+	ClientCertificate *Secret
 }
 
 func (c Cluster) Visit(f func(Vertex)) {
 	f(c.Upstream)
+	if c.ClientCertificate != nil {
+		f(c.ClientCertificate)
+	}
 }
 
 // Secret represents a K8s Secret for TLS usage as a DAG Vertex. A Secret is
@@ -335,10 +384,81 @@ func (s *Secret) PrivateKey() []byte {
 
 // Cluster health check policy.
 type HealthCheckPolicy struct {
-	Path               string
-	Host               string
+	// Type is the type of health check, one of "http", "tcp", or "grpc".
+	Type string
+
+	// Path and Host are the path and host header of
+	// the request of an HTTP health check.
+	Path string
+	Host string
+
+	// ExpectedStatuses are the ranges of HTTP response statuses
+	// considered healthy. If empty, only a 200 response is healthy.
+	ExpectedStatuses []StatusRange
+
+	// Send and Receive are the hex encoded payloads
+	// sent and expected by a TCP health check.
+	Send    string
+	Receive []string
+
+	// GRPCServiceName is the name of the service whose
+	// health is checked by a gRPC health check.
+	GRPCServiceName string
+
 	Interval           time.Duration
 	Timeout            time.Duration
 	UnhealthyThreshold uint32
 	HealthyThreshold   uint32
 }
+
+// OutlierDetectionPolicy defines when the endpoints of a
+// cluster are ejected from its load balancing set.
+type OutlierDetectionPolicy struct {
+	// Consecutive5xxErrors is the number of consecutive 5xx
+	// responses after which an endpoint is ejected.
+	Consecutive5xxErrors uint32
+
+	// ConsecutiveGatewayErrors is the number of consecutive 502,
+	// 503, or 504 responses after which an endpoint is ejected.
+	ConsecutiveGatewayErrors uint32
+
+	Interval           time.Duration
+	BaseEjectionTime   time.Duration
+	MaxEjectionPercent uint32
+}
+
+// CircuitBreakerPolicy defines the circuit breaking limits of a cluster.
+// A limit of zero is not set.
+type CircuitBreakerPolicy struct {
+	MaxConnections     uint32
+	MaxPendingRequests uint32
+	MaxRequests        uint32
+	MaxRetries         uint32
+}
+
+// ProtocolOptions defines the HTTP/1 and HTTP/2 settings
+// of the connections to a cluster.
+type ProtocolOptions struct {
+	HTTP1 *HTTP1ProtocolOptions
+	HTTP2 *HTTP2ProtocolOptions
+}
+
+// HTTP1ProtocolOptions defines the HTTP/1 settings of a cluster.
+type HTTP1ProtocolOptions struct {
+	ProperCaseHeaders bool
+	EnableTrailers    bool
+}
+
+// HTTP2ProtocolOptions defines the HTTP/2 settings of a cluster.
+// A setting of zero is not set.
+type HTTP2ProtocolOptions struct {
+	MaxConcurrentStreams        uint32
+	InitialStreamWindowSize     uint32
+	InitialConnectionWindowSize uint32
+}
+
+// StatusRange is a range of HTTP response statuses,
+// from Start inclusive, to End exclusive.
+type StatusRange struct {
+	Start, End int64
+}
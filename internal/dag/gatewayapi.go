@@ -0,0 +1,672 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha1"
+)
+
+// gatewayAPIGroup is the API group of the Gateway API routes.
+const gatewayAPIGroup = "networking.x-k8s.io"
+
+// The default ports of Envoy's HTTP and HTTPS listeners.
+const (
+	defaultHTTPPort  = 8080
+	defaultHTTPSPort = 8443
+)
+
+// GatewayAPIStatus holds the status of a Gateway API object. Unlike
+// Status, which describes IngressRoute and HTTPProxy objects, it is
+// expressed as the conditions of the Gateway API.
+type GatewayAPIStatus struct {
+	Object Object
+
+	// Conditions of a GatewayClass or Gateway.
+	// The LastTransitionTime of each condition is not set.
+	Conditions []metav1.Condition
+
+	// Listeners of a Gateway.
+	Listeners []gatewayapi.ListenerStatus
+
+	// Gateways of Contour's GatewayClasses which select a route,
+	// and whether they admit it.
+	Gateways []gatewayapi.RouteGatewayStatus
+}
+
+// computeGatewayAPI translates the Gateways of Contour's GatewayClasses,
+// and the HTTPRoutes and TLSRoutes their listeners select, into virtual hosts.
+func (b *Builder) computeGatewayAPI() {
+	controller := b.Source.gatewayController()
+
+	for _, class := range b.Source.gatewayclasses {
+		if class.Spec.Controller != controller {
+			continue
+		}
+		b.gatewayAPIStatus(class).Conditions = []metav1.Condition{
+			gatewayAPICondition(class, string(gatewayapi.GatewayClassConditionStatusAdmitted), metav1.ConditionTrue, "Admitted", "GatewayClass is admitted"),
+		}
+	}
+
+	// The hosts configured so far belong to Ingresses, IngressRoutes,
+	// and HTTPProxies, so routes may not reconfigure them.
+	b.claimedHosts = make(map[string]hostClaim)
+	for host := range b.virtualhosts {
+		b.claimedHosts[host] = hostClaim{}
+	}
+	for host := range b.securevirtualhosts {
+		b.claimedHosts[host] = hostClaim{}
+	}
+
+	// Every route has a status, so routes which are no longer
+	// selected by one of Contour's Gateways are updated.
+	for _, route := range b.Source.httproutes {
+		b.gatewayAPIStatus(route)
+	}
+	for _, route := range b.Source.tlsroutes {
+		b.gatewayAPIStatus(route)
+	}
+
+	// Gateways are processed in order, so if two Gateways
+	// configure the same host the result is stable.
+	var gateways []*gatewayapi.Gateway
+	for _, gw := range b.Source.gateways {
+		class, ok := b.Source.gatewayclasses[gw.Spec.GatewayClassName]
+		if ok && class.Spec.Controller == controller {
+			gateways = append(gateways, gw)
+		}
+	}
+	sort.Slice(gateways, func(i, j int) bool {
+		return objectLess(gateways[i], gateways[j])
+	})
+	for _, gw := range gateways {
+		b.computeGateway(gw)
+	}
+}
+
+func (b *Builder) computeGateway(gw *gatewayapi.Gateway) {
+	st := b.gatewayAPIStatus(gw)
+
+	ready := gatewayAPICondition(gw, string(gatewayapi.GatewayConditionReady), metav1.ConditionTrue, "Ready", "Gateway is ready")
+	for _, l := range gw.Spec.Listeners {
+		ls := gatewayapi.ListenerStatus{
+			Port:     l.Port,
+			Protocol: l.Protocol,
+			Hostname: l.Hostname,
+		}
+		if cond := b.computeListener(gw, l); cond != nil {
+			ls.Conditions = []metav1.Condition{
+				*cond,
+				gatewayAPICondition(gw, string(gatewayapi.ListenerConditionReady), metav1.ConditionFalse, string(gatewayapi.ListenerReasonInvalid), cond.Message),
+			}
+			ready = gatewayAPICondition(gw, string(gatewayapi.GatewayConditionReady), metav1.ConditionFalse, string(gatewayapi.GatewayReasonListenersNotValid), "one or more listeners are not valid")
+		} else {
+			ls.Conditions = []metav1.Condition{
+				gatewayAPICondition(gw, string(gatewayapi.ListenerConditionReady), metav1.ConditionTrue, "Ready", "listener is ready"),
+			}
+		}
+		st.Listeners = append(st.Listeners, ls)
+	}
+
+	st.Conditions = []metav1.Condition{
+		gatewayAPICondition(gw, string(gatewayapi.GatewayConditionScheduled), metav1.ConditionTrue, "Scheduled", "Gateway is scheduled"),
+		ready,
+	}
+}
+
+// computeListener attaches the routes selected by the listener to the
+// virtual hosts of its protocol. If the listener is not valid,
+// computeListener returns the condition describing why.
+func (b *Builder) computeListener(gw *gatewayapi.Gateway, l gatewayapi.Listener) *metav1.Condition {
+	host := "*"
+	if l.Hostname != nil && *l.Hostname != "" {
+		host = string(*l.Hostname)
+	}
+
+	invalidRoutesRef := func(kind string) *metav1.Condition {
+		cond := gatewayAPICondition(gw, string(gatewayapi.ListenerConditionResolvedRefs), metav1.ConditionFalse, string(gatewayapi.ListenerReasonInvalidRoutesRef),
+			fmt.Sprintf("%s listeners must select routes of kind %q", l.Protocol, kind))
+		return &cond
+	}
+	invalidCertificateRef := func(err error) *metav1.Condition {
+		cond := gatewayAPICondition(gw, string(gatewayapi.ListenerConditionResolvedRefs), metav1.ConditionFalse, string(gatewayapi.ListenerReasonInvalidCertificateRef), err.Error())
+		return &cond
+	}
+
+	// every listener of a protocol is served by the same Envoy listener,
+	// so must use its port.
+	if port, ok := b.listenerPort(l.Protocol); ok && int(l.Port) != port {
+		cond := gatewayAPICondition(gw, string(gatewayapi.ListenerConditionDetached), metav1.ConditionTrue, string(gatewayapi.ListenerReasonPortUnavailable),
+			fmt.Sprintf("%s listeners must use port %d", l.Protocol, port))
+		return &cond
+	}
+
+	switch l.Protocol {
+	case gatewayapi.HTTPProtocolType, gatewayapi.HTTPSProtocolType:
+		if !selectsRouteKind(l.Routes, "HTTPRoute") {
+			return invalidRoutesRef("HTTPRoute")
+		}
+		var sec *Secret
+		if l.Protocol == gatewayapi.HTTPSProtocolType {
+			var err error
+			if sec, err = b.listenerSecret(gw, l); err != nil {
+				return invalidCertificateRef(err)
+			}
+		}
+		for _, route := range b.selectedHTTPRoutes(gw, l) {
+			b.setRouteAdmitted(route, gw, b.computeHTTPRoute(gw, route, host, sec))
+		}
+	case gatewayapi.TLSProtocolType:
+		if !selectsRouteKind(l.Routes, "TLSRoute") {
+			return invalidRoutesRef("TLSRoute")
+		}
+		var sec *Secret
+		if l.TLS == nil || l.TLS.Mode == nil || *l.TLS.Mode == gatewayapi.TLSModeTerminate {
+			var err error
+			if sec, err = b.listenerSecret(gw, l); err != nil {
+				return invalidCertificateRef(err)
+			}
+		}
+		for _, route := range b.selectedTLSRoutes(gw, l) {
+			b.setRouteAdmitted(route, gw, b.computeTLSRoute(gw, route, host, sec))
+		}
+	default:
+		cond := gatewayAPICondition(gw, string(gatewayapi.ListenerConditionDetached), metav1.ConditionTrue, string(gatewayapi.ListenerReasonUnsupportedProtocol),
+			fmt.Sprintf("protocol %q is not supported", l.Protocol))
+		return &cond
+	}
+	return nil
+}
+
+// listenerPort returns the port of the Envoy listener which
+// serves listeners of protocol, and false if the protocol is
+// not supported.
+func (b *Builder) listenerPort(protocol gatewayapi.ProtocolType) (int, bool) {
+	switch protocol {
+	case gatewayapi.HTTPProtocolType:
+		if b.HTTPPort != 0 {
+			return b.HTTPPort, true
+		}
+		return defaultHTTPPort, true
+	case gatewayapi.HTTPSProtocolType, gatewayapi.TLSProtocolType:
+		if b.HTTPSPort != 0 {
+			return b.HTTPSPort, true
+		}
+		return defaultHTTPSPort, true
+	default:
+		return 0, false
+	}
+}
+
+// listenerSecret returns the Secret referenced by the TLS
+// configuration of the listener.
+func (b *Builder) listenerSecret(gw *gatewayapi.Gateway, l gatewayapi.Listener) (*Secret, error) {
+	if l.TLS == nil || l.TLS.CertificateRef == nil {
+		return nil, fmt.Errorf("%s listeners must specify a TLS certificateRef", l.Protocol)
+	}
+	ref := l.TLS.CertificateRef
+	if ref.Kind != "Secret" || (ref.Group != "" && ref.Group != "core") {
+		return nil, fmt.Errorf("certificateRef must reference a Secret, not %s %s", ref.Group, ref.Kind)
+	}
+	sec := b.lookupSecret(Meta{name: ref.Name, namespace: gw.Namespace}, validSecret)
+	if sec == nil {
+		return nil, fmt.Errorf("Secret %q not found or is malformed", ref.Name)
+	}
+	return sec, nil
+}
+
+// computeHTTPRoute adds the routes of the HTTPRoute to the virtual hosts
+// of its hostnames the listener accepts. If sec is not nil the routes are
+// added to secure virtual hosts which present sec.
+func (b *Builder) computeHTTPRoute(gw *gatewayapi.Gateway, route *gatewayapi.HTTPRoute, listenerHost string, sec *Secret) error {
+	hosts := routeHostnames(listenerHost, route.Spec.Hostnames)
+	if sec != nil {
+		hosts = withoutAnyHost(hosts)
+	}
+	if len(hosts) == 0 {
+		return errors.New("no hostname of the route matches the listener hostname")
+	}
+	claim := hostClaim{gateway: toMeta(gw), kind: "HTTPRoute", route: toMeta(route)}
+	if err := b.checkHostsUnclaimed(hosts, claim, sec != nil, sec); err != nil {
+		return err
+	}
+
+	var routes []Vertex
+	for _, rule := range route.Spec.Rules {
+		if len(rule.Filters) > 0 {
+			return errors.New("filters are not supported")
+		}
+		var clusters []*Cluster
+		for _, fwd := range rule.ForwardTo {
+			if len(fwd.Filters) > 0 {
+				return errors.New("filters are not supported")
+			}
+			c, err := b.forwardToCluster(route.Namespace, fwd.ServiceName, fwd.BackendRef, fwd.Port, fwd.Weight)
+			if err != nil {
+				return err
+			}
+			clusters = append(clusters, c)
+		}
+		if len(clusters) == 0 {
+			return errors.New("each rule must forward to at least one service")
+		}
+
+		matches := rule.Matches
+		if len(matches) == 0 {
+			// a rule without matches matches all requests.
+			matches = []gatewayapi.HTTPRouteMatch{{}}
+		}
+		for _, match := range matches {
+			if match.Headers != nil || match.QueryParams != nil || match.ExtensionRef != nil {
+				return errors.New("only path matches are supported")
+			}
+			r, err := pathMatchRoute(match.Path, Route{Clusters: clusters})
+			if err != nil {
+				return err
+			}
+			routes = append(routes, r)
+		}
+	}
+
+	for _, host := range hosts {
+		b.claimedHosts[host] = claim
+		var vhost *VirtualHost
+		if sec != nil {
+			svhost := b.lookupSecureVirtualHost(host)
+			svhost.Secret = sec
+			svhost.MinProtoVersion = MinProtoVersion("")
+			vhost = &svhost.VirtualHost
+		} else {
+			vhost = b.lookupVirtualHost(host)
+		}
+		for _, r := range routes {
+			vhost.addRoute(r)
+		}
+	}
+	return nil
+}
+
+// computeTLSRoute proxies the connections of each SNI of the TLSRoute the
+// listener accepts to the services of its rule. If sec is not nil the
+// connections are terminated with sec, otherwise they are passed through.
+func (b *Builder) computeTLSRoute(gw *gatewayapi.Gateway, route *gatewayapi.TLSRoute, listenerHost string, sec *Secret) error {
+	proxies := make(map[string]*TCPProxy)
+	for _, rule := range route.Spec.Rules {
+		var clusters []*Cluster
+		for _, fwd := range rule.ForwardTo {
+			c, err := b.forwardToCluster(route.Namespace, fwd.ServiceName, fwd.BackendRef, fwd.Port, fwd.Weight)
+			if err != nil {
+				return err
+			}
+			clusters = append(clusters, c)
+		}
+		if len(clusters) == 0 {
+			return errors.New("each rule must forward to at least one service")
+		}
+
+		var snis []gatewayapi.Hostname
+		for _, match := range rule.Matches {
+			if match.ExtensionRef != nil {
+				return errors.New("only SNI matches are supported")
+			}
+			snis = append(snis, match.SNIs...)
+		}
+		hosts := withoutAnyHost(routeHostnames(listenerHost, snis))
+		if len(hosts) == 0 {
+			return errors.New("no SNI of the route matches the listener hostname")
+		}
+		for _, host := range hosts {
+			proxies[host] = &TCPProxy{
+				Clusters: clusters,
+			}
+		}
+	}
+
+	var hosts []string
+	for host := range proxies {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	claim := hostClaim{gateway: toMeta(gw), kind: "TLSRoute", route: toMeta(route)}
+	if err := b.checkHostsUnclaimed(hosts, claim, true, sec); err != nil {
+		return err
+	}
+
+	for host, proxy := range proxies {
+		b.claimedHosts[host] = claim
+		svhost := b.lookupSecureVirtualHost(host)
+		svhost.Secret = sec
+		if sec != nil {
+			svhost.MinProtoVersion = MinProtoVersion("")
+		}
+		svhost.TCPProxy = proxy
+	}
+	return nil
+}
+
+// hostClaim identifies the route, and the Gateway it is attached to,
+// which configures a host. The zero value is the claim of an Ingress,
+// IngressRoute, or HTTPProxy.
+type hostClaim struct {
+	gateway Meta
+	kind    string
+	route   Meta
+}
+
+func (c hostClaim) String() string {
+	if c.kind == "" {
+		return "an Ingress, IngressRoute, or HTTPProxy"
+	}
+	return fmt.Sprintf("%s %s/%s of Gateway %s/%s", c.kind, c.route.namespace, c.route.name, c.gateway.namespace, c.gateway.name)
+}
+
+// checkHostsUnclaimed returns an error if any of hosts is configured
+// by another resource than claim. Each host has at most one owner, so
+// a route may not override the certificate, or replace the routes, of
+// a host configured by another resource, just as only one HTTPProxy
+// may configure an FQDN. If secure, each host must also present sec,
+// so a route attached to several listeners may not serve a host with
+// different certificates.
+func (b *Builder) checkHostsUnclaimed(hosts []string, claim hostClaim, secure bool, sec *Secret) error {
+	for _, host := range hosts {
+		owner, ok := b.claimedHosts[host]
+		if !ok {
+			continue
+		}
+		if owner != claim {
+			return fmt.Errorf("hostname %q is already configured by %s", host, owner)
+		}
+		if svhost, ok := b.securevirtualhosts[host]; secure && ok && svhost.Secret != sec {
+			return fmt.Errorf("hostname %q is already served with a different certificate", host)
+		}
+	}
+	return nil
+}
+
+// forwardToCluster returns the Cluster for a forwardTo of a route in namespace.
+func (b *Builder) forwardToCluster(namespace string, serviceName *string, backendRef *gatewayapi.LocalObjectReference, port *gatewayapi.PortNumber, weight *int32) (*Cluster, error) {
+	if backendRef != nil || serviceName == nil {
+		return nil, errors.New("forwardTo must reference a service by serviceName")
+	}
+	if port == nil {
+		return nil, fmt.Errorf("forwardTo service %q must specify a port", *serviceName)
+	}
+	s := b.lookupService(Meta{name: *serviceName, namespace: namespace}, intstr.FromInt(int(*port)))
+	if s == nil {
+		return nil, fmt.Errorf("service \"%s/%s\" not found", namespace, *serviceName)
+	}
+
+	// the weight of a forwardTo defaults to 1.
+	w := uint32(1)
+	if weight != nil {
+		w = uint32(*weight)
+	}
+	return &Cluster{
+		Upstream: s,
+		Weight:   w,
+	}, nil
+}
+
+// pathMatchRoute returns the route matching requests with path.
+func pathMatchRoute(path *gatewayapi.HTTPPathMatch, r Route) (Vertex, error) {
+	pathType := gatewayapi.PathMatchPrefix
+	value := "/"
+	if path != nil {
+		if path.Type != nil {
+			pathType = *path.Type
+		}
+		if path.Value != nil {
+			value = *path.Value
+		}
+	}
+
+	switch pathType {
+	case gatewayapi.PathMatchPrefix, gatewayapi.PathMatchImplementationSpecific:
+		return &PrefixRoute{
+			Prefix: value,
+			Route:  r,
+		}, nil
+	case gatewayapi.PathMatchExact:
+		return &RegexRoute{
+			Regex: regexp.QuoteMeta(value),
+			Route: r,
+		}, nil
+	case gatewayapi.PathMatchRegularExpression:
+		if _, err := regexp.Compile(value); err != nil {
+			return nil, fmt.Errorf("path %q is not a valid regular expression", value)
+		}
+		return &RegexRoute{
+			Regex: value,
+			Route: r,
+		}, nil
+	default:
+		return nil, fmt.Errorf("path match type %q is not supported", pathType)
+	}
+}
+
+// selectedHTTPRoutes returns the HTTPRoutes selected by the listener
+// which allow the Gateway, in order.
+func (b *Builder) selectedHTTPRoutes(gw *gatewayapi.Gateway, l gatewayapi.Listener) []*gatewayapi.HTTPRoute {
+	var routes []*gatewayapi.HTTPRoute
+	for _, route := range b.Source.httproutes {
+		if b.routeSelected(gw, l, route.ObjectMeta, route.Spec.Gateways) {
+			routes = append(routes, route)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return objectLess(routes[i], routes[j])
+	})
+	return routes
+}
+
+// selectedTLSRoutes returns the TLSRoutes selected by the listener
+// which allow the Gateway, in order.
+func (b *Builder) selectedTLSRoutes(gw *gatewayapi.Gateway, l gatewayapi.Listener) []*gatewayapi.TLSRoute {
+	var routes []*gatewayapi.TLSRoute
+	for _, route := range b.Source.tlsroutes {
+		if b.routeSelected(gw, l, route.ObjectMeta, route.Spec.Gateways) {
+			routes = append(routes, route)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return objectLess(routes[i], routes[j])
+	})
+	return routes
+}
+
+// routeSelected returns true if the listener selects the route, and the
+// route allows the Gateway.
+func (b *Builder) routeSelected(gw *gatewayapi.Gateway, l gatewayapi.Listener, route metav1.ObjectMeta, gateways *gatewayapi.RouteGateways) bool {
+	if !b.listenerSelectsNamespace(gw, l.Routes.Namespaces, route.Namespace) {
+		return false
+	}
+	if l.Routes.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(l.Routes.Selector)
+		if err != nil || !selector.Matches(labels.Set(route.Labels)) {
+			return false
+		}
+	}
+	return routeAllowsGateway(gateways, gw, route.Namespace)
+}
+
+// listenerSelectsNamespace returns true if a listener of the Gateway
+// selects routes in namespace. By default, listeners select routes in
+// the namespace of their Gateway.
+func (b *Builder) listenerSelectsNamespace(gw *gatewayapi.Gateway, namespaces *gatewayapi.RouteNamespaces, namespace string) bool {
+	from := gatewayapi.RouteSelectSame
+	if namespaces != nil && namespaces.From != nil {
+		from = *namespaces.From
+	}
+
+	switch from {
+	case gatewayapi.RouteSelectAll:
+		return true
+	case gatewayapi.RouteSelectSelector:
+		if namespaces.Selector == nil {
+			return false
+		}
+		selector, err := metav1.LabelSelectorAsSelector(namespaces.Selector)
+		if err != nil {
+			return false
+		}
+		ns, ok := b.Source.namespaces[namespace]
+		return ok && selector.Matches(labels.Set(ns.Labels))
+	default:
+		return namespace == gw.Namespace
+	}
+}
+
+// routeAllowsGateway returns true if a route in namespace allows the
+// Gateway. By default, routes allow Gateways in their namespace.
+func routeAllowsGateway(gateways *gatewayapi.RouteGateways, gw *gatewayapi.Gateway, namespace string) bool {
+	allow := gatewayapi.GatewayAllowSameNamespace
+	if gateways != nil && gateways.Allow != nil {
+		allow = *gateways.Allow
+	}
+
+	switch allow {
+	case gatewayapi.GatewayAllowAll:
+		return true
+	case gatewayapi.GatewayAllowFromList:
+		for _, ref := range gateways.GatewayRefs {
+			if ref.Name == gw.Name && ref.Namespace == gw.Namespace {
+				return true
+			}
+		}
+		return false
+	default:
+		return namespace == gw.Namespace
+	}
+}
+
+// selectsRouteKind returns true if the selector selects routes of kind.
+func selectsRouteKind(sel gatewayapi.RouteBindingSelector, kind string) bool {
+	if sel.Group != nil && *sel.Group != gatewayAPIGroup {
+		return false
+	}
+	return sel.Kind == kind
+}
+
+// routeHostnames returns the hostnames of a route which the listener
+// hostname accepts. A route without hostnames takes the listener hostname.
+func routeHostnames(listener string, hostnames []gatewayapi.Hostname) []string {
+	if len(hostnames) == 0 {
+		return []string{listener}
+	}
+
+	var hosts []string
+	for _, hostname := range hostnames {
+		host := string(hostname)
+		switch {
+		case listener == "*", host == listener, wildcardMatches(listener, host):
+			hosts = append(hosts, host)
+		case wildcardMatches(host, listener):
+			hosts = append(hosts, listener)
+		}
+	}
+	return hosts
+}
+
+// wildcardMatches returns true if wildcard, of the form *.example.com,
+// matches the single leftmost label of host.
+func wildcardMatches(wildcard, host string) bool {
+	if !strings.HasPrefix(wildcard, "*.") {
+		return false
+	}
+	label := strings.TrimSuffix(host, wildcard[1:])
+	return label != host && label != "" && !strings.Contains(label, ".")
+}
+
+// withoutAnyHost returns hosts without the wildcard host *,
+// which cannot be matched by SNI.
+func withoutAnyHost(hosts []string) []string {
+	var filtered []string
+	for _, host := range hosts {
+		if host != "*" {
+			filtered = append(filtered, host)
+		}
+	}
+	return filtered
+}
+
+// setRouteAdmitted records whether the Gateway admits the route. A route
+// selected by several listeners of a Gateway is admitted if any of them
+// admit it.
+func (b *Builder) setRouteAdmitted(route Object, gw *gatewayapi.Gateway, err error) {
+	cond := gatewayAPICondition(route, string(gatewayapi.ConditionRouteAdmitted), metav1.ConditionTrue, "Admitted", "route is admitted")
+	if err != nil {
+		cond = gatewayAPICondition(route, string(gatewayapi.ConditionRouteAdmitted), metav1.ConditionFalse, "Invalid", err.Error())
+	}
+
+	st := b.gatewayAPIStatus(route)
+	for i := range st.Gateways {
+		ref := st.Gateways[i].GatewayRef
+		if ref.Name != gw.Name || ref.Namespace != gw.Namespace {
+			continue
+		}
+		if st.Gateways[i].Conditions[0].Status != metav1.ConditionTrue {
+			st.Gateways[i].Conditions = []metav1.Condition{cond}
+		}
+		return
+	}
+
+	controller := b.Source.gatewayController()
+	st.Gateways = append(st.Gateways, gatewayapi.RouteGatewayStatus{
+		GatewayRef: gatewayapi.RouteStatusGatewayReference{
+			Name:       gw.Name,
+			Namespace:  gw.Namespace,
+			Controller: &controller,
+		},
+		Conditions: []metav1.Condition{cond},
+	})
+}
+
+// gatewayAPIStatus returns the status of the Gateway API object.
+func (b *Builder) gatewayAPIStatus(obj Object) *GatewayAPIStatus {
+	st, ok := b.gatewayAPIStatuses[obj]
+	if !ok {
+		st = &GatewayAPIStatus{
+			Object: obj,
+		}
+		b.gatewayAPIStatuses[obj] = st
+	}
+	return st
+}
+
+func gatewayAPICondition(obj Object, conditionType string, status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: obj.GetObjectMeta().GetGeneration(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// objectLess orders objects by namespace, then name.
+func objectLess(a, b Object) bool {
+	ma, mb := toMeta(a), toMeta(b)
+	if ma.namespace != mb.namespace {
+		return ma.namespace < mb.namespace
+	}
+	return ma.name < mb.name
+}
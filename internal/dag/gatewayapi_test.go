@@ -0,0 +1,999 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha1"
+)
+
+func TestGatewayAPI(t *testing.T) {
+	class := &gatewayapi.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "contour",
+		},
+		Spec: gatewayapi.GatewayClassSpec{
+			Controller: "projectcontour.io/contour",
+		},
+	}
+
+	otherClass := &gatewayapi.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "other",
+		},
+		Spec: gatewayapi.GatewayClassSpec{
+			Controller: "example.com/other",
+		},
+	}
+
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	s2 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "teama",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	sec1 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata("certificate", "key"),
+	}
+
+	teama := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "teama",
+			Labels: map[string]string{
+				"team": "a",
+			},
+		},
+	}
+
+	gateway := func(class string, listeners ...gatewayapi.Listener) *gatewayapi.Gateway {
+		return &gatewayapi.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "contour",
+				Namespace: "default",
+			},
+			Spec: gatewayapi.GatewaySpec{
+				GatewayClassName: class,
+				Listeners:        listeners,
+			},
+		}
+	}
+
+	httpListener := gatewayapi.Listener{
+		Port:     8080,
+		Protocol: gatewayapi.HTTPProtocolType,
+		Routes: gatewayapi.RouteBindingSelector{
+			Kind: "HTTPRoute",
+		},
+	}
+
+	httpsListener := gatewayapi.Listener{
+		Hostname: hostname("kuard.example.com"),
+		Port:     8443,
+		Protocol: gatewayapi.HTTPSProtocolType,
+		TLS: &gatewayapi.GatewayTLSConfig{
+			CertificateRef: &gatewayapi.LocalObjectReference{
+				Group: "core",
+				Kind:  "Secret",
+				Name:  "secret",
+			},
+		},
+		Routes: gatewayapi.RouteBindingSelector{
+			Kind: "HTTPRoute",
+		},
+	}
+
+	sec2 := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secret2",
+			Namespace: "default",
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata("certificate2", "key2"),
+	}
+
+	httpsListener2 := *httpsListener.DeepCopy()
+	httpsListener2.TLS.CertificateRef.Name = "secret2"
+
+	passthrough := gatewayapi.TLSModePassthrough
+	tlsListener := gatewayapi.Listener{
+		Port:     8443,
+		Protocol: gatewayapi.TLSProtocolType,
+		TLS: &gatewayapi.GatewayTLSConfig{
+			Mode: &passthrough,
+		},
+		Routes: gatewayapi.RouteBindingSelector{
+			Kind: "TLSRoute",
+		},
+	}
+
+	fromSelector := gatewayapi.RouteSelectSelector
+	selectorListener := gatewayapi.Listener{
+		Port:     8080,
+		Protocol: gatewayapi.HTTPProtocolType,
+		Routes: gatewayapi.RouteBindingSelector{
+			Kind: "HTTPRoute",
+			Namespaces: &gatewayapi.RouteNamespaces{
+				From: &fromSelector,
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"team": "a",
+					},
+				},
+			},
+		},
+	}
+
+	fromAll := gatewayapi.RouteSelectAll
+	allNamespacesListener := gatewayapi.Listener{
+		Port:     8080,
+		Protocol: gatewayapi.HTTPProtocolType,
+		Routes: gatewayapi.RouteBindingSelector{
+			Kind: "HTTPRoute",
+			Namespaces: &gatewayapi.RouteNamespaces{
+				From: &fromAll,
+			},
+		},
+	}
+
+	httproute := func(namespace string, hostnames []gatewayapi.Hostname, gateways *gatewayapi.RouteGateways, rules ...gatewayapi.HTTPRouteRule) *gatewayapi.HTTPRoute {
+		return &gatewayapi.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kuard",
+				Namespace: namespace,
+			},
+			Spec: gatewayapi.HTTPRouteSpec{
+				Gateways:  gateways,
+				Hostnames: hostnames,
+				Rules:     rules,
+			},
+		}
+	}
+
+	tlsroute := &gatewayapi.TLSRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: gatewayapi.TLSRouteSpec{
+			Rules: []gatewayapi.TLSRouteRule{{
+				Matches: []gatewayapi.TLSRouteMatch{{
+					SNIs: []gatewayapi.Hostname{"kuard.example.com"},
+				}},
+				ForwardTo: []gatewayapi.RouteForwardTo{{
+					ServiceName: stringptr("kuard"),
+					Port:        portnumber(8080),
+				}},
+			}},
+		},
+	}
+
+	// proxy is an HTTPProxy for the host of the routes.
+	proxy := func(tls *projcontour.TLS) *projcontour.HTTPProxy {
+		return &projcontour.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kuard",
+				Namespace: "default",
+			},
+			Spec: projcontour.HTTPProxySpec{
+				VirtualHost: &projcontour.VirtualHost{
+					Fqdn: "kuard.example.com",
+					TLS:  tls,
+				},
+				Routes: []projcontour.Route{{
+					Services: []projcontour.Service{{
+						Name: "kuard",
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+
+	allowAll := gatewayapi.GatewayAllowAll
+	exact := gatewayapi.PathMatchExact
+
+	tests := map[string]struct {
+		objs []interface{}
+		want []Vertex
+	}{
+		"http listener with httproute": {
+			objs: []interface{}{
+				class,
+				gateway("contour", httpListener),
+				httproute("default", nil, nil, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("*", routeCluster("/", weightedCluster(s1, 1))),
+					),
+				},
+			),
+		},
+		"gateway of another controller": {
+			objs: []interface{}{
+				otherClass,
+				gateway("other", httpListener),
+				httproute("default", nil, nil, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+			},
+			want: listeners(),
+		},
+		"httproute with hostnames and path matches": {
+			objs: []interface{}{
+				class,
+				gateway("contour", httpListener),
+				httproute("default", []gatewayapi.Hostname{"kuard.example.com"}, nil, gatewayapi.HTTPRouteRule{
+					Matches: []gatewayapi.HTTPRouteMatch{{
+						Path: &gatewayapi.HTTPPathMatch{
+							Value: stringptr("/prefix"),
+						},
+					}, {
+						Path: &gatewayapi.HTTPPathMatch{
+							Type:  &exact,
+							Value: stringptr("/exact"),
+						},
+					}},
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("kuard.example.com",
+							routeCluster("/prefix", weightedCluster(s1, 1)),
+							&RegexRoute{
+								Regex: "/exact",
+								Route: Route{
+									Clusters: []*Cluster{weightedCluster(s1, 1)},
+								},
+							},
+						),
+					),
+				},
+			),
+		},
+		"httproute with missing service": {
+			objs: []interface{}{
+				class,
+				gateway("contour", httpListener),
+				httproute("default", nil, nil, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+			},
+			want: listeners(),
+		},
+		"httproute in another namespace is not selected": {
+			objs: []interface{}{
+				class,
+				gateway("contour", httpListener),
+				httproute("teama", nil, &gatewayapi.RouteGateways{Allow: &allowAll}, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s2,
+			},
+			want: listeners(),
+		},
+		"httproute in a namespace selected by label": {
+			objs: []interface{}{
+				class,
+				teama,
+				gateway("contour", selectorListener),
+				httproute("teama", nil, &gatewayapi.RouteGateways{Allow: &allowAll}, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s2,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("*", routeCluster("/", weightedCluster(s2, 1))),
+					),
+				},
+			),
+		},
+		"httproute in a namespace selected by label which does not allow the gateway": {
+			objs: []interface{}{
+				class,
+				teama,
+				gateway("contour", selectorListener),
+				httproute("teama", nil, nil, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s2,
+			},
+			want: listeners(),
+		},
+		"https listener with httproute": {
+			objs: []interface{}{
+				class,
+				gateway("contour", httpsListener),
+				httproute("default", nil, nil, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+				sec1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						securevirtualhost("kuard.example.com", sec1, routeCluster("/", weightedCluster(s1, 1))),
+					),
+				},
+			),
+		},
+		"https listener with missing secret": {
+			objs: []interface{}{
+				class,
+				gateway("contour", httpsListener),
+				httproute("default", nil, nil, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+			},
+			want: listeners(),
+		},
+		"tls passthrough listener with tlsroute": {
+			objs: []interface{}{
+				class,
+				gateway("contour", tlsListener),
+				tlsroute,
+				s1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name: "kuard.example.com",
+							},
+							TCPProxy: &TCPProxy{
+								Clusters: []*Cluster{weightedCluster(s1, 1)},
+							},
+						},
+					),
+				},
+			),
+		},
+		"httproute for a host of an httpproxy": {
+			objs: []interface{}{
+				class,
+				gateway("contour", httpListener),
+				httproute("default", []gatewayapi.Hostname{"kuard.example.com"}, nil, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				proxy(nil),
+				s1,
+				sec1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("kuard.example.com", prefixroute("/", service(s1))),
+					),
+				},
+			),
+		},
+		"tls passthrough route for a host of an httpproxy": {
+			objs: []interface{}{
+				class,
+				gateway("contour", tlsListener),
+				tlsroute,
+				proxy(&projcontour.TLS{SecretName: sec1.Name}),
+				s1,
+				sec1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("kuard.example.com", routeUpgrade("/", service(s1))),
+					),
+				},
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						securevirtualhost("kuard.example.com", sec1, routeUpgrade("/", service(s1))),
+					),
+				},
+			),
+		},
+		"httproutes in two namespaces for the same host": {
+			objs: []interface{}{
+				class,
+				gateway("contour", allNamespacesListener),
+				httproute("default", []gatewayapi.Hostname{"kuard.example.com"}, nil, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				httproute("teama", []gatewayapi.Hostname{"kuard.example.com"}, &gatewayapi.RouteGateways{Allow: &allowAll}, gatewayapi.HTTPRouteRule{
+					Matches: []gatewayapi.HTTPRouteMatch{{
+						Path: &gatewayapi.HTTPPathMatch{
+							Type:  &exact,
+							Value: stringptr("/admin"),
+						},
+					}},
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+				s2,
+			},
+			want: listeners(
+				&Listener{
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("kuard.example.com", routeCluster("/", weightedCluster(s1, 1))),
+					),
+				},
+			),
+		},
+		"https listeners with different certificates for a host": {
+			objs: []interface{}{
+				class,
+				gateway("contour", httpsListener, httpsListener2),
+				httproute("default", nil, nil, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+				sec1,
+				sec2,
+			},
+			want: listeners(
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						securevirtualhost("kuard.example.com", sec1, routeCluster("/", weightedCluster(s1, 1))),
+					),
+				},
+			),
+		},
+		"tls passthrough route for a host of an httproute": {
+			objs: []interface{}{
+				class,
+				gateway("contour", httpsListener, tlsListener),
+				httproute("default", nil, nil, gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				tlsroute,
+				s1,
+				sec1,
+			},
+			want: listeners(
+				&Listener{
+					Port: 443,
+					VirtualHosts: virtualhosts(
+						securevirtualhost("kuard.example.com", sec1, routeCluster("/", weightedCluster(s1, 1))),
+					),
+				},
+			),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: testLogger(t),
+				},
+			}
+			for _, o := range tc.objs {
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			got := make(map[int]*Listener)
+			dag.Visit(listenerMap(got).Visit)
+
+			want := make(map[int]*Listener)
+			for _, v := range tc.want {
+				if l, ok := v.(*Listener); ok {
+					want[l.Port] = l
+				}
+			}
+
+			opts := []cmp.Option{
+				cmp.AllowUnexported(VirtualHost{}),
+			}
+			if diff := cmp.Diff(want, got, opts...); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestGatewayAPIStatus(t *testing.T) {
+	class := &gatewayapi.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "contour",
+		},
+		Spec: gatewayapi.GatewayClassSpec{
+			Controller: "projectcontour.io/contour",
+		},
+	}
+
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	namedGateway := func(name string, listeners ...gatewayapi.Listener) *gatewayapi.Gateway {
+		return &gatewayapi.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: gatewayapi.GatewaySpec{
+				GatewayClassName: "contour",
+				Listeners:        listeners,
+			},
+		}
+	}
+
+	gateway := func(listeners ...gatewayapi.Listener) *gatewayapi.Gateway {
+		return namedGateway("contour", listeners...)
+	}
+
+	httpListener := gatewayapi.Listener{
+		Port:     8080,
+		Protocol: gatewayapi.HTTPProtocolType,
+		Routes: gatewayapi.RouteBindingSelector{
+			Kind: "HTTPRoute",
+		},
+	}
+
+	secret := func(name string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Type: v1.SecretTypeTLS,
+			Data: secretdata("certificate", "key"),
+		}
+	}
+
+	httpsListener := func(secret string) gatewayapi.Listener {
+		return gatewayapi.Listener{
+			Hostname: hostname("kuard.example.com"),
+			Port:     8443,
+			Protocol: gatewayapi.HTTPSProtocolType,
+			TLS: &gatewayapi.GatewayTLSConfig{
+				CertificateRef: &gatewayapi.LocalObjectReference{
+					Kind: "Secret",
+					Name: secret,
+				},
+			},
+			Routes: gatewayapi.RouteBindingSelector{
+				Kind: "HTTPRoute",
+			},
+		}
+	}
+
+	httproute := func(name string, rule gatewayapi.HTTPRouteRule) *gatewayapi.HTTPRoute {
+		return &gatewayapi.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: gatewayapi.HTTPRouteSpec{
+				Rules: []gatewayapi.HTTPRouteRule{rule},
+			},
+		}
+	}
+
+	// conditions summarises the conditions of each Gateway API
+	// object as kind/name: type=status/reason.
+	conditions := func(statuses map[Object]*GatewayAPIStatus) map[string][]string {
+		got := make(map[string][]string)
+		add := func(key string, conds []metav1.Condition) {
+			for _, c := range conds {
+				got[key] = append(got[key], fmt.Sprintf("%s=%s/%s", c.Type, c.Status, c.Reason))
+			}
+		}
+		for _, st := range statuses {
+			key := fmt.Sprintf("%T/%s", st.Object, st.Object.GetObjectMeta().GetName())
+			add(key, st.Conditions)
+			for _, l := range st.Listeners {
+				add(fmt.Sprintf("%s/%s", key, l.Protocol), l.Conditions)
+			}
+			for _, g := range st.Gateways {
+				add(fmt.Sprintf("%s/%s", key, g.GatewayRef.Name), g.Conditions)
+			}
+			if _, ok := got[key]; !ok && len(st.Listeners) == 0 && len(st.Gateways) == 0 {
+				got[key] = nil
+			}
+		}
+		return got
+	}
+
+	tests := map[string]struct {
+		objs     []interface{}
+		httpPort int
+		want     map[string][]string
+	}{
+		"admitted route": {
+			objs: []interface{}{
+				class,
+				gateway(httpListener),
+				httproute("kuard", gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+			},
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour":    {"Admitted=True/Admitted"},
+				"*v1alpha1.Gateway/contour":         {"Scheduled=True/Scheduled", "Ready=True/Ready"},
+				"*v1alpha1.Gateway/contour/HTTP":    {"Ready=True/Ready"},
+				"*v1alpha1.HTTPRoute/kuard/contour": {"Admitted=True/Admitted"},
+			},
+		},
+		"route with missing service": {
+			objs: []interface{}{
+				class,
+				gateway(httpListener),
+				httproute("kuard", gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("missing", 8080),
+				}),
+			},
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour":    {"Admitted=True/Admitted"},
+				"*v1alpha1.Gateway/contour":         {"Scheduled=True/Scheduled", "Ready=True/Ready"},
+				"*v1alpha1.Gateway/contour/HTTP":    {"Ready=True/Ready"},
+				"*v1alpha1.HTTPRoute/kuard/contour": {"Admitted=False/Invalid"},
+			},
+		},
+		"route with unsupported header match": {
+			objs: []interface{}{
+				class,
+				gateway(httpListener),
+				httproute("kuard", gatewayapi.HTTPRouteRule{
+					Matches: []gatewayapi.HTTPRouteMatch{{
+						Headers: &gatewayapi.HTTPHeaderMatch{
+							Values: map[string]string{"x-team": "a"},
+						},
+					}},
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+			},
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour":    {"Admitted=True/Admitted"},
+				"*v1alpha1.Gateway/contour":         {"Scheduled=True/Scheduled", "Ready=True/Ready"},
+				"*v1alpha1.Gateway/contour/HTTP":    {"Ready=True/Ready"},
+				"*v1alpha1.HTTPRoute/kuard/contour": {"Admitted=False/Invalid"},
+			},
+		},
+		"route not selected by any gateway": {
+			objs: []interface{}{
+				class,
+				httproute("kuard", gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+			},
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour": {"Admitted=True/Admitted"},
+				"*v1alpha1.HTTPRoute/kuard":      nil,
+			},
+		},
+		"unsupported listener protocol": {
+			objs: []interface{}{
+				class,
+				gateway(gatewayapi.Listener{
+					Port:     53,
+					Protocol: gatewayapi.UDPProtocolType,
+					Routes: gatewayapi.RouteBindingSelector{
+						Kind: "UDPRoute",
+					},
+				}),
+			},
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour": {"Admitted=True/Admitted"},
+				"*v1alpha1.Gateway/contour":      {"Scheduled=True/Scheduled", "Ready=False/ListenersNotValid"},
+				"*v1alpha1.Gateway/contour/UDP":  {"Detached=True/UnsupportedProtocol", "Ready=False/Invalid"},
+			},
+		},
+		"route for a host of an httpproxy": {
+			objs: []interface{}{
+				class,
+				gateway(httpListener),
+				&gatewayapi.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: gatewayapi.HTTPRouteSpec{
+						Hostnames: []gatewayapi.Hostname{"kuard.example.com"},
+						Rules: []gatewayapi.HTTPRouteRule{{
+							ForwardTo: forwardTo("kuard", 8080),
+						}},
+					},
+				},
+				&projcontour.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: projcontour.HTTPProxySpec{
+						VirtualHost: &projcontour.VirtualHost{
+							Fqdn: "kuard.example.com",
+						},
+						Routes: []projcontour.Route{{
+							Services: []projcontour.Service{{
+								Name: "kuard",
+								Port: 8080,
+							}},
+						}},
+					},
+				},
+				s1,
+			},
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour":    {"Admitted=True/Admitted"},
+				"*v1alpha1.Gateway/contour":         {"Scheduled=True/Scheduled", "Ready=True/Ready"},
+				"*v1alpha1.Gateway/contour/HTTP":    {"Ready=True/Ready"},
+				"*v1alpha1.HTTPRoute/kuard/contour": {"Admitted=False/Invalid"},
+			},
+		},
+		"routes for the same host": {
+			objs: []interface{}{
+				class,
+				gateway(httpListener),
+				httproute("kuard", gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				httproute("kuard2", gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+			},
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour":     {"Admitted=True/Admitted"},
+				"*v1alpha1.Gateway/contour":          {"Scheduled=True/Scheduled", "Ready=True/Ready"},
+				"*v1alpha1.Gateway/contour/HTTP":     {"Ready=True/Ready"},
+				"*v1alpha1.HTTPRoute/kuard/contour":  {"Admitted=True/Admitted"},
+				"*v1alpha1.HTTPRoute/kuard2/contour": {"Admitted=False/Invalid"},
+			},
+		},
+		"route for a host of another gateway": {
+			objs: []interface{}{
+				class,
+				namedGateway("contour", httpsListener("secret")),
+				namedGateway("contour2", httpsListener("secret2")),
+				httproute("kuard", gatewayapi.HTTPRouteRule{
+					ForwardTo: forwardTo("kuard", 8080),
+				}),
+				s1,
+				secret("secret"),
+				secret("secret2"),
+			},
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour":     {"Admitted=True/Admitted"},
+				"*v1alpha1.Gateway/contour":          {"Scheduled=True/Scheduled", "Ready=True/Ready"},
+				"*v1alpha1.Gateway/contour/HTTPS":    {"Ready=True/Ready"},
+				"*v1alpha1.Gateway/contour2":         {"Scheduled=True/Scheduled", "Ready=True/Ready"},
+				"*v1alpha1.Gateway/contour2/HTTPS":   {"Ready=True/Ready"},
+				"*v1alpha1.HTTPRoute/kuard/contour":  {"Admitted=True/Admitted"},
+				"*v1alpha1.HTTPRoute/kuard/contour2": {"Admitted=False/Invalid"},
+			},
+		},
+		"listener port is not the port of envoy's listener": {
+			objs: []interface{}{
+				class,
+				gateway(gatewayapi.Listener{
+					Port:     80,
+					Protocol: gatewayapi.HTTPProtocolType,
+					Routes: gatewayapi.RouteBindingSelector{
+						Kind: "HTTPRoute",
+					},
+				}),
+			},
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour": {"Admitted=True/Admitted"},
+				"*v1alpha1.Gateway/contour":      {"Scheduled=True/Scheduled", "Ready=False/ListenersNotValid"},
+				"*v1alpha1.Gateway/contour/HTTP": {"Detached=True/PortUnavailable", "Ready=False/Invalid"},
+			},
+		},
+		"listener port is the configured port of envoy's listener": {
+			objs: []interface{}{
+				class,
+				gateway(gatewayapi.Listener{
+					Port:     80,
+					Protocol: gatewayapi.HTTPProtocolType,
+					Routes: gatewayapi.RouteBindingSelector{
+						Kind: "HTTPRoute",
+					},
+				}),
+			},
+			httpPort: 80,
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour": {"Admitted=True/Admitted"},
+				"*v1alpha1.Gateway/contour":      {"Scheduled=True/Scheduled", "Ready=True/Ready"},
+				"*v1alpha1.Gateway/contour/HTTP": {"Ready=True/Ready"},
+			},
+		},
+		"listener selecting the wrong kind of route": {
+			objs: []interface{}{
+				class,
+				gateway(gatewayapi.Listener{
+					Port:     8080,
+					Protocol: gatewayapi.HTTPProtocolType,
+					Routes: gatewayapi.RouteBindingSelector{
+						Kind: "TLSRoute",
+					},
+				}),
+			},
+			want: map[string][]string{
+				"*v1alpha1.GatewayClass/contour": {"Admitted=True/Admitted"},
+				"*v1alpha1.Gateway/contour":      {"Scheduled=True/Scheduled", "Ready=False/ListenersNotValid"},
+				"*v1alpha1.Gateway/contour/HTTP": {"ResolvedRefs=False/InvalidRoutesRef", "Ready=False/Invalid"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: testLogger(t),
+				},
+				HTTPPort: tc.httpPort,
+			}
+			for _, o := range tc.objs {
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			got := conditions(dag.GatewayAPIStatuses())
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestRouteHostnames(t *testing.T) {
+	tests := map[string]struct {
+		listener  string
+		hostnames []gatewayapi.Hostname
+		want      []string
+	}{
+		"no hostnames": {
+			listener: "*",
+			want:     []string{"*"},
+		},
+		"no route hostnames": {
+			listener: "kuard.example.com",
+			want:     []string{"kuard.example.com"},
+		},
+		"any listener hostname": {
+			listener:  "*",
+			hostnames: []gatewayapi.Hostname{"kuard.example.com", "*.example.com"},
+			want:      []string{"kuard.example.com", "*.example.com"},
+		},
+		"matching hostname": {
+			listener:  "kuard.example.com",
+			hostnames: []gatewayapi.Hostname{"kuard.example.com", "kuarder.example.com"},
+			want:      []string{"kuard.example.com"},
+		},
+		"wildcard listener hostname": {
+			listener:  "*.example.com",
+			hostnames: []gatewayapi.Hostname{"kuard.example.com", "a.kuard.example.com", "example.com"},
+			want:      []string{"kuard.example.com"},
+		},
+		"wildcard route hostname": {
+			listener:  "kuard.example.com",
+			hostnames: []gatewayapi.Hostname{"*.example.com"},
+			want:      []string{"kuard.example.com"},
+		},
+		"no matching hostname": {
+			listener:  "kuard.example.com",
+			hostnames: []gatewayapi.Hostname{"kuard.example.org"},
+			want:      nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := routeHostnames(tc.listener, tc.hostnames)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func forwardTo(name string, port int32) []gatewayapi.HTTPRouteForwardTo {
+	return []gatewayapi.HTTPRouteForwardTo{{
+		ServiceName: stringptr(name),
+		Port:        portnumber(port),
+	}}
+}
+
+func weightedCluster(s *v1.Service, weight uint32) *Cluster {
+	return &Cluster{
+		Upstream: service(s),
+		Weight:   weight,
+	}
+}
+
+func hostname(s string) *gatewayapi.Hostname {
+	h := gatewayapi.Hostname(s)
+	return &h
+}
+
+func portnumber(p int32) *gatewayapi.PortNumber {
+	n := gatewayapi.PortNumber(p)
+	return &n
+}
+
+func stringptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,164 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
+	networking_v1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// The Ingress API is served as extensions/v1beta1, networking.k8s.io/v1beta1,
+// and networking.k8s.io/v1, depending on the version of Kubernetes. The
+// KubernetesCache converts each to networking.k8s.io/v1, so the DAG only
+// deals with one shape of Ingress.
+
+// annotationDefaultIngressClass marks an IngressClass as the class of
+// Ingresses which do not specify one.
+const annotationDefaultIngressClass = "ingressclass.kubernetes.io/is-default-class"
+
+// extensionsIngress converts an extensions/v1beta1 Ingress to networking.k8s.io/v1.
+// The extensions/v1beta1 types are identical to those of networking.k8s.io/v1beta1,
+// so it is first copied to networking.k8s.io/v1beta1, then converted by
+// networkingV1beta1Ingress.
+func extensionsIngress(ing *v1beta1.Ingress) *networking_v1.Ingress {
+	copied := &networking_v1beta1.Ingress{
+		ObjectMeta: ing.ObjectMeta,
+		Spec: networking_v1beta1.IngressSpec{
+			IngressClassName: ing.Spec.IngressClassName,
+		},
+		Status: networking_v1beta1.IngressStatus(ing.Status),
+	}
+	if be := ing.Spec.Backend; be != nil {
+		backend := networking_v1beta1.IngressBackend(*be)
+		copied.Spec.Backend = &backend
+	}
+	for _, t := range ing.Spec.TLS {
+		copied.Spec.TLS = append(copied.Spec.TLS, networking_v1beta1.IngressTLS(t))
+	}
+	for _, rule := range ing.Spec.Rules {
+		r := networking_v1beta1.IngressRule{
+			Host: rule.Host,
+		}
+		if http := rule.HTTP; http != nil {
+			r.HTTP = new(networking_v1beta1.HTTPIngressRuleValue)
+			for _, path := range http.Paths {
+				r.HTTP.Paths = append(r.HTTP.Paths, networking_v1beta1.HTTPIngressPath{
+					Path:     path.Path,
+					PathType: (*networking_v1beta1.PathType)(path.PathType),
+					Backend:  networking_v1beta1.IngressBackend(path.Backend),
+				})
+			}
+		}
+		copied.Spec.Rules = append(copied.Spec.Rules, r)
+	}
+	return networkingV1beta1Ingress(copied)
+}
+
+// networkingV1beta1Ingress converts a networking.k8s.io/v1beta1 Ingress to
+// networking.k8s.io/v1.
+func networkingV1beta1Ingress(ing *networking_v1beta1.Ingress) *networking_v1.Ingress {
+	rules := make([]networking_v1.IngressRule, 0, len(ing.Spec.Rules))
+	for _, rule := range ing.Spec.Rules {
+		rules = append(rules, ingressRule(rule))
+	}
+
+	tls := make([]networking_v1.IngressTLS, 0, len(ing.Spec.TLS))
+	for _, t := range ing.Spec.TLS {
+		tls = append(tls, ingressTLS(t))
+	}
+
+	converted := &networking_v1.Ingress{
+		ObjectMeta: ing.ObjectMeta,
+		Spec: networking_v1.IngressSpec{
+			IngressClassName: ing.Spec.IngressClassName,
+			TLS:              tls,
+			Rules:            rules,
+		},
+		Status: networking_v1.IngressStatus{
+			LoadBalancer: ing.Status.LoadBalancer,
+		},
+	}
+	if be := ing.Spec.Backend; be != nil {
+		converted.Spec.DefaultBackend = ingressBackend(be.ServiceName, be.ServicePort)
+	}
+	return converted
+}
+
+// ingressRule converts a networking.k8s.io/v1beta1 IngressRule to
+// networking.k8s.io/v1.
+func ingressRule(rule networking_v1beta1.IngressRule) networking_v1.IngressRule {
+	r := networking_v1.IngressRule{
+		Host: rule.Host,
+	}
+	if http := rule.HTTP; http != nil {
+		r.HTTP = new(networking_v1.HTTPIngressRuleValue)
+		for _, path := range http.Paths {
+			r.HTTP.Paths = append(r.HTTP.Paths, networking_v1.HTTPIngressPath{
+				Path:     path.Path,
+				PathType: (*networking_v1.PathType)(path.PathType),
+				Backend:  *ingressBackend(path.Backend.ServiceName, path.Backend.ServicePort),
+			})
+		}
+	}
+	return r
+}
+
+// ingressTLS converts a networking.k8s.io/v1beta1 IngressTLS to
+// networking.k8s.io/v1.
+func ingressTLS(t networking_v1beta1.IngressTLS) networking_v1.IngressTLS {
+	return networking_v1.IngressTLS{
+		Hosts:      t.Hosts,
+		SecretName: t.SecretName,
+	}
+}
+
+// ingressBackend returns the networking.k8s.io/v1 backend for the
+// v1beta1 service name and port.
+func ingressBackend(name string, port intstr.IntOrString) *networking_v1.IngressBackend {
+	be := &networking_v1.IngressBackend{
+		Service: &networking_v1.IngressServiceBackend{
+			Name: name,
+		},
+	}
+	switch port.Type {
+	case intstr.String:
+		be.Service.Port.Name = port.StrVal
+	default:
+		be.Service.Port.Number = port.IntVal
+	}
+	return be
+}
+
+// servicePort returns the port of the Ingress service backend
+// in the form expected by lookupService.
+func servicePort(port networking_v1.ServiceBackendPort) intstr.IntOrString {
+	if port.Name != "" {
+		return intstr.FromString(port.Name)
+	}
+	return intstr.FromInt(int(port.Number))
+}
+
+// networkingV1beta1IngressClass converts a networking.k8s.io/v1beta1
+// IngressClass to networking.k8s.io/v1. Contour does not use the
+// parameters of an IngressClass, so they are not converted.
+func networkingV1beta1IngressClass(class *networking_v1beta1.IngressClass) *networking_v1.IngressClass {
+	return &networking_v1.IngressClass{
+		ObjectMeta: class.ObjectMeta,
+		Spec: networking_v1.IngressClassSpec{
+			Controller: class.Spec.Controller,
+		},
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
+	networking_v1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestIngressConversion(t *testing.T) {
+	exact := networking_v1.PathTypeExact
+	class := "contour"
+	meta := metav1.ObjectMeta{
+		Name:      "kuard",
+		Namespace: "default",
+	}
+	want := &networking_v1.Ingress{
+		ObjectMeta: meta,
+		Spec: networking_v1.IngressSpec{
+			IngressClassName: &class,
+			DefaultBackend: &networking_v1.IngressBackend{
+				Service: &networking_v1.IngressServiceBackend{
+					Name: "kuard",
+					Port: networking_v1.ServiceBackendPort{Number: 8080},
+				},
+			},
+			TLS: []networking_v1.IngressTLS{{
+				Hosts:      []string{"kuard.example.com"},
+				SecretName: "secret",
+			}},
+			Rules: []networking_v1.IngressRule{{
+				Host: "kuard.example.com",
+				IngressRuleValue: networking_v1.IngressRuleValue{
+					HTTP: &networking_v1.HTTPIngressRuleValue{
+						Paths: []networking_v1.HTTPIngressPath{{
+							Path:     "/kuard",
+							PathType: &exact,
+							Backend: networking_v1.IngressBackend{
+								Service: &networking_v1.IngressServiceBackend{
+									Name: "kuard",
+									Port: networking_v1.ServiceBackendPort{Name: "http"},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+
+	t.Run("extensions/v1beta1", func(t *testing.T) {
+		v1beta1Exact := v1beta1.PathTypeExact
+		got := extensionsIngress(&v1beta1.Ingress{
+			ObjectMeta: meta,
+			Spec: v1beta1.IngressSpec{
+				IngressClassName: &class,
+				Backend: &v1beta1.IngressBackend{
+					ServiceName: "kuard",
+					ServicePort: intstr.FromInt(8080),
+				},
+				TLS: []v1beta1.IngressTLS{{
+					Hosts:      []string{"kuard.example.com"},
+					SecretName: "secret",
+				}},
+				Rules: []v1beta1.IngressRule{{
+					Host: "kuard.example.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{{
+								Path:     "/kuard",
+								PathType: &v1beta1Exact,
+								Backend: v1beta1.IngressBackend{
+									ServiceName: "kuard",
+									ServicePort: intstr.FromString("http"),
+								},
+							}},
+						},
+					},
+				}},
+			},
+		})
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("networking.k8s.io/v1beta1", func(t *testing.T) {
+		v1beta1Exact := networking_v1beta1.PathTypeExact
+		got := networkingV1beta1Ingress(&networking_v1beta1.Ingress{
+			ObjectMeta: meta,
+			Spec: networking_v1beta1.IngressSpec{
+				IngressClassName: &class,
+				Backend: &networking_v1beta1.IngressBackend{
+					ServiceName: "kuard",
+					ServicePort: intstr.FromInt(8080),
+				},
+				TLS: []networking_v1beta1.IngressTLS{{
+					Hosts:      []string{"kuard.example.com"},
+					SecretName: "secret",
+				}},
+				Rules: []networking_v1beta1.IngressRule{{
+					Host: "kuard.example.com",
+					IngressRuleValue: networking_v1beta1.IngressRuleValue{
+						HTTP: &networking_v1beta1.HTTPIngressRuleValue{
+							Paths: []networking_v1beta1.HTTPIngressPath{{
+								Path:     "/kuard",
+								PathType: &v1beta1Exact,
+								Backend: networking_v1beta1.IngressBackend{
+									ServiceName: "kuard",
+									ServicePort: intstr.FromString("http"),
+								},
+							}},
+						},
+					},
+				}},
+			},
+		})
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
+
+func TestExtensionsIngressWithoutHTTP(t *testing.T) {
+	// a rule without paths, and the load balancer status,
+	// survive the copy through networking.k8s.io/v1beta1.
+	lb := v1.LoadBalancerStatus{
+		Ingress: []v1.LoadBalancerIngress{{IP: "10.0.0.1"}},
+	}
+	got := extensionsIngress(&v1beta1.Ingress{
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{
+				Host: "kuard.example.com",
+			}},
+		},
+		Status: v1beta1.IngressStatus{
+			LoadBalancer: lb,
+		},
+	})
+	want := &networking_v1.Ingress{
+		Spec: networking_v1.IngressSpec{
+			TLS: []networking_v1.IngressTLS{},
+			Rules: []networking_v1.IngressRule{{
+				Host: "kuard.example.com",
+			}},
+		},
+		Status: networking_v1.IngressStatus{
+			LoadBalancer: lb,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -14,6 +14,9 @@
 package dag
 
 import (
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"time"
 
 	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
@@ -40,11 +43,14 @@ func timeoutPolicy(tp *projcontour.TimeoutPolicy) *TimeoutPolicy {
 	}
 }
 
-func healthCheckPolicy(hc *projcontour.HealthCheck) *HealthCheckPolicy {
+// healthCheckPolicy returns the HealthCheckPolicy of hc for an upstream
+// of the supplied protocol, or an error if hc is not valid.
+func healthCheckPolicy(hc *projcontour.HealthCheck, protocol string) (*HealthCheckPolicy, error) {
 	if hc == nil {
-		return nil
+		return nil, nil
 	}
-	return &HealthCheckPolicy{
+	hcp := &HealthCheckPolicy{
+		Type:               hc.Type,
 		Path:               hc.Path,
 		Host:               hc.Host,
 		Interval:           time.Duration(hc.IntervalSeconds) * time.Second,
@@ -52,6 +58,132 @@ func healthCheckPolicy(hc *projcontour.HealthCheck) *HealthCheckPolicy {
 		UnhealthyThreshold: hc.UnhealthyThresholdCount,
 		HealthyThreshold:   hc.HealthyThresholdCount,
 	}
+	if hcp.Type == "" {
+		hcp.Type = "http"
+	}
+
+	switch hcp.Type {
+	case "http", "tcp", "grpc":
+	default:
+		return nil, fmt.Errorf("health check type %q is not supported", hc.Type)
+	}
+	if hc.TCP != nil && hcp.Type != "tcp" {
+		return nil, errors.New("health check tcp settings require type \"tcp\"")
+	}
+	if hc.GRPC != nil && hcp.Type != "grpc" {
+		return nil, errors.New("health check grpc settings require type \"grpc\"")
+	}
+	if len(hc.ExpectedStatuses) > 0 && hcp.Type != "http" {
+		return nil, errors.New("health check expected statuses require type \"http\"")
+	}
+
+	switch hcp.Type {
+	case "http":
+		if hc.Path == "" {
+			return nil, errors.New("health check path must be specified")
+		}
+		for _, r := range hc.ExpectedStatuses {
+			if r.Start < 100 || r.End > 600 || r.Start >= r.End {
+				return nil, fmt.Errorf("health check expected status range [%d, %d) must be within [100, 600)", r.Start, r.End)
+			}
+			hcp.ExpectedStatuses = append(hcp.ExpectedStatuses, StatusRange{Start: r.Start, End: r.End})
+		}
+	case "tcp":
+		if hc.TCP != nil {
+			for _, payload := range append([]string{hc.TCP.Send}, hc.TCP.Receive...) {
+				if _, err := hex.DecodeString(payload); err != nil {
+					return nil, fmt.Errorf("health check payload %q is not hex encoded", payload)
+				}
+			}
+			hcp.Send = hc.TCP.Send
+			hcp.Receive = hc.TCP.Receive
+		}
+	case "grpc":
+		if protocol != "h2" && protocol != "h2c" {
+			// envoy can only send grpc health checks over http/2.
+			return nil, errors.New("grpc health checks require the upstream protocol \"h2\" or \"h2c\"")
+		}
+		if hc.GRPC != nil {
+			hcp.GRPCServiceName = hc.GRPC.ServiceName
+		}
+	}
+	return hcp, nil
+}
+
+// outlierDetectionPolicy returns the OutlierDetectionPolicy
+// of od, or an error if od is not valid.
+func outlierDetectionPolicy(od *projcontour.OutlierDetection) (*OutlierDetectionPolicy, error) {
+	if od == nil {
+		return nil, nil
+	}
+	if od.IntervalSeconds < 0 || od.BaseEjectionTimeSeconds < 0 {
+		return nil, errors.New("outlier detection interval and base ejection time must not be negative")
+	}
+	if od.MaxEjectionPercent > 100 {
+		return nil, fmt.Errorf("outlier detection max ejection percent %d must not exceed 100", od.MaxEjectionPercent)
+	}
+	return &OutlierDetectionPolicy{
+		Consecutive5xxErrors:     od.Consecutive5xxErrors,
+		ConsecutiveGatewayErrors: od.ConsecutiveGatewayErrors,
+		Interval:                 time.Duration(od.IntervalSeconds) * time.Second,
+		BaseEjectionTime:         time.Duration(od.BaseEjectionTimeSeconds) * time.Second,
+		MaxEjectionPercent:       od.MaxEjectionPercent,
+	}, nil
+}
+
+func circuitBreakerPolicy(cb *projcontour.CircuitBreakers) *CircuitBreakerPolicy {
+	if cb == nil {
+		return nil
+	}
+	return &CircuitBreakerPolicy{
+		MaxConnections:     cb.MaxConnections,
+		MaxPendingRequests: cb.MaxPendingRequests,
+		MaxRequests:        cb.MaxRequests,
+		MaxRetries:         cb.MaxRetries,
+	}
+}
+
+// protocolOptions returns the ProtocolOptions of po, or an error if po
+// is not valid for the supplied protocol.
+func protocolOptions(po *projcontour.ProtocolOptions, protocol string) (*ProtocolOptions, error) {
+	if po == nil || (po.HTTP1 == nil && po.HTTP2 == nil) {
+		return nil, nil
+	}
+	options := new(ProtocolOptions)
+	if h1 := po.HTTP1; h1 != nil {
+		if protocol == "h2" || protocol == "h2c" {
+			return nil, fmt.Errorf("http1 protocol options are not valid with the upstream protocol %q", protocol)
+		}
+		options.HTTP1 = &HTTP1ProtocolOptions{
+			ProperCaseHeaders: h1.ProperCaseHeaders,
+			EnableTrailers:    h1.EnableTrailers,
+		}
+	}
+	if h2 := po.HTTP2; h2 != nil {
+		if protocol != "h2" && protocol != "h2c" && protocol != "auto" {
+			return nil, errors.New("http2 protocol options require the upstream protocol \"h2\", \"h2c\", or \"auto\"")
+		}
+		// Envoy's limits on the HTTP/2 settings.
+		const (
+			maxSetting    = 1<<31 - 1
+			minWindowSize = 1<<16 - 1
+		)
+		if h2.MaxConcurrentStreams > maxSetting {
+			return nil, fmt.Errorf("http2 maxConcurrentStreams must be between 1 and %d", maxSetting)
+		}
+		if ws := h2.InitialStreamWindowSize; ws != 0 && (ws < minWindowSize || ws > maxSetting) {
+			return nil, fmt.Errorf("http2 initialStreamWindowSize must be between %d and %d", minWindowSize, maxSetting)
+		}
+		if ws := h2.InitialConnectionWindowSize; ws != 0 && (ws < minWindowSize || ws > maxSetting) {
+			return nil, fmt.Errorf("http2 initialConnectionWindowSize must be between %d and %d", minWindowSize, maxSetting)
+		}
+		options.HTTP2 = &HTTP2ProtocolOptions{
+			MaxConcurrentStreams:        h2.MaxConcurrentStreams,
+			InitialStreamWindowSize:     h2.InitialStreamWindowSize,
+			InitialConnectionWindowSize: h2.InitialConnectionWindowSize,
+		}
+	}
+	return options, nil
 }
 
 func parseTimeout(timeout string) time.Duration {
@@ -165,3 +165,304 @@ func TestParseTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestHealthCheckPolicy(t *testing.T) {
+	tests := map[string]struct {
+		hc       *projcontour.HealthCheck
+		protocol string
+		want     *HealthCheckPolicy
+		wantErr  string
+	}{
+		"nil health check": {
+			hc:   nil,
+			want: nil,
+		},
+		"http by default": {
+			hc: &projcontour.HealthCheck{
+				Path:            "/healthz",
+				IntervalSeconds: 5,
+			},
+			want: &HealthCheckPolicy{
+				Type:     "http",
+				Path:     "/healthz",
+				Interval: 5 * time.Second,
+			},
+		},
+		"http expected statuses": {
+			hc: &projcontour.HealthCheck{
+				Type: "http",
+				Path: "/healthz",
+				ExpectedStatuses: []projcontour.StatusRange{
+					{Start: 200, End: 400},
+				},
+			},
+			want: &HealthCheckPolicy{
+				Type:             "http",
+				Path:             "/healthz",
+				ExpectedStatuses: []StatusRange{{Start: 200, End: 400}},
+			},
+		},
+		"http without path": {
+			hc:      &projcontour.HealthCheck{Type: "http"},
+			wantErr: "health check path must be specified",
+		},
+		"http empty status range": {
+			hc: &projcontour.HealthCheck{
+				Path: "/healthz",
+				ExpectedStatuses: []projcontour.StatusRange{
+					{Start: 300, End: 300},
+				},
+			},
+			wantErr: "health check expected status range [300, 300) must be within [100, 600)",
+		},
+		"tcp connect only": {
+			hc: &projcontour.HealthCheck{Type: "tcp"},
+			want: &HealthCheckPolicy{
+				Type: "tcp",
+			},
+		},
+		"tcp payloads": {
+			hc: &projcontour.HealthCheck{
+				Type: "tcp",
+				TCP: &projcontour.TCPHealthCheck{
+					Send:    "50494e47",
+					Receive: []string{"504f4e47"},
+				},
+			},
+			want: &HealthCheckPolicy{
+				Type:    "tcp",
+				Send:    "50494e47",
+				Receive: []string{"504f4e47"},
+			},
+		},
+		"tcp payload not hex": {
+			hc: &projcontour.HealthCheck{
+				Type: "tcp",
+				TCP: &projcontour.TCPHealthCheck{
+					Send: "PING",
+				},
+			},
+			wantErr: `health check payload "PING" is not hex encoded`,
+		},
+		"tcp with expected statuses": {
+			hc: &projcontour.HealthCheck{
+				Type: "tcp",
+				ExpectedStatuses: []projcontour.StatusRange{
+					{Start: 200, End: 300},
+				},
+			},
+			wantErr: `health check expected statuses require type "http"`,
+		},
+		"grpc": {
+			hc: &projcontour.HealthCheck{
+				Type: "grpc",
+				GRPC: &projcontour.GRPCHealthCheck{
+					ServiceName: "helloworld.Greeter",
+				},
+			},
+			protocol: "h2c",
+			want: &HealthCheckPolicy{
+				Type:            "grpc",
+				GRPCServiceName: "helloworld.Greeter",
+			},
+		},
+		"grpc over http/1": {
+			hc:      &projcontour.HealthCheck{Type: "grpc"},
+			wantErr: `grpc health checks require the upstream protocol "h2" or "h2c"`,
+		},
+		"grpc settings on http health check": {
+			hc: &projcontour.HealthCheck{
+				Path: "/healthz",
+				GRPC: &projcontour.GRPCHealthCheck{},
+			},
+			wantErr: `health check grpc settings require type "grpc"`,
+		},
+		"unknown type": {
+			hc:      &projcontour.HealthCheck{Type: "udp"},
+			wantErr: `health check type "udp" is not supported`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := healthCheckPolicy(tc.hc, tc.protocol)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestOutlierDetectionPolicy(t *testing.T) {
+	tests := map[string]struct {
+		od      *projcontour.OutlierDetection
+		want    *OutlierDetectionPolicy
+		wantErr string
+	}{
+		"nil outlier detection": {
+			od:   nil,
+			want: nil,
+		},
+		"empty outlier detection": {
+			od:   &projcontour.OutlierDetection{},
+			want: &OutlierDetectionPolicy{},
+		},
+		"explicit outlier detection": {
+			od: &projcontour.OutlierDetection{
+				Consecutive5xxErrors:     3,
+				ConsecutiveGatewayErrors: 2,
+				IntervalSeconds:          5,
+				BaseEjectionTimeSeconds:  60,
+				MaxEjectionPercent:       50,
+			},
+			want: &OutlierDetectionPolicy{
+				Consecutive5xxErrors:     3,
+				ConsecutiveGatewayErrors: 2,
+				Interval:                 5 * time.Second,
+				BaseEjectionTime:         60 * time.Second,
+				MaxEjectionPercent:       50,
+			},
+		},
+		"negative interval": {
+			od: &projcontour.OutlierDetection{
+				IntervalSeconds: -1,
+			},
+			wantErr: "outlier detection interval and base ejection time must not be negative",
+		},
+		"max ejection percent too large": {
+			od: &projcontour.OutlierDetection{
+				MaxEjectionPercent: 101,
+			},
+			wantErr: "outlier detection max ejection percent 101 must not exceed 100",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := outlierDetectionPolicy(tc.od)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestProtocolOptions(t *testing.T) {
+	tests := map[string]struct {
+		po       *projcontour.ProtocolOptions
+		protocol string
+		want     *ProtocolOptions
+		wantErr  string
+	}{
+		"nil protocol options": {
+			po:   nil,
+			want: nil,
+		},
+		"empty protocol options": {
+			po:   &projcontour.ProtocolOptions{},
+			want: nil,
+		},
+		"http1 protocol options": {
+			po: &projcontour.ProtocolOptions{
+				HTTP1: &projcontour.HTTP1ProtocolOptions{
+					ProperCaseHeaders: true,
+				},
+			},
+			protocol: "tls",
+			want: &ProtocolOptions{
+				HTTP1: &HTTP1ProtocolOptions{
+					ProperCaseHeaders: true,
+				},
+			},
+		},
+		"http1 protocol options with h2c": {
+			po: &projcontour.ProtocolOptions{
+				HTTP1: &projcontour.HTTP1ProtocolOptions{
+					EnableTrailers: true,
+				},
+			},
+			protocol: "h2c",
+			wantErr:  `http1 protocol options are not valid with the upstream protocol "h2c"`,
+		},
+		"http2 protocol options": {
+			po: &projcontour.ProtocolOptions{
+				HTTP2: &projcontour.HTTP2ProtocolOptions{
+					MaxConcurrentStreams:        100,
+					InitialStreamWindowSize:     65535,
+					InitialConnectionWindowSize: 1048576,
+				},
+			},
+			protocol: "auto",
+			want: &ProtocolOptions{
+				HTTP2: &HTTP2ProtocolOptions{
+					MaxConcurrentStreams:        100,
+					InitialStreamWindowSize:     65535,
+					InitialConnectionWindowSize: 1048576,
+				},
+			},
+		},
+		"http2 protocol options without h2": {
+			po: &projcontour.ProtocolOptions{
+				HTTP2: &projcontour.HTTP2ProtocolOptions{
+					MaxConcurrentStreams: 100,
+				},
+			},
+			protocol: "tls",
+			wantErr:  `http2 protocol options require the upstream protocol "h2", "h2c", or "auto"`,
+		},
+		"http2 max concurrent streams too large": {
+			po: &projcontour.ProtocolOptions{
+				HTTP2: &projcontour.HTTP2ProtocolOptions{
+					MaxConcurrentStreams: 1 << 31,
+				},
+			},
+			protocol: "h2",
+			wantErr:  "http2 maxConcurrentStreams must be between 1 and 2147483647",
+		},
+		"http2 stream window size too small": {
+			po: &projcontour.ProtocolOptions{
+				HTTP2: &projcontour.HTTP2ProtocolOptions{
+					InitialStreamWindowSize: 1024,
+				},
+			},
+			protocol: "h2c",
+			wantErr:  "http2 initialStreamWindowSize must be between 65535 and 2147483647",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := protocolOptions(tc.po, tc.protocol)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -14,6 +14,8 @@
 package dag
 
 import (
+	"strings"
+
 	ingressroutev1 "github.com/projectcontour/contour/apis/contour/v1beta1"
 	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,6 +33,10 @@ type Status struct {
 	Status      string
 	Description string
 	Vhost       string
+
+	// Warnings are problems with the object that do not
+	// make it invalid, but which the user should know about.
+	Warnings []string
 }
 
 type StatusWriter struct {
@@ -42,9 +48,10 @@ type Object interface {
 }
 
 type ObjectStatusWriter struct {
-	sw     *StatusWriter
-	obj    Object
-	values map[string]string
+	sw       *StatusWriter
+	obj      Object
+	values   map[string]string
+	warnings []string
 }
 
 // WithObject returns an ObjectStatusWriter that can be used to set the state of
@@ -77,11 +84,16 @@ func (sw *StatusWriter) commit(osw *ObjectStatusWriter) {
 	}
 	if _, ok := sw.statuses[m]; !ok {
 		// only record the first status event
+		desc := osw.values["description"]
+		if len(osw.warnings) > 0 {
+			desc += "; warning: " + strings.Join(osw.warnings, "; ")
+		}
 		sw.statuses[m] = Status{
 			Object:      osw.obj,
 			Status:      osw.values["status"],
-			Description: osw.values["description"],
+			Description: desc,
 			Vhost:       osw.values["vhost"],
+			Warnings:    osw.warnings,
 		}
 	}
 }
@@ -90,6 +102,12 @@ func (osw *ObjectStatusWriter) WithValue(key, val string) *ObjectStatusWriter {
 	return osw
 }
 
+// AddWarning records a warning against the object. Warnings do not
+// change the object's status, but are appended to its description.
+func (osw *ObjectStatusWriter) AddWarning(msg string) {
+	osw.warnings = append(osw.warnings, msg)
+}
+
 func (osw *ObjectStatusWriter) SetInvalid(desc string) {
 	osw.WithValue("description", desc).WithValue("status", StatusInvalid)
 }
@@ -106,7 +124,7 @@ func (osw *ObjectStatusWriter) SetValid() {
 }
 
 // WithObject returns a new ObjectStatusWriter with a copy of the current
-// ObjectStatusWriter's values, including its status if set. This is convenient if
+// ObjectStatusWriter's values, including its status if set, but not its warnings. This is convenient if
 // the object shares a relationship with its parent. The caller should arrange for
 // the commit function to be called to write the final status of the object.
 func (osw *ObjectStatusWriter) WithObject(obj Object) (_ *ObjectStatusWriter, commit func()) {
@@ -757,6 +757,41 @@ func TestDAGIngressRouteStatus(t *testing.T) {
 		},
 	}
 
+	s11 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "coredns",
+			Namespace: "roots",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "dns",
+				Protocol: "UDP",
+				Port:     53,
+			}},
+		},
+	}
+
+	ir32 := &ingressroutev1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dns",
+			Namespace: "roots",
+		},
+		Spec: ingressroutev1.IngressRouteSpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &projcontour.TLS{
+					SecretName: sec1.Name,
+				},
+			},
+			TCPProxy: &ingressroutev1.TCPProxy{
+				Services: []ingressroutev1.Service{{
+					Name: s11.Name,
+					Port: 53,
+				}},
+			},
+		},
+	}
+
 	tests := map[string]struct {
 		objs []interface{}
 		want map[Meta]Status
@@ -1007,6 +1042,18 @@ func TestDAGIngressRouteStatus(t *testing.T) {
 				},
 			},
 		},
+		"tcpproxy references a non tcp service port": {
+			objs: []interface{}{sec1, s11, ir32},
+			want: map[Meta]Status{
+				{name: ir32.Name, namespace: ir32.Namespace}: {
+					Object:      ir32,
+					Status:      StatusValid,
+					Description: `valid IngressRoute; warning: tcpproxy: service roots/coredns/53: port has no TCP endpoints`,
+					Vhost:       "example.com",
+					Warnings:    []string{`tcpproxy: service roots/coredns/53: port has no TCP endpoints`},
+				},
+			},
+		},
 		"two root ingressroutes delegated to the same object should not conflict on hostname": {
 			objs: []interface{}{
 				s1, ir29, ir30, ir31,
@@ -1536,6 +1583,160 @@ func TestDAGHTTPProxyStatus(t *testing.T) {
 		},
 	}
 
+	proxy24 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dns",
+			Namespace: "marketing",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "dns.containersteve.com",
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "coredns",
+					Port: 53,
+				}},
+			}},
+		},
+	}
+
+	proxy25 := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "green",
+			Namespace: "marketing",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "green.containersteve.com",
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name:     "green",
+					Port:     80,
+					Protocol: "h3",
+				}},
+			}},
+		},
+	}
+
+	s10 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "coredns",
+			Namespace: "marketing",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "dns",
+				Protocol: "UDP",
+				Port:     53,
+			}},
+		},
+	}
+
+	s11 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "coredns",
+			Namespace: "marketing",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "dns",
+				Protocol: "UDP",
+				Port:     53,
+			}, {
+				Name:     "dns-tcp",
+				Protocol: "TCP",
+				Port:     53,
+			}},
+		},
+	}
+
+	sharedService := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "auth",
+			Namespace: "shared",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     80,
+			}},
+		},
+	}
+
+	// proxyCrossNamespace routes to a service in another namespace
+	proxyCrossNamespace := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "cross-namespace",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []projcontour.Route{{
+				Condition: &projcontour.Condition{
+					Prefix: "/auth",
+				},
+				Services: []projcontour.Service{{
+					Name:      "auth",
+					Namespace: "shared",
+					Port:      80,
+				}},
+			}},
+		},
+	}
+
+	// proxyFleetChild sets a fleet, which is only valid on a root
+	proxyFleetParent := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fleet-parent",
+			Namespace: "roots",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Includes: []projcontour.Include{{
+				Name:      "fleet-child",
+				Namespace: "roots",
+			}},
+		},
+	}
+
+	proxyFleetChild := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fleet-child",
+			Namespace: "roots",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			Fleet: "internal",
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	sharedDelegation := &projcontour.ServiceDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "auth",
+			Namespace: "shared",
+		},
+		Spec: projcontour.ServiceDelegationSpec{
+			Delegations: []projcontour.DelegatedService{{
+				ServiceName: "auth",
+				TargetNamespaces: []string{
+					"roots",
+				},
+			}},
+		},
+	}
+
 	tests := map[string]struct {
 		objs []interface{}
 		want map[Meta]Status
@@ -1622,6 +1823,45 @@ func TestDAGHTTPProxyStatus(t *testing.T) {
 				},
 			},
 		},
+		"included proxy sets fleet": {
+			objs: []interface{}{proxyFleetParent, proxyFleetChild, s4},
+			want: map[Meta]Status{
+				{name: proxyFleetParent.Name, namespace: proxyFleetParent.Namespace}: {
+					Object:      proxyFleetParent,
+					Status:      StatusValid,
+					Description: "valid HTTPProxy",
+					Vhost:       "example.com",
+				},
+				{name: proxyFleetChild.Name, namespace: proxyFleetChild.Namespace}: {
+					Object:      proxyFleetChild,
+					Status:      StatusInvalid,
+					Description: "Spec.Fleet may only be set on a root HTTPProxy",
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"service in another namespace not delegated": {
+			objs: []interface{}{proxyCrossNamespace, sharedService},
+			want: map[Meta]Status{
+				{name: proxyCrossNamespace.Name, namespace: proxyCrossNamespace.Namespace}: {
+					Object:      proxyCrossNamespace,
+					Status:      StatusInvalid,
+					Description: `route "/auth": service "shared/auth": not delegated to namespace "roots"`,
+					Vhost:       "example.com",
+				},
+			},
+		},
+		"service in another namespace delegated": {
+			objs: []interface{}{proxyCrossNamespace, sharedService, sharedDelegation},
+			want: map[Meta]Status{
+				{name: proxyCrossNamespace.Name, namespace: proxyCrossNamespace.Namespace}: {
+					Object:      proxyCrossNamespace,
+					Status:      StatusValid,
+					Description: "valid HTTPProxy",
+					Vhost:       "example.com",
+				},
+			},
+		},
 		"insert proxy": {
 			objs: []interface{}{s2, proxy17},
 			want: map[Meta]Status{
@@ -1684,6 +1924,40 @@ func TestDAGHTTPProxyStatus(t *testing.T) {
 				},
 			},
 		},
+		"route references a non tcp service port": {
+			objs: []interface{}{proxy24, s10},
+			want: map[Meta]Status{
+				{name: proxy24.Name, namespace: proxy24.Namespace}: {
+					Object:      proxy24,
+					Status:      StatusValid,
+					Description: `valid HTTPProxy; warning: route "/": service "coredns": port 53 has no TCP endpoints`,
+					Vhost:       "dns.containersteve.com",
+					Warnings:    []string{`route "/": service "coredns": port 53 has no TCP endpoints`},
+				},
+			},
+		},
+		"route references a service port exposed for udp and tcp": {
+			objs: []interface{}{proxy24, s11},
+			want: map[Meta]Status{
+				{name: proxy24.Name, namespace: proxy24.Namespace}: {
+					Object:      proxy24,
+					Status:      StatusValid,
+					Description: `valid HTTPProxy`,
+					Vhost:       "dns.containersteve.com",
+				},
+			},
+		},
+		"route references an unsupported upstream protocol": {
+			objs: []interface{}{proxy25, s8},
+			want: map[Meta]Status{
+				{name: proxy25.Name, namespace: proxy25.Namespace}: {
+					Object:      proxy25,
+					Status:      StatusInvalid,
+					Description: `route "/": service "green": unsupported protocol "h3"`,
+					Vhost:       "green.containersteve.com",
+				},
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -0,0 +1,78 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"sort"
+
+	ingressroutev1 "github.com/projectcontour/contour/apis/contour/v1beta1"
+	projectcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+)
+
+// Validate returns the Status the Builder would record for obj, an
+// IngressRoute or HTTPProxy, if obj were added to its Source, and the
+// Statuses of the other objects which adding obj would make invalid.
+// If obj is not served by Contour, Validate returns the zero Status.
+//
+// The Source is left unchanged, however the Builder's DAG is rebuilt,
+// so Validate must not be called concurrently with Build.
+func (b *Builder) Validate(obj Object) (Status, []Status) {
+	m := toMeta(obj)
+
+	var existing Object
+	switch obj.(type) {
+	case *ingressroutev1.IngressRoute:
+		if ir, ok := b.Source.ingressroutes[m]; ok {
+			existing = ir
+		}
+	case *projectcontour.HTTPProxy:
+		if proxy, ok := b.Source.httpproxies[m]; ok {
+			existing = proxy
+		}
+	default:
+		return Status{}, nil
+	}
+
+	before := b.Build().Statuses()
+
+	if !b.Source.Insert(obj) {
+		return Status{}, nil
+	}
+	defer func() {
+		// restore the Source to its state before obj was inserted.
+		b.Source.Remove(obj)
+		if existing != nil {
+			b.Source.Insert(existing)
+		}
+	}()
+
+	after := b.Build().Statuses()
+
+	var invalidated []Status
+	for om, st := range after {
+		if om == m || st.Status != StatusInvalid {
+			continue
+		}
+		if bst, ok := before[om]; ok && bst.Status == StatusInvalid {
+			// obj did not make this object invalid.
+			continue
+		}
+		invalidated = append(invalidated, st)
+	}
+	sort.Slice(invalidated, func(i, j int) bool {
+		return objectLess(invalidated[i].Object, invalidated[j].Object)
+	})
+
+	return after[m], invalidated
+}
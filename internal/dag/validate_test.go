@@ -0,0 +1,177 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuilderValidate(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	s2 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuarder",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	proxy := func(name, fqdn string, route projcontour.Route) *projcontour.HTTPProxy {
+		return &projcontour.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: projcontour.HTTPProxySpec{
+				VirtualHost: &projcontour.VirtualHost{
+					Fqdn: fqdn,
+				},
+				Routes: []projcontour.Route{route},
+			},
+		}
+	}
+
+	route := projcontour.Route{
+		Services: []projcontour.Service{{
+			Name: "kuard",
+			Port: 8080,
+		}},
+	}
+
+	websockets := projcontour.Route{
+		EnableWebsockets: true,
+		Services: []projcontour.Service{{
+			Name: "kuard",
+			Port: 8080,
+		}, {
+			Name: "kuarder",
+			Port: 8080,
+		}},
+	}
+
+	// summary summarises a Status as namespace/name: status: description.
+	summary := func(st Status) string {
+		if st.Object == nil {
+			return ""
+		}
+		m := toMeta(st.Object)
+		return m.namespace + "/" + m.name + ": " + st.Status + ": " + st.Description
+	}
+
+	tests := map[string]struct {
+		objs            []interface{}
+		obj             Object
+		wantStatus      string
+		wantInvalidated []string
+	}{
+		"valid httpproxy": {
+			objs:       []interface{}{s1},
+			obj:        proxy("example", "example.com", route),
+			wantStatus: "default/example: valid: valid HTTPProxy",
+		},
+		"websockets with multiple services": {
+			objs:       []interface{}{s1, s2},
+			obj:        proxy("example", "example.com", websockets),
+			wantStatus: `default/example: invalid: route "/": cannot specify multiple services and enable websockets`,
+		},
+		"update of an invalid httpproxy": {
+			objs: []interface{}{
+				s1,
+				s2,
+				proxy("example", "example.com", websockets),
+			},
+			obj:        proxy("example", "example.com", route),
+			wantStatus: "default/example: valid: valid HTTPProxy",
+		},
+		"duplicate fqdn": {
+			objs: []interface{}{
+				s1,
+				proxy("example", "example.com", route),
+			},
+			obj:        proxy("other", "example.com", route),
+			wantStatus: `default/other: invalid: fqdn "example.com" is used in multiple HTTPProxies: default/example, default/other`,
+			wantInvalidated: []string{
+				`default/example: invalid: fqdn "example.com" is used in multiple HTTPProxies: default/example, default/other`,
+			},
+		},
+		"httpproxy of another ingress class": {
+			objs: []interface{}{s1},
+			obj: &projcontour.HTTPProxy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+					Annotations: map[string]string{
+						"kubernetes.io/ingress.class": "nginx",
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: testLogger(t),
+				},
+			}
+			for _, o := range tc.objs {
+				builder.Source.Insert(o)
+			}
+
+			want := builder.Build().Statuses()
+
+			status, invalidated := builder.Validate(tc.obj)
+			if diff := cmp.Diff(tc.wantStatus, summary(status)); diff != "" {
+				t.Fatal(diff)
+			}
+			var got []string
+			for _, st := range invalidated {
+				got = append(got, summary(st))
+			}
+			if diff := cmp.Diff(tc.wantInvalidated, got); diff != "" {
+				t.Fatal(diff)
+			}
+
+			// Validate must leave the Source unchanged.
+			if diff := cmp.Diff(want, builder.Build().Statuses(), cmp.AllowUnexported(Meta{})); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	ingressroutev1 "github.com/projectcontour/contour/apis/contour/v1beta1"
 	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/contour"
 	"github.com/projectcontour/contour/internal/envoy"
 	"github.com/projectcontour/contour/internal/protobuf"
 	"google.golang.org/grpc"
@@ -527,6 +528,7 @@ func TestClusterCircuitbreakerAnnotations(t *testing.T) {
 						MaxPendingRequests: protobuf.UInt32(4096),
 						MaxRequests:        protobuf.UInt32(404),
 						MaxRetries:         protobuf.UInt32(7),
+						TrackRemaining:     true,
 					}},
 				},
 				CommonLbConfig: envoy.ClusterCommonLBConfig(),
@@ -570,6 +572,7 @@ func TestClusterCircuitbreakerAnnotations(t *testing.T) {
 				CircuitBreakers: &envoy_cluster.CircuitBreakers{
 					Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
 						MaxPendingRequests: protobuf.UInt32(9999),
+						TrackRemaining:     true,
 					}},
 				},
 				CommonLbConfig: envoy.ClusterCommonLBConfig(),
@@ -843,6 +846,7 @@ func TestClusterServiceTLSBackendCAValidation(t *testing.T) {
 		},
 	}
 
+	// the secret is not referenced yet, so does not trigger a rebuild.
 	rh.OnAdd(secret)
 
 	ir1 := &ingressroutev1.IngressRoute{
@@ -865,12 +869,12 @@ func TestClusterServiceTLSBackendCAValidation(t *testing.T) {
 	rh.OnAdd(ir1)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "2",
+		VersionInfo: "1",
 		Resources: resources(t,
 			tlscluster("default/kuard/443/da39a3ee5e", "default/kuard/securebackend", "default_kuard_443", nil, ""),
 		),
 		TypeUrl: clusterType,
-		Nonce:   "2",
+		Nonce:   "1",
 	}, streamCDS(t, cc))
 
 	ir2 := &ingressroutev1.IngressRoute{
@@ -897,18 +901,20 @@ func TestClusterServiceTLSBackendCAValidation(t *testing.T) {
 	rh.OnUpdate(ir1, ir2)
 
 	assertEqual(t, &v2.DiscoveryResponse{
-		VersionInfo: "3",
+		VersionInfo: "2",
 		Resources: resources(t,
 			tlscluster("default/kuard/443/98c0f31c72", "default/kuard/securebackend", "default_kuard_443", []byte("ca"), "subjname"),
 		),
 		TypeUrl: clusterType,
-		Nonce:   "3",
+		Nonce:   "2",
 	}, streamCDS(t, cc))
 }
 
 // Test processing a service type ExternalName
 func TestExternalNameService(t *testing.T) {
-	rh, cc, done := setup(t)
+	rh, cc, done := setup(t, func(reh *contour.EventHandler) {
+		reh.Builder.EnableExternalNameService = true
+	})
 	defer done()
 
 	i1 := &v1beta1.Ingress{
@@ -943,6 +949,67 @@ func TestExternalNameService(t *testing.T) {
 	}, streamCDS(t, cc))
 }
 
+// Test that services of type ExternalName are ignored unless enabled,
+// and that they may not point Envoy at itself.
+func TestExternalNameServiceDenied(t *testing.T) {
+	tests := map[string]struct {
+		enable       bool
+		externalName string
+	}{
+		"not enabled": {
+			enable:       false,
+			externalName: "foo.io",
+		},
+		"localhost": {
+			enable:       true,
+			externalName: "localhost",
+		},
+		"loopback address": {
+			enable:       true,
+			externalName: "127.0.0.1",
+		},
+		"link local address": {
+			enable:       true,
+			externalName: "169.254.169.254",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			rh, cc, done := setup(t, func(reh *contour.EventHandler) {
+				reh.Builder.EnableExternalNameService = tc.enable
+			})
+			defer done()
+
+			rh.OnAdd(&v1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kuard",
+					Namespace: "default",
+				},
+				Spec: v1beta1.IngressSpec{
+					Backend: &v1beta1.IngressBackend{
+						ServiceName: "kuard",
+						ServicePort: intstr.FromInt(80),
+					},
+				},
+			})
+			rh.OnAdd(externalnameservice("default", "kuard", tc.externalName, v1.ServicePort{
+				Protocol:   "TCP",
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			}))
+
+			assertEqual(t, &v2.DiscoveryResponse{
+				VersionInfo: "2",
+				Resources:   resources(t),
+				TypeUrl:     clusterType,
+				Nonce:       "2",
+			}, streamCDS(t, cc))
+		})
+	}
+}
+
 // Test processing a service that exists but is not referenced
 func TestUnreferencedService(t *testing.T) {
 	rh, cc, done := setup(t)
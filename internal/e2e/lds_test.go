@@ -1641,6 +1641,73 @@ func TestIngressRouteTLSCertificateDelegation(t *testing.T) {
 		Nonce:   "5",
 	}, streamLDS(t, cc))
 
+	// t5 is a TLSCertificateDelegation that permits access to secret/wildcard
+	// from the namespaces labelled team=web.
+	t5 := &ingressroutev1.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "delegation",
+			Namespace: "secret",
+		},
+		Spec: ingressroutev1.TLSCertificateDelegationSpec{
+			Delegations: []ingressroutev1.CertificateDelegation{{
+				SecretName: "wildcard",
+				TargetNamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"team": "web",
+					},
+				},
+			}},
+		},
+	}
+	rh.OnUpdate(t4, t5)
+
+	// the default namespace is not yet known, so is not selected.
+	assertEqual(t, &v2.DiscoveryResponse{
+		VersionInfo: "6",
+		Resources: resources(t,
+			staticListener(),
+		),
+		TypeUrl: listenerType,
+		Nonce:   "6",
+	}, streamLDS(t, cc))
+
+	ns1 := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "default",
+			Labels: map[string]string{
+				"team": "web",
+			},
+		},
+	}
+	rh.OnAdd(ns1)
+
+	assertEqual(t, &v2.DiscoveryResponse{
+		VersionInfo: "7",
+		Resources: resources(t,
+			ingress_http,
+			ingress_https,
+			staticListener(),
+		),
+		TypeUrl: listenerType,
+		Nonce:   "7",
+	}, streamLDS(t, cc))
+
+	// removing the label revokes the delegation.
+	ns2 := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "default",
+		},
+	}
+	rh.OnUpdate(ns1, ns2)
+
+	assertEqual(t, &v2.DiscoveryResponse{
+		VersionInfo: "8",
+		Resources: resources(t,
+			staticListener(),
+		),
+		TypeUrl: listenerType,
+		Nonce:   "8",
+	}, streamLDS(t, cc))
 }
 
 func TestIngressRouteMinimumTLSVersion(t *testing.T) {
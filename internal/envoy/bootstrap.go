@@ -24,9 +24,14 @@ import (
 	clusterv2 "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	bootstrap "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
+	_struct "github.com/golang/protobuf/ptypes/struct"
 	"github.com/projectcontour/contour/internal/protobuf"
 )
 
+// UNIX_SOCKET_PREFIX is the prefix of an xDS address which names
+// the path of a Unix domain socket.
+const UNIX_SOCKET_PREFIX = "unix://"
+
 // Bootstrap creates a new v2 Bootstrap configuration.
 func Bootstrap(c *BootstrapConfig) *bootstrap.Bootstrap {
 	b := &bootstrap.Bootstrap{
@@ -83,6 +88,14 @@ func Bootstrap(c *BootstrapConfig) *bootstrap.Bootstrap {
 		},
 	}
 
+	if path, ok := c.xdsPipePath(); ok {
+		// A Unix domain socket cannot be resolved by DNS.
+		contour := b.StaticResources.Clusters[0]
+		contour.AltStatName = strings.Join([]string{c.Namespace, "contour", "unix"}, "_")
+		contour.ClusterDiscoveryType = ClusterDiscoveryType(api.Cluster_STATIC)
+		contour.LoadAssignment.Endpoints = Endpoints(PipeAddress(path))
+	}
+
 	if c.GrpcClientCert != "" || c.GrpcClientKey != "" || c.GrpcCABundle != "" {
 		// If one of the two TLS options is not empty, they all must be not empty
 		if !(c.GrpcClientCert != "" && c.GrpcClientKey != "" && c.GrpcCABundle != "") {
@@ -91,6 +104,45 @@ func Bootstrap(c *BootstrapConfig) *bootstrap.Bootstrap {
 		b.StaticResources.Clusters[0].TlsContext = upstreamFileTLSContext(c.GrpcCABundle, c.GrpcClientCert, c.GrpcClientKey)
 	}
 
+	if c.LocalCluster != "" {
+		// Zone aware routing compares the localities of the upstream
+		// cluster's endpoints to those of the Envoys in the local cluster.
+		b.StaticResources.Clusters = append(b.StaticResources.Clusters, &api.Cluster{
+			Name:                 "local_cluster",
+			AltStatName:          strings.Join([]string{c.Namespace, "local_cluster"}, "_"),
+			ConnectTimeout:       protobuf.Duration(250 * time.Millisecond),
+			ClusterDiscoveryType: ClusterDiscoveryType(api.Cluster_EDS),
+			EdsClusterConfig: &api.Cluster_EdsClusterConfig{
+				EdsConfig:   ConfigSource("contour"),
+				ServiceName: c.LocalCluster,
+			},
+		})
+		b.ClusterManager = &bootstrap.ClusterManager{
+			LocalClusterName: "local_cluster",
+		}
+	}
+
+	// The node's id and cluster are supplied on Envoy's command line,
+	// which Envoy merges with the node recorded here.
+	if c.Fleet != "" {
+		b.Node = &envoy_api_v2_core.Node{
+			Metadata: &_struct.Struct{
+				Fields: map[string]*_struct.Value{
+					"fleet": {Kind: &_struct.Value_StringValue{StringValue: c.Fleet}},
+				},
+			},
+		}
+	}
+	if c.Region != "" || c.Zone != "" {
+		if b.Node == nil {
+			b.Node = new(envoy_api_v2_core.Node)
+		}
+		b.Node.Locality = &envoy_api_v2_core.Locality{
+			Region: c.Region,
+			Zone:   c.Zone,
+		}
+	}
+
 	return b
 }
 
@@ -139,7 +191,8 @@ type BootstrapConfig struct {
 	// Defaults to 9001.
 	AdminPort int
 
-	// XDSAddress is the TCP address of the gRPC XDS management server.
+	// XDSAddress is the TCP address of the gRPC XDS management server,
+	// or, if prefixed with unix://, the path of its Unix domain socket.
 	// Defaults to 127.0.0.1.
 	XDSAddress string
 
@@ -160,6 +213,18 @@ type BootstrapConfig struct {
 
 	// GrpcClientKey is the filename that contains a client key for secure gRPC with TLS.
 	GrpcClientKey string
+
+	// Fleet is the name of the fleet of Envoys this node is a member of.
+	// It is recorded under the "fleet" key of the node's metadata.
+	Fleet string
+
+	// Region and Zone are the locality of this node.
+	Region string
+	Zone   string
+
+	// LocalCluster is the EDS service name of the Envoys in this
+	// node's own cluster, required for zone aware routing.
+	LocalCluster string
 }
 
 func (c *BootstrapConfig) xdsAddress() string   { return stringOrDefault(c.XDSAddress, "127.0.0.1") }
@@ -170,6 +235,15 @@ func (c *BootstrapConfig) adminAccessLogPath() string {
 	return stringOrDefault(c.AdminAccessLogPath, "/dev/null")
 }
 
+// xdsPipePath returns the path of the management server's Unix domain
+// socket, and true, if XDSAddress is prefixed with unix://.
+func (c *BootstrapConfig) xdsPipePath() (string, bool) {
+	if !strings.HasPrefix(c.XDSAddress, UNIX_SOCKET_PREFIX) {
+		return "", false
+	}
+	return strings.TrimPrefix(c.XDSAddress, UNIX_SOCKET_PREFIX), true
+}
+
 func stringOrDefault(s, def string) string {
 	if s == "" {
 		return def
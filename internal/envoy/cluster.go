@@ -22,6 +22,7 @@ import (
 	"time"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
@@ -36,24 +37,91 @@ const CACertificateKey = "ca.crt"
 // Cluster creates new v2.Cluster from dag.Cluster.
 func Cluster(c *dag.Cluster) *v2.Cluster {
 	cl := cluster(c)
-	switch c.Upstream.Protocol {
+	switch upstreamProtocol(c) {
 	case "tls":
-		cl.TlsContext = UpstreamTLSContext(
-			upstreamValidationCACert(c),
-			upstreamValidationSubjectAltName(c),
-		)
+		cl.TlsContext = upstreamTLSContext(c)
+		cl.HttpProtocolOptions = http1ProtocolOptions(c.ProtocolOptions)
 	case "h2":
-		cl.TlsContext = UpstreamTLSContext(
-			upstreamValidationCACert(c),
-			upstreamValidationSubjectAltName(c),
-			"h2")
+		cl.TlsContext = upstreamTLSContext(c, "h2")
 		fallthrough
 	case "h2c":
-		cl.Http2ProtocolOptions = &envoy_api_v2_core.Http2ProtocolOptions{}
+		cl.Http2ProtocolOptions = http2ProtocolOptions(c.ProtocolOptions)
+	case "auto":
+		// Offer both HTTP/2 and HTTP/1.1 and proxy each request
+		// with the protocol it was received with.
+		cl.TlsContext = upstreamTLSContext(c, "h2", "http/1.1")
+		cl.HttpProtocolOptions = http1ProtocolOptions(c.ProtocolOptions)
+		cl.Http2ProtocolOptions = http2ProtocolOptions(c.ProtocolOptions)
+		cl.ProtocolSelection = v2.Cluster_USE_DOWNSTREAM_PROTOCOL
+	default:
+		cl.HttpProtocolOptions = http1ProtocolOptions(c.ProtocolOptions)
 	}
 	return cl
 }
 
+// upstreamTLSContext returns the UpstreamTlsContext of the cluster, which
+// presents the cluster's client certificate, if any, fetched over SDS.
+func upstreamTLSContext(c *dag.Cluster, alpnProtocols ...string) *envoy_api_v2_auth.UpstreamTlsContext {
+	context := UpstreamTLSContext(
+		upstreamValidationCACert(c),
+		upstreamValidationSubjectAltName(c),
+		alpnProtocols...)
+	if name := c.Upstream.ExternalName; name != "" {
+		// present the external name, rather than no SNI,
+		// as services outside the cluster often need it.
+		context.Sni = name
+	}
+	if c.ClientCertificate != nil {
+		context.CommonTlsContext.TlsCertificateSdsSecretConfigs = []*envoy_api_v2_auth.SdsSecretConfig{{
+			Name:      Secretname(c.ClientCertificate),
+			SdsConfig: ConfigSource("contour"),
+		}}
+	}
+	return context
+}
+
+// upstreamProtocol returns the protocol of the cluster, or
+// if not set, the protocol of its upstream service.
+func upstreamProtocol(c *dag.Cluster) string {
+	if c.Protocol != "" {
+		return c.Protocol
+	}
+	return c.Upstream.Protocol
+}
+
+// http1ProtocolOptions returns the HTTP/1 options of po, or
+// nil if po has none.
+func http1ProtocolOptions(po *dag.ProtocolOptions) *envoy_api_v2_core.Http1ProtocolOptions {
+	if po == nil || po.HTTP1 == nil {
+		return nil
+	}
+	options := &envoy_api_v2_core.Http1ProtocolOptions{
+		EnableTrailers: po.HTTP1.EnableTrailers,
+	}
+	if po.HTTP1.ProperCaseHeaders {
+		options.HeaderKeyFormat = &envoy_api_v2_core.Http1ProtocolOptions_HeaderKeyFormat{
+			HeaderFormat: &envoy_api_v2_core.Http1ProtocolOptions_HeaderKeyFormat_ProperCaseWords_{
+				ProperCaseWords: new(envoy_api_v2_core.Http1ProtocolOptions_HeaderKeyFormat_ProperCaseWords),
+			},
+		}
+	}
+	return options
+}
+
+// http2ProtocolOptions returns the HTTP/2 options of po. Unlike
+// http1ProtocolOptions, the result is never nil as its presence
+// configures Envoy to use HTTP/2.
+func http2ProtocolOptions(po *dag.ProtocolOptions) *envoy_api_v2_core.Http2ProtocolOptions {
+	if po == nil || po.HTTP2 == nil {
+		return &envoy_api_v2_core.Http2ProtocolOptions{}
+	}
+	return &envoy_api_v2_core.Http2ProtocolOptions{
+		MaxConcurrentStreams:        u32nil(po.HTTP2.MaxConcurrentStreams),
+		InitialStreamWindowSize:     u32nil(po.HTTP2.InitialStreamWindowSize),
+		InitialConnectionWindowSize: u32nil(po.HTTP2.InitialConnectionWindowSize),
+	}
+}
+
 func upstreamValidationCACert(c *dag.Cluster) []byte {
 	if c.UpstreamValidation == nil {
 		// No validation required
@@ -73,12 +141,13 @@ func upstreamValidationSubjectAltName(c *dag.Cluster) string {
 func cluster(cluster *dag.Cluster) *v2.Cluster {
 	service := cluster.Upstream
 	c := &v2.Cluster{
-		Name:           Clustername(cluster),
-		AltStatName:    altStatName(service),
-		ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
-		LbPolicy:       lbPolicy(cluster.LoadBalancerStrategy),
-		CommonLbConfig: ClusterCommonLBConfig(),
-		HealthChecks:   edshealthcheck(cluster),
+		Name:             Clustername(cluster),
+		AltStatName:      altStatName(service),
+		ConnectTimeout:   protobuf.Duration(250 * time.Millisecond),
+		LbPolicy:         lbPolicy(cluster.LoadBalancerStrategy),
+		CommonLbConfig:   ClusterCommonLBConfig(),
+		HealthChecks:     edshealthcheck(cluster),
+		OutlierDetection: outlierDetection(cluster.OutlierDetectionPolicy),
 	}
 
 	switch len(service.ExternalName) {
@@ -90,6 +159,21 @@ func cluster(cluster *dag.Cluster) *v2.Cluster {
 		// external name set, use hard coded DNS name
 		c.ClusterDiscoveryType = ClusterDiscoveryType(v2.Cluster_STRICT_DNS)
 		c.LoadAssignment = StaticClusterLoadAssignment(service)
+		if service.DNSRefreshRate > 0 {
+			c.DnsRefreshRate = protobuf.Duration(service.DNSRefreshRate)
+		}
+		c.DnsLookupFamily = dnsLookupFamily(service.DNSLookupFamily)
+	}
+
+	switch service.LocalityLBPolicy {
+	case "ZoneAware":
+		c.CommonLbConfig.LocalityConfigSpecifier = &v2.Cluster_CommonLbConfig_ZoneAwareLbConfig_{
+			ZoneAwareLbConfig: new(v2.Cluster_CommonLbConfig_ZoneAwareLbConfig),
+		}
+	case "LocalityWeighted":
+		c.CommonLbConfig.LocalityConfigSpecifier = &v2.Cluster_CommonLbConfig_LocalityWeightedLbConfig_{
+			LocalityWeightedLbConfig: new(v2.Cluster_CommonLbConfig_LocalityWeightedLbConfig),
+		}
 	}
 
 	// Drain connections immediately if using healthchecks and the endpoint is known to be removed
@@ -97,17 +181,50 @@ func cluster(cluster *dag.Cluster) *v2.Cluster {
 		c.DrainConnectionsOnHostRemoval = true
 	}
 
-	if anyPositive(service.MaxConnections, service.MaxPendingRequests, service.MaxRequests, service.MaxRetries) {
-		c.CircuitBreakers = &envoy_cluster.CircuitBreakers{
-			Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
-				MaxConnections:     u32nil(service.MaxConnections),
-				MaxPendingRequests: u32nil(service.MaxPendingRequests),
-				MaxRequests:        u32nil(service.MaxRequests),
-				MaxRetries:         u32nil(service.MaxRetries),
-			}},
+	c.CircuitBreakers = circuitBreakers(cluster)
+	return c
+}
+
+// circuitBreakers returns the circuit breakers of cluster, or nil if it has
+// no limits. Limits of the cluster's circuit breaker policy take precedence
+// over those of its service. Envoy is asked to track the remaining capacity
+// under each limit so that it is visible in Envoy's metrics.
+func circuitBreakers(cluster *dag.Cluster) *envoy_cluster.CircuitBreakers {
+	service := cluster.Upstream
+	limits := dag.CircuitBreakerPolicy{
+		MaxConnections:     service.MaxConnections,
+		MaxPendingRequests: service.MaxPendingRequests,
+		MaxRequests:        service.MaxRequests,
+		MaxRetries:         service.MaxRetries,
+	}
+	if cb := cluster.CircuitBreakerPolicy; cb != nil {
+		limits.MaxConnections = firstPositive(cb.MaxConnections, limits.MaxConnections)
+		limits.MaxPendingRequests = firstPositive(cb.MaxPendingRequests, limits.MaxPendingRequests)
+		limits.MaxRequests = firstPositive(cb.MaxRequests, limits.MaxRequests)
+		limits.MaxRetries = firstPositive(cb.MaxRetries, limits.MaxRetries)
+	}
+	if !anyPositive(limits.MaxConnections, limits.MaxPendingRequests, limits.MaxRequests, limits.MaxRetries) {
+		return nil
+	}
+	return &envoy_cluster.CircuitBreakers{
+		Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
+			MaxConnections:     u32nil(limits.MaxConnections),
+			MaxPendingRequests: u32nil(limits.MaxPendingRequests),
+			MaxRequests:        u32nil(limits.MaxRequests),
+			MaxRetries:         u32nil(limits.MaxRetries),
+			TrackRemaining:     true,
+		}},
+	}
+}
+
+// firstPositive returns the first of vals which is greater than zero, or zero.
+func firstPositive(vals ...uint32) uint32 {
+	for _, v := range vals {
+		if v > 0 {
+			return v
 		}
 	}
-	return c
+	return 0
 }
 
 // StaticClusterLoadAssignment creates a *v2.ClusterLoadAssignment pointing to the external DNS address of the service
@@ -140,6 +257,19 @@ func edsconfig(cluster string, service *dag.Service) *v2.Cluster_EdsClusterConfi
 	}
 }
 
+// dnsLookupFamily returns the DNS lookup family of a cluster
+// for family, one of "auto", "v4", or "v6".
+func dnsLookupFamily(family string) v2.Cluster_DnsLookupFamily {
+	switch family {
+	case "v4":
+		return v2.Cluster_V4_ONLY
+	case "v6":
+		return v2.Cluster_V6_ONLY
+	default:
+		return v2.Cluster_AUTO
+	}
+}
+
 func lbPolicy(strategy string) v2.Cluster_LbPolicy {
 	switch strategy {
 	case "WeightedLeastRequest":
@@ -162,6 +292,31 @@ func edshealthcheck(c *dag.Cluster) []*envoy_api_v2_core.HealthCheck {
 	}
 }
 
+// outlierDetection returns the outlier detection of a cluster with
+// the supplied policy, or nil if the policy is nil. Envoy's defaults
+// apply to any field left unset, except that gateway errors are only
+// enforced if a number of consecutive gateway errors is given.
+func outlierDetection(od *dag.OutlierDetectionPolicy) *envoy_cluster.OutlierDetection {
+	if od == nil {
+		return nil
+	}
+	o := &envoy_cluster.OutlierDetection{
+		Consecutive_5Xx:    u32nil(od.Consecutive5xxErrors),
+		MaxEjectionPercent: u32nil(od.MaxEjectionPercent),
+	}
+	if od.Interval > 0 {
+		o.Interval = protobuf.Duration(od.Interval)
+	}
+	if od.BaseEjectionTime > 0 {
+		o.BaseEjectionTime = protobuf.Duration(od.BaseEjectionTime)
+	}
+	if od.ConsecutiveGatewayErrors > 0 {
+		o.ConsecutiveGatewayFailure = protobuf.UInt32(od.ConsecutiveGatewayErrors)
+		o.EnforcingConsecutiveGatewayFailure = protobuf.UInt32(100)
+	}
+	return o
+}
+
 // Clustername returns the name of the CDS cluster for this service.
 func Clustername(cluster *dag.Cluster) string {
 	service := cluster.Upstream
@@ -180,6 +335,34 @@ func Clustername(cluster *dag.Cluster) string {
 			buf += strconv.Itoa(int(hc.HealthyThreshold))
 		}
 		buf += hc.Path
+		if hc.Type != "" && hc.Type != "http" {
+			buf += hc.Type
+		}
+		for _, r := range hc.ExpectedStatuses {
+			buf += strconv.FormatInt(r.Start, 10) + "-" + strconv.FormatInt(r.End, 10)
+		}
+		buf += hc.Send
+		buf += strings.Join(hc.Receive, ",")
+		buf += hc.GRPCServiceName
+	}
+	if od := cluster.OutlierDetectionPolicy; od != nil {
+		buf += fmt.Sprintf("od%d/%d/%s/%s/%d", od.Consecutive5xxErrors, od.ConsecutiveGatewayErrors,
+			od.Interval, od.BaseEjectionTime, od.MaxEjectionPercent)
+	}
+	if cb := cluster.CircuitBreakerPolicy; cb != nil {
+		buf += fmt.Sprintf("cb%d/%d/%d/%d", cb.MaxConnections, cb.MaxPendingRequests, cb.MaxRequests, cb.MaxRetries)
+	}
+	buf += cluster.Protocol
+	if po := cluster.ProtocolOptions; po != nil {
+		if h1 := po.HTTP1; h1 != nil {
+			buf += fmt.Sprintf("h1%t/%t", h1.ProperCaseHeaders, h1.EnableTrailers)
+		}
+		if h2 := po.HTTP2; h2 != nil {
+			buf += fmt.Sprintf("h2%d/%d/%d", h2.MaxConcurrentStreams, h2.InitialStreamWindowSize, h2.InitialConnectionWindowSize)
+		}
+	}
+	if cc := cluster.ClientCertificate; cc != nil {
+		buf += cc.Namespace() + "/" + cc.Name()
 	}
 	if uv := cluster.UpstreamValidation; uv != nil {
 		buf += uv.CACertificate.Object.ObjectMeta.Name
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
 	envoy_cluster "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
@@ -25,6 +26,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/protobuf"
+	"google.golang.org/protobuf/testing/protocmp"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -120,6 +122,86 @@ func TestCluster(t *testing.T) {
 				CommonLbConfig:       ClusterCommonLBConfig(),
 			},
 		},
+		"h2c cluster protocol overrides service protocol": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1, "tls"),
+				Protocol: "h2c",
+				ProtocolOptions: &dag.ProtocolOptions{
+					HTTP2: &dag.HTTP2ProtocolOptions{
+						MaxConcurrentStreams:    100,
+						InitialStreamWindowSize: 65535,
+					},
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/d0f7995bfa",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+				LbPolicy:       v2.Cluster_ROUND_ROBIN,
+				Http2ProtocolOptions: &envoy_api_v2_core.Http2ProtocolOptions{
+					MaxConcurrentStreams:    protobuf.UInt32(100),
+					InitialStreamWindowSize: protobuf.UInt32(65535),
+				},
+				CommonLbConfig: ClusterCommonLBConfig(),
+			},
+		},
+		"auto upstream": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1),
+				Protocol: "auto",
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/0d612c12d2",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				ConnectTimeout:       protobuf.Duration(250 * time.Millisecond),
+				LbPolicy:             v2.Cluster_ROUND_ROBIN,
+				TlsContext:           UpstreamTLSContext(nil, "", "h2", "http/1.1"),
+				Http2ProtocolOptions: &envoy_api_v2_core.Http2ProtocolOptions{},
+				ProtocolSelection:    v2.Cluster_USE_DOWNSTREAM_PROTOCOL,
+				CommonLbConfig:       ClusterCommonLBConfig(),
+			},
+		},
+		"http1 protocol options": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1),
+				ProtocolOptions: &dag.ProtocolOptions{
+					HTTP1: &dag.HTTP1ProtocolOptions{
+						ProperCaseHeaders: true,
+						EnableTrailers:    true,
+					},
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/e6f2a9c16d",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+				LbPolicy:       v2.Cluster_ROUND_ROBIN,
+				HttpProtocolOptions: &envoy_api_v2_core.Http1ProtocolOptions{
+					EnableTrailers: true,
+					HeaderKeyFormat: &envoy_api_v2_core.Http1ProtocolOptions_HeaderKeyFormat{
+						HeaderFormat: &envoy_api_v2_core.Http1ProtocolOptions_HeaderKeyFormat_ProperCaseWords_{
+							ProperCaseWords: new(envoy_api_v2_core.Http1ProtocolOptions_HeaderKeyFormat_ProperCaseWords),
+						},
+					},
+				},
+				CommonLbConfig: ClusterCommonLBConfig(),
+			},
+		},
 		"externalName service": {
 			cluster: &dag.Cluster{
 				Upstream: service(s2),
@@ -134,6 +216,105 @@ func TestCluster(t *testing.T) {
 				CommonLbConfig:       ClusterCommonLBConfig(),
 			},
 		},
+		"externalName service dns options": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Name:            s2.Name,
+					Namespace:       s2.Namespace,
+					ServicePort:     &s2.Spec.Ports[0],
+					ExternalName:    s2.Spec.ExternalName,
+					DNSRefreshRate:  30 * time.Second,
+					DNSLookupFamily: "v4",
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_STRICT_DNS),
+				LoadAssignment:       StaticClusterLoadAssignment(service(s2)),
+				DnsRefreshRate:       protobuf.Duration(30 * time.Second),
+				DnsLookupFamily:      v2.Cluster_V4_ONLY,
+				ConnectTimeout:       protobuf.Duration(250 * time.Millisecond),
+				LbPolicy:             v2.Cluster_ROUND_ROBIN,
+				CommonLbConfig:       ClusterCommonLBConfig(),
+			},
+		},
+		"externalName service tls upstream": {
+			cluster: &dag.Cluster{
+				Upstream: service(s2, "tls"),
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_STRICT_DNS),
+				LoadAssignment:       StaticClusterLoadAssignment(service(s2)),
+				ConnectTimeout:       protobuf.Duration(250 * time.Millisecond),
+				LbPolicy:             v2.Cluster_ROUND_ROBIN,
+				TlsContext: &envoy_api_v2_auth.UpstreamTlsContext{
+					CommonTlsContext: &envoy_api_v2_auth.CommonTlsContext{},
+					Sni:              "foo.io",
+				},
+				CommonLbConfig: ClusterCommonLBConfig(),
+			},
+		},
+		"zone aware locality lb policy": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Name:             s1.Name,
+					Namespace:        s1.Namespace,
+					ServicePort:      &s1.Spec.Ports[0],
+					LocalityLBPolicy: "ZoneAware",
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+				LbPolicy:       v2.Cluster_ROUND_ROBIN,
+				CommonLbConfig: &v2.Cluster_CommonLbConfig{
+					HealthyPanicThreshold: &envoy_type.Percent{
+						Value: 0,
+					},
+					LocalityConfigSpecifier: &v2.Cluster_CommonLbConfig_ZoneAwareLbConfig_{
+						ZoneAwareLbConfig: new(v2.Cluster_CommonLbConfig_ZoneAwareLbConfig),
+					},
+				},
+			},
+		},
+		"locality weighted lb policy": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Name:             s1.Name,
+					Namespace:        s1.Namespace,
+					ServicePort:      &s1.Spec.Ports[0],
+					LocalityLBPolicy: "LocalityWeighted",
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/da39a3ee5e",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+				LbPolicy:       v2.Cluster_ROUND_ROBIN,
+				CommonLbConfig: &v2.Cluster_CommonLbConfig{
+					HealthyPanicThreshold: &envoy_type.Percent{
+						Value: 0,
+					},
+					LocalityConfigSpecifier: &v2.Cluster_CommonLbConfig_LocalityWeightedLbConfig_{
+						LocalityWeightedLbConfig: new(v2.Cluster_CommonLbConfig_LocalityWeightedLbConfig),
+					},
+				},
+			},
+		},
 		"tls upstream": {
 			cluster: &dag.Cluster{
 				Upstream: service(s1, "tls"),
@@ -184,6 +365,44 @@ func TestCluster(t *testing.T) {
 				CommonLbConfig: ClusterCommonLBConfig(),
 			},
 		},
+		"tls upstream with client certificate": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1, "tls"),
+				ClientCertificate: &dag.Secret{
+					Object: &v1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "clientcert",
+							Namespace: "default",
+						},
+						Type: v1.SecretTypeTLS,
+						Data: map[string][]byte{
+							v1.TLSCertKey:       []byte("cert"),
+							v1.TLSPrivateKeyKey: []byte("key"),
+						},
+					},
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/abd732ffaf",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+				LbPolicy:       v2.Cluster_ROUND_ROBIN,
+				TlsContext: &envoy_api_v2_auth.UpstreamTlsContext{
+					CommonTlsContext: &envoy_api_v2_auth.CommonTlsContext{
+						TlsCertificateSdsSecretConfigs: []*envoy_api_v2_auth.SdsSecretConfig{{
+							Name:      "default/clientcert/cd1b506996",
+							SdsConfig: ConfigSource("contour"),
+						}},
+					},
+				},
+				CommonLbConfig: ClusterCommonLBConfig(),
+			},
+		},
 		"contour.heptio.com/max-connections": {
 			cluster: &dag.Cluster{
 				Upstream: &dag.Service{
@@ -205,6 +424,7 @@ func TestCluster(t *testing.T) {
 				CircuitBreakers: &envoy_cluster.CircuitBreakers{
 					Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
 						MaxConnections: protobuf.UInt32(9000),
+						TrackRemaining: true,
 					}},
 				},
 				CommonLbConfig: ClusterCommonLBConfig(),
@@ -231,6 +451,7 @@ func TestCluster(t *testing.T) {
 				CircuitBreakers: &envoy_cluster.CircuitBreakers{
 					Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
 						MaxPendingRequests: protobuf.UInt32(4096),
+						TrackRemaining:     true,
 					}},
 				},
 				CommonLbConfig: ClusterCommonLBConfig(),
@@ -256,7 +477,8 @@ func TestCluster(t *testing.T) {
 				LbPolicy:       v2.Cluster_ROUND_ROBIN,
 				CircuitBreakers: &envoy_cluster.CircuitBreakers{
 					Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
-						MaxRequests: protobuf.UInt32(404),
+						MaxRequests:    protobuf.UInt32(404),
+						TrackRemaining: true,
 					}},
 				},
 				CommonLbConfig: ClusterCommonLBConfig(),
@@ -282,7 +504,8 @@ func TestCluster(t *testing.T) {
 				LbPolicy:       v2.Cluster_ROUND_ROBIN,
 				CircuitBreakers: &envoy_cluster.CircuitBreakers{
 					Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
-						MaxRetries: protobuf.UInt32(7),
+						MaxRetries:     protobuf.UInt32(7),
+						TrackRemaining: true,
 					}},
 				},
 				CommonLbConfig: ClusterCommonLBConfig(),
@@ -375,12 +598,76 @@ func TestCluster(t *testing.T) {
 				}},
 			},
 		},
+		"circuit breaker policy takes precedence over annotations": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Name: s1.Name, Namespace: s1.Namespace,
+					ServicePort:    &s1.Spec.Ports[0],
+					MaxConnections: 9000,
+					MaxRequests:    404,
+				},
+				CircuitBreakerPolicy: &dag.CircuitBreakerPolicy{
+					MaxConnections: 100,
+					MaxRetries:     3,
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/6735c3a853",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+				LbPolicy:       v2.Cluster_ROUND_ROBIN,
+				CircuitBreakers: &envoy_cluster.CircuitBreakers{
+					Thresholds: []*envoy_cluster.CircuitBreakers_Thresholds{{
+						MaxConnections: protobuf.UInt32(100),
+						MaxRequests:    protobuf.UInt32(404),
+						MaxRetries:     protobuf.UInt32(3),
+						TrackRemaining: true,
+					}},
+				},
+				CommonLbConfig: ClusterCommonLBConfig(),
+			},
+		},
+		"service with outlier detection": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1),
+				OutlierDetectionPolicy: &dag.OutlierDetectionPolicy{
+					Consecutive5xxErrors:     3,
+					ConsecutiveGatewayErrors: 2,
+					Interval:                 5 * time.Second,
+					MaxEjectionPercent:       50,
+				},
+			},
+			want: &v2.Cluster{
+				Name:                 "default/kuard/443/99e114c514",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+				EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("contour"),
+					ServiceName: "default/kuard/http",
+				},
+				ConnectTimeout: protobuf.Duration(250 * time.Millisecond),
+				LbPolicy:       v2.Cluster_ROUND_ROBIN,
+				CommonLbConfig: ClusterCommonLBConfig(),
+				OutlierDetection: &envoy_cluster.OutlierDetection{
+					Consecutive_5Xx:                    protobuf.UInt32(3),
+					ConsecutiveGatewayFailure:          protobuf.UInt32(2),
+					EnforcingConsecutiveGatewayFailure: protobuf.UInt32(100),
+					Interval:                           protobuf.Duration(5 * time.Second),
+					MaxEjectionPercent:                 protobuf.UInt32(50),
+				},
+			},
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			got := Cluster(tc.cluster)
-			if diff := cmp.Diff(tc.want, got); diff != "" {
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
 				t.Fatal(diff)
 			}
 		})
@@ -445,6 +732,44 @@ func TestClustername(t *testing.T) {
 			},
 			want: "default/backend/80/5c26077e1d",
 		},
+		"tcp healthcheck": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Name:      "backend",
+					Namespace: "default",
+					ServicePort: &v1.ServicePort{
+						Name:       "http",
+						Protocol:   "TCP",
+						Port:       80,
+						TargetPort: intstr.FromInt(6502),
+					},
+				},
+				HealthCheckPolicy: &dag.HealthCheckPolicy{
+					Type:    "tcp",
+					Send:    "50494e47",
+					Receive: []string{"504f4e47"},
+				},
+			},
+			want: "default/backend/80/f89f0e26a0",
+		},
+		"outlier detection": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Name:      "backend",
+					Namespace: "default",
+					ServicePort: &v1.ServicePort{
+						Name:       "http",
+						Protocol:   "TCP",
+						Port:       80,
+						TargetPort: intstr.FromInt(6502),
+					},
+				},
+				OutlierDetectionPolicy: &dag.OutlierDetectionPolicy{
+					Consecutive5xxErrors: 3,
+				},
+			},
+			want: "default/backend/80/bca3ce842d",
+		},
 		"upstream tls validation with subject alt name": {
 			cluster: &dag.Cluster{
 				Upstream: &dag.Service{
@@ -565,6 +890,42 @@ func TestTruncate(t *testing.T) {
 	}
 }
 
+func TestCircuitBreakersTrackRemaining(t *testing.T) {
+	tests := map[string]*dag.Cluster{
+		"service annotation": {
+			Upstream: &dag.Service{
+				MaxPendingRequests: 9999,
+			},
+		},
+		"route policy": {
+			Upstream: &dag.Service{},
+			CircuitBreakerPolicy: &dag.CircuitBreakerPolicy{
+				MaxRetries: 7,
+			},
+		},
+	}
+
+	for name, cluster := range tests {
+		t.Run(name, func(t *testing.T) {
+			// the remaining capacity must be tracked so that
+			// operators can observe how close each limit is to
+			// overflowing from Envoy's circuit_breakers stats.
+			cb := circuitBreakers(cluster)
+			if cb == nil || len(cb.Thresholds) != 1 {
+				t.Fatalf("expected one circuit breaker threshold, got %v", cb)
+			}
+			if !cb.Thresholds[0].TrackRemaining {
+				t.Fatal("expected circuit breaker threshold to track remaining capacity")
+			}
+		})
+	}
+
+	// without any limit, there is nothing to track.
+	if cb := circuitBreakers(&dag.Cluster{Upstream: &dag.Service{}}); cb != nil {
+		t.Fatalf("expected no circuit breakers, got %v", cb)
+	}
+}
+
 func TestAnyPositive(t *testing.T) {
 	assert := func(want, got bool) {
 		t.Helper()
@@ -580,10 +941,24 @@ func TestAnyPositive(t *testing.T) {
 	assert(true, anyPositive(0, 1))
 }
 
+func TestFirstPositive(t *testing.T) {
+	assert := func(want, got uint32) {
+		t.Helper()
+		if want != got {
+			t.Fatal("expected", want, "got", got)
+		}
+	}
+
+	assert(0, firstPositive())
+	assert(0, firstPositive(0, 0))
+	assert(1, firstPositive(1, 2))
+	assert(2, firstPositive(0, 2))
+}
+
 func TestU32nil(t *testing.T) {
 	assert := func(want, got *wrappers.UInt32Value) {
 		t.Helper()
-		if diff := cmp.Diff(want, got); diff != "" {
+		if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
 			t.Fatal(diff)
 		}
 	}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/projectcontour/contour/internal/dag"
@@ -35,23 +36,70 @@ const (
 // healthCheck returns a *envoy_api_v2_core.HealthCheck value.
 func healthCheck(cluster *dag.Cluster) *envoy_api_v2_core.HealthCheck {
 	hc := cluster.HealthCheckPolicy
-	host := hcHost
-	if hc.Host != "" {
-		host = hc.Host
-	}
 
 	// TODO(dfc) why do we need to specify our own default, what is the default
 	// that envoy applies if these fields are left nil?
-	return &envoy_api_v2_core.HealthCheck{
+	check := &envoy_api_v2_core.HealthCheck{
 		Timeout:            durationOrDefault(hc.Timeout, hcTimeout),
 		Interval:           durationOrDefault(hc.Interval, hcInterval),
 		UnhealthyThreshold: countOrDefault(hc.UnhealthyThreshold, hcUnhealthyThreshold),
 		HealthyThreshold:   countOrDefault(hc.HealthyThreshold, hcHealthyThreshold),
-		HealthChecker: &envoy_api_v2_core.HealthCheck_HttpHealthCheck_{
-			HttpHealthCheck: &envoy_api_v2_core.HealthCheck_HttpHealthCheck{
-				Path: hc.Path,
-				Host: host,
+	}
+
+	switch hc.Type {
+	case "tcp":
+		check.HealthChecker = &envoy_api_v2_core.HealthCheck_TcpHealthCheck_{
+			TcpHealthCheck: tcpHealthCheck(hc),
+		}
+	case "grpc":
+		check.HealthChecker = &envoy_api_v2_core.HealthCheck_GrpcHealthCheck_{
+			GrpcHealthCheck: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck{
+				ServiceName: hc.GRPCServiceName,
 			},
+		}
+	default:
+		check.HealthChecker = &envoy_api_v2_core.HealthCheck_HttpHealthCheck_{
+			HttpHealthCheck: httpHealthCheck(hc),
+		}
+	}
+	return check
+}
+
+func httpHealthCheck(hc *dag.HealthCheckPolicy) *envoy_api_v2_core.HealthCheck_HttpHealthCheck {
+	host := hcHost
+	if hc.Host != "" {
+		host = hc.Host
+	}
+	http := &envoy_api_v2_core.HealthCheck_HttpHealthCheck{
+		Path: hc.Path,
+		Host: host,
+	}
+	for _, r := range hc.ExpectedStatuses {
+		http.ExpectedStatuses = append(http.ExpectedStatuses, &envoy_type.Int64Range{
+			Start: r.Start,
+			End:   r.End,
+		})
+	}
+	return http
+}
+
+func tcpHealthCheck(hc *dag.HealthCheckPolicy) *envoy_api_v2_core.HealthCheck_TcpHealthCheck {
+	// an empty send payload means connect only.
+	tcp := new(envoy_api_v2_core.HealthCheck_TcpHealthCheck)
+	if hc.Send != "" {
+		tcp.Send = payload(hc.Send)
+	}
+	for _, r := range hc.Receive {
+		tcp.Receive = append(tcp.Receive, payload(r))
+	}
+	return tcp
+}
+
+// payload returns a health check payload of the hex encoded text.
+func payload(text string) *envoy_api_v2_core.HealthCheck_Payload {
+	return &envoy_api_v2_core.HealthCheck_Payload{
+		Payload: &envoy_api_v2_core.HealthCheck_Payload_Text{
+			Text: text,
 		},
 	}
 }
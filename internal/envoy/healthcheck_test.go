@@ -18,9 +18,11 @@ import (
 	"time"
 
 	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type"
 	"github.com/google/go-cmp/cmp"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/protobuf"
+	"google.golang.org/protobuf/testing/protocmp"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -90,12 +92,100 @@ func TestHealthCheck(t *testing.T) {
 				},
 			},
 		},
+		"http healthcheck with expected statuses": {
+			cluster: &dag.Cluster{
+				HealthCheckPolicy: &dag.HealthCheckPolicy{
+					Type: "http",
+					Path: "/healthy",
+					ExpectedStatuses: []dag.StatusRange{
+						{Start: 200, End: 300},
+						{Start: 404, End: 405},
+					},
+				},
+			},
+			want: &envoy_api_v2_core.HealthCheck{
+				Timeout:            protobuf.Duration(hcTimeout),
+				Interval:           protobuf.Duration(hcInterval),
+				UnhealthyThreshold: protobuf.UInt32(3),
+				HealthyThreshold:   protobuf.UInt32(2),
+				HealthChecker: &envoy_api_v2_core.HealthCheck_HttpHealthCheck_{
+					HttpHealthCheck: &envoy_api_v2_core.HealthCheck_HttpHealthCheck{
+						Path: "/healthy",
+						Host: "contour-envoy-healthcheck",
+						ExpectedStatuses: []*envoy_type.Int64Range{
+							{Start: 200, End: 300},
+							{Start: 404, End: 405},
+						},
+					},
+				},
+			},
+		},
+		"tcp healthcheck connect only": {
+			cluster: &dag.Cluster{
+				HealthCheckPolicy: &dag.HealthCheckPolicy{
+					Type: "tcp",
+				},
+			},
+			want: &envoy_api_v2_core.HealthCheck{
+				Timeout:            protobuf.Duration(hcTimeout),
+				Interval:           protobuf.Duration(hcInterval),
+				UnhealthyThreshold: protobuf.UInt32(3),
+				HealthyThreshold:   protobuf.UInt32(2),
+				HealthChecker: &envoy_api_v2_core.HealthCheck_TcpHealthCheck_{
+					TcpHealthCheck: &envoy_api_v2_core.HealthCheck_TcpHealthCheck{},
+				},
+			},
+		},
+		"tcp healthcheck with payloads": {
+			cluster: &dag.Cluster{
+				HealthCheckPolicy: &dag.HealthCheckPolicy{
+					Type:    "tcp",
+					Send:    "50494e47",
+					Receive: []string{"504f4e47"},
+				},
+			},
+			want: &envoy_api_v2_core.HealthCheck{
+				Timeout:            protobuf.Duration(hcTimeout),
+				Interval:           protobuf.Duration(hcInterval),
+				UnhealthyThreshold: protobuf.UInt32(3),
+				HealthyThreshold:   protobuf.UInt32(2),
+				HealthChecker: &envoy_api_v2_core.HealthCheck_TcpHealthCheck_{
+					TcpHealthCheck: &envoy_api_v2_core.HealthCheck_TcpHealthCheck{
+						Send: &envoy_api_v2_core.HealthCheck_Payload{
+							Payload: &envoy_api_v2_core.HealthCheck_Payload_Text{Text: "50494e47"},
+						},
+						Receive: []*envoy_api_v2_core.HealthCheck_Payload{{
+							Payload: &envoy_api_v2_core.HealthCheck_Payload_Text{Text: "504f4e47"},
+						}},
+					},
+				},
+			},
+		},
+		"grpc healthcheck": {
+			cluster: &dag.Cluster{
+				HealthCheckPolicy: &dag.HealthCheckPolicy{
+					Type:            "grpc",
+					GRPCServiceName: "helloworld.Greeter",
+				},
+			},
+			want: &envoy_api_v2_core.HealthCheck{
+				Timeout:            protobuf.Duration(hcTimeout),
+				Interval:           protobuf.Duration(hcInterval),
+				UnhealthyThreshold: protobuf.UInt32(3),
+				HealthyThreshold:   protobuf.UInt32(2),
+				HealthChecker: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck_{
+					GrpcHealthCheck: &envoy_api_v2_core.HealthCheck_GrpcHealthCheck{
+						ServiceName: "helloworld.Greeter",
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			got := healthCheck(tc.cluster)
-			if diff := cmp.Diff(tc.want, got); diff != "" {
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
 				t.Fatal(diff)
 			}
 
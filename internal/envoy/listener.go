@@ -213,6 +213,17 @@ func SocketAddress(address string, port int) *envoy_api_v2_core.Address {
 	}
 }
 
+// PipeAddress creates a new Unix domain socket envoy_api_v2_core.Address.
+func PipeAddress(path string) *envoy_api_v2_core.Address {
+	return &envoy_api_v2_core.Address{
+		Address: &envoy_api_v2_core.Address_Pipe{
+			Pipe: &envoy_api_v2_core.Pipe{
+				Path: path,
+			},
+		},
+	}
+}
+
 // Filters returns a []*envoy_api_v2_listener.Filter for the supplied filters.
 func Filters(filters ...*envoy_api_v2_listener.Filter) []*envoy_api_v2_listener.Filter {
 	if len(filters) == 0 {
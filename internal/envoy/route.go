@@ -47,6 +47,12 @@ func RouteRoute(r *dag.Route) *envoy_api_v2_route.Route_Route {
 		HashPolicy:    hashPolicy(r),
 	}
 
+	if autoHostRewrite(r) {
+		ra.HostRewriteSpecifier = &envoy_api_v2_route.RouteAction_AutoHostRewrite{
+			AutoHostRewrite: protobuf.Bool(true),
+		}
+	}
+
 	if r.Websocket {
 		ra.UpgradeConfigs = append(ra.UpgradeConfigs,
 			&envoy_api_v2_route.RouteAction_UpgradeConfig{
@@ -70,6 +76,23 @@ func RouteRoute(r *dag.Route) *envoy_api_v2_route.Route_Route {
 	}
 }
 
+// autoHostRewrite returns true if a cluster of the route connects to an
+// ExternalName service with TLS, whose Host header must then be its external
+// name. Envoy only rewrites the Host header of requests to hosts resolved
+// from DNS, so requests to the route's other clusters are unaffected.
+func autoHostRewrite(r *dag.Route) bool {
+	for _, c := range r.Clusters {
+		if c.Upstream.ExternalName == "" {
+			continue
+		}
+		switch upstreamProtocol(c) {
+		case "tls", "h2", "auto":
+			return true
+		}
+	}
+	return false
+}
+
 // hashPolicy returns a slice of hash policies iff at least one of the route's
 // clusters supplied uses the `Cookie` load balancing stategy.
 func hashPolicy(r *dag.Route) []*envoy_api_v2_route.RouteAction_HashPolicy {
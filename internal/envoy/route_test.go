@@ -21,6 +21,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/projectcontour/contour/internal/dag"
 	"github.com/projectcontour/contour/internal/protobuf"
+	"google.golang.org/protobuf/testing/protocmp"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -58,7 +59,7 @@ func TestRoute(t *testing.T) {
 		Action:              action,
 		RequestHeadersToAdd: RouteHeaders(),
 	}
-	if diff := cmp.Diff(want, got); diff != "" {
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
 		t.Fatal(diff)
 	}
 }
@@ -93,6 +94,23 @@ func TestRouteRoute(t *testing.T) {
 		},
 		LoadBalancerStrategy: "Cookie",
 	}
+	c3 := &dag.Cluster{
+		Upstream: &dag.Service{
+			Name:         s1.Name,
+			Namespace:    s1.Namespace,
+			ServicePort:  &s1.Spec.Ports[0],
+			ExternalName: "foo.io",
+		},
+	}
+	c4 := &dag.Cluster{
+		Upstream: &dag.Service{
+			Name:         s1.Name,
+			Namespace:    s1.Namespace,
+			ServicePort:  &s1.Spec.Ports[0],
+			ExternalName: "foo.io",
+		},
+		Protocol: "tls",
+	}
 
 	tests := map[string]struct {
 		route *dag.Route
@@ -110,6 +128,33 @@ func TestRouteRoute(t *testing.T) {
 				},
 			},
 		},
+		"externalName service": {
+			route: &dag.Route{
+				Clusters: []*dag.Cluster{c3},
+			},
+			want: &envoy_api_v2_route.Route_Route{
+				Route: &envoy_api_v2_route.RouteAction{
+					ClusterSpecifier: &envoy_api_v2_route.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+				},
+			},
+		},
+		"externalName service with tls": {
+			route: &dag.Route{
+				Clusters: []*dag.Cluster{c4},
+			},
+			want: &envoy_api_v2_route.Route_Route{
+				Route: &envoy_api_v2_route.RouteAction{
+					ClusterSpecifier: &envoy_api_v2_route.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/4929fca9d4",
+					},
+					HostRewriteSpecifier: &envoy_api_v2_route.RouteAction_AutoHostRewrite{
+						AutoHostRewrite: protobuf.Bool(true),
+					},
+				},
+			},
+		},
 		"websocket": {
 			route: &dag.Route{
 				Websocket: true,
@@ -354,7 +399,7 @@ func TestRouteRoute(t *testing.T) {
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			got := RouteRoute(tc.route)
-			if diff := cmp.Diff(tc.want, got); diff != "" {
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
 				t.Fatal(diff)
 			}
 		})
@@ -473,7 +518,7 @@ func TestWeightedClusters(t *testing.T) {
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			got := weightedClusters(tc.clusters)
-			if diff := cmp.Diff(got, tc.want); diff != "" {
+			if diff := cmp.Diff(got, tc.want, protocmp.Transform()); diff != "" {
 				t.Fatal(diff)
 			}
 		})
@@ -0,0 +1,320 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"fmt"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	envoy_config_accesslog_v3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	envoy_config_bootstrap_v2 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v2"
+	envoy_config_bootstrap_v3 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_config_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_config_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_extensions_access_loggers_file_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
+	envoy_extensions_filters_network_http_connection_manager_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_extensions_filters_network_tcp_proxy_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	envoy_extensions_transport_sockets_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	envoy_type_matcher_v3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	resourcev2 "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+)
+
+// The xDS v3 API is a wire compatible evolution of v2; each v3 message keeps
+// the field numbers of its v2 ancestor, and fields deprecated in v2 survive in
+// v3 as hidden_envoy_deprecated_* fields. Contour builds its resources using
+// the v2 types, so to serve v3 each resource is marshalled as v2, unmarshalled
+// as v3, then fixed up to replace the deprecated fields, rewrite nested typed
+// configs to their v3 types, and ask Envoy to fetch any nested xDS resources
+// using v3.
+
+// V3TypeURL returns the v3 type URL corresponding to the supplied v2
+// xDS resource type URL, or the empty string if there is no v3 equivalent.
+func V3TypeURL(typeURL string) string {
+	switch typeURL {
+	case resourcev2.ClusterType:
+		return resourcev3.ClusterType
+	case resourcev2.EndpointType:
+		return resourcev3.EndpointType
+	case resourcev2.ListenerType:
+		return resourcev3.ListenerType
+	case resourcev2.RouteType:
+		return resourcev3.RouteType
+	case resourcev2.SecretType:
+		return resourcev3.SecretType
+	default:
+		return ""
+	}
+}
+
+// Upgrade returns the v3 equivalent of the supplied v2 xDS resource.
+func Upgrade(pb proto.Message) (proto.Message, error) {
+	switch pb := pb.(type) {
+	case *v2.Cluster:
+		return UpgradeCluster(pb)
+	case *v2.ClusterLoadAssignment:
+		cla := new(envoy_config_endpoint_v3.ClusterLoadAssignment)
+		return cla, convert(pb, cla)
+	case *v2.Listener:
+		return UpgradeListener(pb)
+	case *v2.RouteConfiguration:
+		return UpgradeRouteConfiguration(pb)
+	case *envoy_api_v2_auth.Secret:
+		s := new(envoy_extensions_transport_sockets_tls_v3.Secret)
+		return s, convert(pb, s)
+	default:
+		return nil, fmt.Errorf("cannot upgrade %T to v3", pb)
+	}
+}
+
+// UpgradeCluster converts a v2.Cluster to its v3 equivalent.
+func UpgradeCluster(c *v2.Cluster) (*envoy_config_cluster_v3.Cluster, error) {
+	cl := new(envoy_config_cluster_v3.Cluster)
+	if err := convert(c, cl); err != nil {
+		return nil, err
+	}
+	if eds := cl.EdsClusterConfig; eds != nil {
+		configSourceV3(eds.EdsConfig)
+	}
+	if err := clusterTLSV3(cl); err != nil {
+		return nil, err
+	}
+	return cl, nil
+}
+
+// UpgradeListener converts a v2.Listener to its v3 equivalent.
+func UpgradeListener(l *v2.Listener) (*envoy_config_listener_v3.Listener, error) {
+	lis := new(envoy_config_listener_v3.Listener)
+	if err := convert(l, lis); err != nil {
+		return nil, err
+	}
+	for _, fc := range lis.FilterChains {
+		if tc := fc.HiddenEnvoyDeprecatedTlsContext; tc != nil {
+			fc.HiddenEnvoyDeprecatedTlsContext = nil
+			for _, sds := range tc.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs() {
+				configSourceV3(sds.SdsConfig)
+			}
+			ts, err := transportSocketV3(tc)
+			if err != nil {
+				return nil, err
+			}
+			fc.TransportSocket = ts
+		}
+		for _, f := range fc.Filters {
+			tc, ok := f.ConfigType.(*envoy_config_listener_v3.Filter_TypedConfig)
+			if !ok {
+				continue
+			}
+			a, err := upgradeFilterConfig(tc.TypedConfig)
+			if err != nil {
+				return nil, err
+			}
+			tc.TypedConfig = a
+		}
+	}
+	return lis, nil
+}
+
+// UpgradeRouteConfiguration converts a v2.RouteConfiguration to its v3 equivalent.
+func UpgradeRouteConfiguration(rc *v2.RouteConfiguration) (*envoy_config_route_v3.RouteConfiguration, error) {
+	r := new(envoy_config_route_v3.RouteConfiguration)
+	if err := convert(rc, r); err != nil {
+		return nil, err
+	}
+	routeConfigurationV3(r)
+	return r, nil
+}
+
+// BootstrapV3 creates a new v3 Bootstrap configuration.
+func BootstrapV3(c *BootstrapConfig) (*envoy_config_bootstrap_v3.Bootstrap, error) {
+	return upgradeBootstrap(Bootstrap(c))
+}
+
+func upgradeBootstrap(bs *envoy_config_bootstrap_v2.Bootstrap) (*envoy_config_bootstrap_v3.Bootstrap, error) {
+	b := new(envoy_config_bootstrap_v3.Bootstrap)
+	if err := convert(bs, b); err != nil {
+		return nil, err
+	}
+	if dr := b.DynamicResources; dr != nil {
+		configSourceV3(dr.LdsConfig)
+		configSourceV3(dr.CdsConfig)
+	}
+	for _, c := range b.GetStaticResources().GetClusters() {
+		if eds := c.GetEdsClusterConfig(); eds != nil {
+			configSourceV3(eds.EdsConfig)
+		}
+		if err := clusterTLSV3(c); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// clusterTLSV3 moves the deprecated TLS context of c, if present,
+// into a TLS transport socket.
+func clusterTLSV3(c *envoy_config_cluster_v3.Cluster) error {
+	tc := c.HiddenEnvoyDeprecatedTlsContext
+	if tc == nil {
+		return nil
+	}
+	c.HiddenEnvoyDeprecatedTlsContext = nil
+	if vc := tc.GetCommonTlsContext().GetValidationContext(); vc != nil {
+		for _, san := range vc.HiddenEnvoyDeprecatedVerifySubjectAltName {
+			vc.MatchSubjectAltNames = append(vc.MatchSubjectAltNames, &envoy_type_matcher_v3.StringMatcher{
+				MatchPattern: &envoy_type_matcher_v3.StringMatcher_Exact{
+					Exact: san,
+				},
+			})
+		}
+		vc.HiddenEnvoyDeprecatedVerifySubjectAltName = nil
+	}
+	for _, sds := range tc.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs() {
+		configSourceV3(sds.SdsConfig)
+	}
+	ts, err := transportSocketV3(tc)
+	if err != nil {
+		return err
+	}
+	c.TransportSocket = ts
+	return nil
+}
+
+// upgradeFilterConfig converts the typed config of a v2 network filter
+// to its v3 equivalent. Unknown types are returned unchanged.
+func upgradeFilterConfig(a *any.Any) (*any.Any, error) {
+	switch a.TypeUrl {
+	case "type.googleapis.com/envoy.config.filter.network.http_connection_manager.v2.HttpConnectionManager":
+		hcm := new(envoy_extensions_filters_network_http_connection_manager_v3.HttpConnectionManager)
+		if err := convertAny(a, hcm); err != nil {
+			return nil, err
+		}
+		if rds := hcm.GetRds(); rds != nil {
+			configSourceV3(rds.ConfigSource)
+		}
+		if rc := hcm.GetRouteConfig(); rc != nil {
+			routeConfigurationV3(rc)
+		}
+		if idle := hcm.HiddenEnvoyDeprecatedIdleTimeout; idle != nil {
+			hcm.HiddenEnvoyDeprecatedIdleTimeout = nil
+			if hcm.CommonHttpProtocolOptions == nil {
+				hcm.CommonHttpProtocolOptions = new(envoy_config_core_v3.HttpProtocolOptions)
+			}
+			hcm.CommonHttpProtocolOptions.IdleTimeout = idle
+		}
+		if err := accessLogsV3(hcm.AccessLog); err != nil {
+			return nil, err
+		}
+		return ptypes.MarshalAny(hcm)
+	case "type.googleapis.com/envoy.config.filter.network.tcp_proxy.v2.TcpProxy":
+		tcp := new(envoy_extensions_filters_network_tcp_proxy_v3.TcpProxy)
+		if err := convertAny(a, tcp); err != nil {
+			return nil, err
+		}
+		if err := accessLogsV3(tcp.AccessLog); err != nil {
+			return nil, err
+		}
+		return ptypes.MarshalAny(tcp)
+	default:
+		return a, nil
+	}
+}
+
+// accessLogsV3 rewrites the typed configs of the supplied access logs to v3.
+func accessLogsV3(logs []*envoy_config_accesslog_v3.AccessLog) error {
+	for _, l := range logs {
+		tc, ok := l.ConfigType.(*envoy_config_accesslog_v3.AccessLog_TypedConfig)
+		if !ok || tc.TypedConfig.TypeUrl != "type.googleapis.com/envoy.config.accesslog.v2.FileAccessLog" {
+			continue
+		}
+		fal := new(envoy_extensions_access_loggers_file_v3.FileAccessLog)
+		if err := convertAny(tc.TypedConfig, fal); err != nil {
+			return err
+		}
+		a, err := ptypes.MarshalAny(fal)
+		if err != nil {
+			return err
+		}
+		tc.TypedConfig = a
+	}
+	return nil
+}
+
+// routeConfigurationV3 replaces deprecated regex matches with their
+// RE2 based equivalent.
+func routeConfigurationV3(rc *envoy_config_route_v3.RouteConfiguration) {
+	for _, vh := range rc.VirtualHosts {
+		for _, r := range vh.Routes {
+			regex, ok := r.GetMatch().GetPathSpecifier().(*envoy_config_route_v3.RouteMatch_HiddenEnvoyDeprecatedRegex)
+			if !ok {
+				continue
+			}
+			r.Match.PathSpecifier = &envoy_config_route_v3.RouteMatch_SafeRegex{
+				SafeRegex: &envoy_type_matcher_v3.RegexMatcher{
+					EngineType: &envoy_type_matcher_v3.RegexMatcher_GoogleRe2{
+						GoogleRe2: &envoy_type_matcher_v3.RegexMatcher_GoogleRE2{},
+					},
+					Regex: regex.HiddenEnvoyDeprecatedRegex,
+				},
+			}
+		}
+	}
+}
+
+// transportSocketV3 wraps the supplied TLS context in a TLS transport socket.
+func transportSocketV3(tc proto.Message) (*envoy_config_core_v3.TransportSocket, error) {
+	a, err := ptypes.MarshalAny(tc)
+	if err != nil {
+		return nil, err
+	}
+	return &envoy_config_core_v3.TransportSocket{
+		Name: wellknown.TransportSocketTls,
+		ConfigType: &envoy_config_core_v3.TransportSocket_TypedConfig{
+			TypedConfig: a,
+		},
+	}, nil
+}
+
+// configSourceV3 instructs Envoy to use the v3 xDS API to fetch
+// resources from cs, if cs is not nil.
+func configSourceV3(cs *envoy_config_core_v3.ConfigSource) {
+	if cs == nil {
+		return
+	}
+	cs.ResourceApiVersion = envoy_config_core_v3.ApiVersion_V3
+	if api := cs.GetApiConfigSource(); api != nil {
+		api.TransportApiVersion = envoy_config_core_v3.ApiVersion_V3
+	}
+}
+
+// convert copies src to dst by way of their shared wire format.
+func convert(src, dst proto.Message) error {
+	buf, err := proto.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(buf, dst)
+}
+
+// convertAny unmarshals the contents of a into dst, ignoring a's type URL.
+func convertAny(a *any.Any, dst proto.Message) error {
+	return proto.Unmarshal(a.Value, dst)
+}
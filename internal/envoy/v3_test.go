@@ -0,0 +1,394 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoy
+
+import (
+	"testing"
+	"time"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	envoy_api_v2_route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	envoy_config_bootstrap_v3 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_config_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_extensions_filters_network_http_connection_manager_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_extensions_transport_sockets_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	envoy_type_matcher_v3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	resourcev2 "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/go-cmp/cmp"
+	"github.com/projectcontour/contour/internal/protobuf"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestV3TypeURL(t *testing.T) {
+	tests := map[string]string{
+		resourcev2.ClusterType:        resourcev3.ClusterType,
+		resourcev2.EndpointType:       resourcev3.EndpointType,
+		resourcev2.ListenerType:       resourcev3.ListenerType,
+		resourcev2.RouteType:          resourcev3.RouteType,
+		resourcev2.SecretType:         resourcev3.SecretType,
+		"type.googleapis.com/unknown": "",
+	}
+	for v2, want := range tests {
+		if got := V3TypeURL(v2); got != want {
+			t.Errorf("V3TypeURL(%q): expected %q, got %q", v2, want, got)
+		}
+	}
+}
+
+func TestUpgradeCluster(t *testing.T) {
+	c := &v2.Cluster{
+		Name:                 "default/kuard/443/da39a3ee5e",
+		AltStatName:          "default_kuard_443",
+		ClusterDiscoveryType: ClusterDiscoveryType(v2.Cluster_EDS),
+		EdsClusterConfig: &v2.Cluster_EdsClusterConfig{
+			EdsConfig:   ConfigSource("contour"),
+			ServiceName: "default/kuard/https",
+		},
+		TlsContext: UpstreamTLSContext(nil, "kuard", "h2"),
+	}
+
+	got, err := UpgradeCluster(c)
+	checkErr(t, err)
+
+	if got.Name != c.Name || got.AltStatName != c.AltStatName {
+		t.Fatalf("expected name %q, got %q", c.Name, got.Name)
+	}
+	if got.HiddenEnvoyDeprecatedTlsContext != nil {
+		t.Fatal("expected deprecated tls_context to be cleared")
+	}
+	cs := got.EdsClusterConfig.EdsConfig
+	if cs.ResourceApiVersion != envoy_config_core_v3.ApiVersion_V3 || cs.GetApiConfigSource().TransportApiVersion != envoy_config_core_v3.ApiVersion_V3 {
+		t.Fatalf("expected eds config source to use v3: %v", cs)
+	}
+
+	ts := got.TransportSocket
+	if ts.Name != "envoy.transport_sockets.tls" {
+		t.Fatalf("expected tls transport socket, got %q", ts.Name)
+	}
+	tc := new(envoy_extensions_transport_sockets_tls_v3.UpstreamTlsContext)
+	checkErr(t, ptypes.UnmarshalAny(ts.GetTypedConfig(), tc))
+	if diff := cmp.Diff([]string{"h2"}, tc.CommonTlsContext.AlpnProtocols); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestUpgradeClusterVerifySubjectAltName(t *testing.T) {
+	got, err := UpgradeCluster(&v2.Cluster{
+		Name:       "default/kuard/443/da39a3ee5e",
+		TlsContext: UpstreamTLSContext([]byte("ca"), "kuard"),
+	})
+	checkErr(t, err)
+
+	tc := new(envoy_extensions_transport_sockets_tls_v3.UpstreamTlsContext)
+	checkErr(t, ptypes.UnmarshalAny(got.TransportSocket.GetTypedConfig(), tc))
+	vc := tc.CommonTlsContext.GetValidationContext()
+	want := []*envoy_type_matcher_v3.StringMatcher{{
+		MatchPattern: &envoy_type_matcher_v3.StringMatcher_Exact{Exact: "kuard"},
+	}}
+	if diff := cmp.Diff(want, vc.MatchSubjectAltNames); diff != "" {
+		t.Fatal(diff)
+	}
+	if len(vc.HiddenEnvoyDeprecatedVerifySubjectAltName) > 0 {
+		t.Fatal("expected deprecated verify_subject_alt_name to be cleared")
+	}
+}
+
+func TestUpgradeClusterClientCertificate(t *testing.T) {
+	tls := UpstreamTLSContext(nil, "")
+	tls.CommonTlsContext.TlsCertificateSdsSecretConfigs = []*envoy_api_v2_auth.SdsSecretConfig{{
+		Name:      "default/clientcert/da39a3ee5e",
+		SdsConfig: ConfigSource("contour"),
+	}}
+	got, err := UpgradeCluster(&v2.Cluster{
+		Name:       "default/kuard/443/da39a3ee5e",
+		TlsContext: tls,
+	})
+	checkErr(t, err)
+
+	tc := new(envoy_extensions_transport_sockets_tls_v3.UpstreamTlsContext)
+	checkErr(t, ptypes.UnmarshalAny(got.TransportSocket.GetTypedConfig(), tc))
+	sds := tc.CommonTlsContext.TlsCertificateSdsSecretConfigs[0]
+	if sds.Name != "default/clientcert/da39a3ee5e" || sds.SdsConfig.ResourceApiVersion != envoy_config_core_v3.ApiVersion_V3 {
+		t.Fatalf("expected v3 sds config for secret: %v", sds)
+	}
+}
+
+func TestUpgradeListener(t *testing.T) {
+	l := Listener("ingress_https", "0.0.0.0", 8443, nil,
+		HTTPConnectionManager("ingress_http", FileAccessLog("/dev/stdout")),
+	)
+	l.FilterChains[0].TlsContext = DownstreamTLSContext("default/secret/da39a3ee5e", envoy_api_v2_auth.TlsParameters_TLSv1_1, "h2")
+
+	got, err := UpgradeListener(l)
+	checkErr(t, err)
+
+	fc := got.FilterChains[0]
+	if fc.HiddenEnvoyDeprecatedTlsContext != nil {
+		t.Fatal("expected deprecated tls_context to be cleared")
+	}
+	tc := new(envoy_extensions_transport_sockets_tls_v3.DownstreamTlsContext)
+	checkErr(t, ptypes.UnmarshalAny(fc.TransportSocket.GetTypedConfig(), tc))
+	sds := tc.CommonTlsContext.TlsCertificateSdsSecretConfigs[0]
+	if sds.Name != "default/secret/da39a3ee5e" || sds.SdsConfig.ResourceApiVersion != envoy_config_core_v3.ApiVersion_V3 {
+		t.Fatalf("expected v3 sds config for secret: %v", sds)
+	}
+
+	f := fc.Filters[0].GetTypedConfig()
+	if want := "type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager"; f.TypeUrl != want {
+		t.Fatalf("expected %q, got %q", want, f.TypeUrl)
+	}
+	hcm := new(envoy_extensions_filters_network_http_connection_manager_v3.HttpConnectionManager)
+	checkErr(t, ptypes.UnmarshalAny(f, hcm))
+	if hcm.GetRds().RouteConfigName != "ingress_http" || hcm.GetRds().ConfigSource.ResourceApiVersion != envoy_config_core_v3.ApiVersion_V3 {
+		t.Fatalf("expected v3 rds config source: %v", hcm.GetRds())
+	}
+	if diff := cmp.Diff(protobuf.Duration(60*time.Second), hcm.CommonHttpProtocolOptions.GetIdleTimeout(), protocmp.Transform()); diff != "" {
+		t.Fatal(diff)
+	}
+	if want := "type.googleapis.com/envoy.extensions.access_loggers.file.v3.FileAccessLog"; hcm.AccessLog[0].GetTypedConfig().TypeUrl != want {
+		t.Fatalf("expected %q, got %q", want, hcm.AccessLog[0].GetTypedConfig().TypeUrl)
+	}
+}
+
+func TestUpgradeRouteConfiguration(t *testing.T) {
+	rc := &v2.RouteConfiguration{
+		Name: "ingress_http",
+		VirtualHosts: []*envoy_api_v2_route.VirtualHost{
+			VirtualHost("www.example.com",
+				&envoy_api_v2_route.Route{
+					Match: RouteRegex("/v[1-3]/.*"),
+				},
+			),
+		},
+	}
+
+	got, err := UpgradeRouteConfiguration(rc)
+	checkErr(t, err)
+
+	want := &envoy_config_route_v3.RouteMatch{
+		PathSpecifier: &envoy_config_route_v3.RouteMatch_SafeRegex{
+			SafeRegex: &envoy_type_matcher_v3.RegexMatcher{
+				EngineType: &envoy_type_matcher_v3.RegexMatcher_GoogleRe2{
+					GoogleRe2: &envoy_type_matcher_v3.RegexMatcher_GoogleRE2{},
+				},
+				Regex: "/v[1-3]/.*",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got.VirtualHosts[0].Routes[0].Match); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	tests := map[string]struct {
+		pb   proto.Message
+		want proto.Message
+	}{
+		"cluster": {
+			pb:   &v2.Cluster{Name: "a"},
+			want: &envoy_config_cluster_v3.Cluster{Name: "a"},
+		},
+		"listener": {
+			pb:   &v2.Listener{Name: "b"},
+			want: &envoy_config_listener_v3.Listener{Name: "b"},
+		},
+		"secret": {
+			pb: &envoy_api_v2_auth.Secret{
+				Name: "c",
+			},
+			want: &envoy_extensions_transport_sockets_tls_v3.Secret{
+				Name: "c",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Upgrade(tc.pb)
+			checkErr(t, err)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+
+	if _, err := Upgrade(&envoy_api_v2_core.Node{}); err == nil {
+		t.Fatal("expected error upgrading unknown type")
+	}
+}
+
+func TestBootstrapV3(t *testing.T) {
+	got, err := BootstrapV3(&BootstrapConfig{
+		Namespace:      "testing-ns",
+		GrpcCABundle:   "CA.cert",
+		GrpcClientCert: "client.cert",
+		GrpcClientKey:  "client.key",
+	})
+	checkErr(t, err)
+
+	want := new(envoy_config_bootstrap_v3.Bootstrap)
+	unmarshal(t, `{
+  "static_resources": {
+    "clusters": [
+      {
+        "name": "contour",
+        "alt_stat_name": "testing-ns_contour_8001",
+        "type": "STRICT_DNS",
+        "connect_timeout": "5s",
+        "load_assignment": {
+          "cluster_name": "contour",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "socket_address": {
+                        "address": "127.0.0.1",
+                        "port_value": 8001
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        },
+        "circuit_breakers": {
+          "thresholds": [
+            {
+              "priority": "HIGH",
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            },
+            {
+              "max_connections": 100000,
+              "max_pending_requests": 100000,
+              "max_requests": 60000000,
+              "max_retries": 50
+            }
+          ]
+        },
+        "http2_protocol_options": {},
+        "transport_socket": {
+          "name": "envoy.transport_sockets.tls",
+          "typed_config": {
+            "@type": "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext",
+            "common_tls_context": {
+              "tls_certificates": [
+                {
+                  "certificate_chain": {
+                    "filename": "client.cert"
+                  },
+                  "private_key": {
+                    "filename": "client.key"
+                  }
+                }
+              ],
+              "validation_context": {
+                "trusted_ca": {
+                  "filename": "CA.cert"
+                },
+                "match_subject_alt_names": [
+                  {
+                    "exact": "contour"
+                  }
+                ]
+              }
+            }
+          }
+        }
+      },
+      {
+        "name": "service-stats",
+        "alt_stat_name": "testing-ns_service-stats_9001",
+        "type": "LOGICAL_DNS",
+        "connect_timeout": "0.250s",
+        "load_assignment": {
+          "cluster_name": "service-stats",
+          "endpoints": [
+            {
+              "lb_endpoints": [
+                {
+                  "endpoint": {
+                    "address": {
+                      "socket_address": {
+                        "address": "127.0.0.1",
+                        "port_value": 9001
+                      }
+                    }
+                  }
+                }
+              ]
+            }
+          ]
+        }
+      }
+    ]
+  },
+  "dynamic_resources": {
+    "lds_config": {
+      "api_config_source": {
+        "api_type": "GRPC",
+        "transport_api_version": "V3",
+        "grpc_services": [
+          {
+            "envoy_grpc": {
+              "cluster_name": "contour"
+            }
+          }
+        ]
+      },
+      "resource_api_version": "V3"
+    },
+    "cds_config": {
+      "api_config_source": {
+        "api_type": "GRPC",
+        "transport_api_version": "V3",
+        "grpc_services": [
+          {
+            "envoy_grpc": {
+              "cluster_name": "contour"
+            }
+          }
+        ]
+      },
+      "resource_api_version": "V3"
+    }
+  },
+  "admin": {
+    "access_log_path": "/dev/null",
+    "address": {
+      "socket_address": {
+        "address": "127.0.0.1",
+        "port_value": 9001
+      }
+    }
+  }
+}`, want)
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Fatal(diff)
+	}
+}
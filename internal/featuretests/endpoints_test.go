@@ -0,0 +1,144 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featuretests
+
+import (
+	"context"
+	"testing"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/projectcontour/contour/apis/generated/clientset/versioned/fake"
+	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/contour"
+	"github.com/projectcontour/contour/internal/envoy"
+	"github.com/projectcontour/contour/internal/k8s"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestEndpointsNamedTargetPort(t *testing.T) {
+	rh, c, done := setup(t)
+	defer done()
+
+	rh.OnAdd(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       80,
+				TargetPort: intstr.FromString("web"),
+			}},
+		},
+	})
+
+	// The endpoint port takes the name of the pod's container port
+	// and each pod may resolve it to a different number.
+	rh.OnAdd(&v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Subsets: []v1.EndpointSubset{{
+			Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}},
+			Ports:     []v1.EndpointPort{{Name: "web", Port: 8080, Protocol: "TCP"}},
+		}, {
+			Addresses: []v1.EndpointAddress{{IP: "10.0.0.2"}},
+			Ports:     []v1.EndpointPort{{Name: "web", Port: 9090, Protocol: "TCP"}},
+		}},
+	})
+
+	c.Request(endpointType).Equals(&v2.DiscoveryResponse{
+		Resources: resources(t,
+			envoy.ClusterLoadAssignment("default/kuard/http",
+				envoy.SocketAddress("10.0.0.1", 8080),
+				envoy.SocketAddress("10.0.0.2", 9090),
+			),
+		),
+		TypeUrl: endpointType,
+	})
+}
+
+func TestHTTPProxyNonTCPServicePort(t *testing.T) {
+	proxy := &projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dns",
+			Namespace: "default",
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "dns.example.com",
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: "coredns",
+					Port: 53,
+				}},
+			}},
+		},
+	}
+
+	client := fake.NewSimpleClientset(proxy)
+	rh, c, done := setup(t, func(reh *contour.EventHandler) {
+		reh.CRDStatus = &k8s.CRDStatus{
+			Client: client,
+		}
+	})
+	defer done()
+
+	rh.OnAdd(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "coredns",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "dns",
+				Protocol: "UDP",
+				Port:     53,
+			}},
+		},
+	})
+	rh.OnAdd(&v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "coredns",
+			Namespace: "default",
+		},
+		Subsets: []v1.EndpointSubset{{
+			Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}},
+			Ports:     []v1.EndpointPort{{Name: "dns", Port: 53, Protocol: "UDP"}},
+		}},
+	})
+	rh.OnAdd(proxy)
+
+	// the udp port is never sent to envoy.
+	c.Request(endpointType).Equals(&v2.DiscoveryResponse{
+		TypeUrl: endpointType,
+	})
+
+	got, err := client.ProjectcontourV1alpha1().HTTPProxies(proxy.Namespace).Get(context.TODO(), proxy.Name, metav1.GetOptions{})
+	check(t, err)
+
+	want := projcontour.Status{
+		CurrentStatus: "valid",
+		Description:   `valid HTTPProxy; warning: route "/": service "coredns": port 53 has no TCP endpoints`,
+	}
+	if got.Status != want {
+		t.Fatalf("expected status %+v, got %+v", want, got.Status)
+	}
+}
@@ -22,13 +22,13 @@ import (
 	"time"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
-	envoy "github.com/envoyproxy/go-control-plane/pkg/cache"
+	envoy "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/google/go-cmp/cmp"
-	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/projectcontour/contour/apis/generated/clientset/versioned/fake"
 	"github.com/projectcontour/contour/internal/contour"
 	cgrpc "github.com/projectcontour/contour/internal/grpc"
@@ -38,6 +38,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/testing/protocmp"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/cache"
 )
@@ -160,6 +161,11 @@ func (r *resourceEventHandler) OnAdd(obj interface{}) {
 	switch obj.(type) {
 	case *v1.Endpoints:
 		r.EndpointsTranslator.OnAdd(obj)
+	case *v1.Service:
+		// the EndpointsTranslator uses services to resolve endpoint ports.
+		r.EndpointsTranslator.OnAdd(obj)
+		r.EventHandler.OnAdd(obj)
+		<-r.EventHandler.Sequence
 	default:
 		r.EventHandler.OnAdd(obj)
 		<-r.EventHandler.Sequence
@@ -170,6 +176,11 @@ func (r *resourceEventHandler) OnUpdate(oldObj, newObj interface{}) {
 	switch newObj.(type) {
 	case *v1.Endpoints:
 		r.EndpointsTranslator.OnUpdate(oldObj, newObj)
+	case *v1.Service:
+		// the EndpointsTranslator uses services to resolve endpoint ports.
+		r.EndpointsTranslator.OnUpdate(oldObj, newObj)
+		r.EventHandler.OnUpdate(oldObj, newObj)
+		<-r.EventHandler.Sequence
 	default:
 		r.EventHandler.OnUpdate(oldObj, newObj)
 		<-r.EventHandler.Sequence
@@ -180,6 +191,11 @@ func (r *resourceEventHandler) OnDelete(obj interface{}) {
 	switch obj.(type) {
 	case *v1.Endpoints:
 		r.EndpointsTranslator.OnDelete(obj)
+	case *v1.Service:
+		// the EndpointsTranslator uses services to resolve endpoint ports.
+		r.EndpointsTranslator.OnDelete(obj)
+		r.EventHandler.OnDelete(obj)
+		<-r.EventHandler.Sequence
 	default:
 		r.EventHandler.OnDelete(obj)
 		<-r.EventHandler.Sequence
@@ -215,11 +231,22 @@ type Contour struct {
 }
 
 func (c *Contour) Request(typeurl string, names ...string) *Response {
+	c.Helper()
+	return c.NodeRequest(nil, typeurl, names...)
+}
+
+// NodeRequest is like Request but identifies the requester as node.
+func (c *Contour) NodeRequest(node *envoy_api_v2_core.Node, typeurl string, names ...string) *Response {
 	c.Helper()
 	var st grpcStream
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	switch typeurl {
+	case endpointType:
+		eds := v2.NewEndpointDiscoveryServiceClient(c.ClientConn)
+		ste, err := eds.StreamEndpoints(ctx)
+		c.check(err)
+		st = ste
 	case secretType:
 		sds := discovery.NewSecretDiscoveryServiceClient(c.ClientConn)
 		sts, err := sds.StreamSecrets(ctx)
@@ -230,10 +257,16 @@ func (c *Contour) Request(typeurl string, names ...string) *Response {
 		str, err := rds.StreamRoutes(ctx)
 		c.check(err)
 		st = str
+	case listenerType:
+		lds := v2.NewListenerDiscoveryServiceClient(c.ClientConn)
+		stl, err := lds.StreamListeners(ctx)
+		c.check(err)
+		st = stl
 	default:
 		c.Fatal("unknown typeURL:", typeurl)
 	}
 	resp := c.sendRequest(st, &v2.DiscoveryRequest{
+		Node:          node,
 		TypeUrl:       typeurl,
 		ResourceNames: names,
 	})
@@ -265,23 +298,11 @@ type Response struct {
 func (r *Response) Equals(want *v2.DiscoveryResponse) {
 	r.Helper()
 	opts := []cmp.Option{
-		cmpopts.IgnoreFields(v2.DiscoveryResponse{}, "VersionInfo", "Nonce"),
-		cmpopts.AcyclicTransformer("UnmarshalAny", unmarshalAny),
+		protocmp.Transform(),
+		protocmp.IgnoreFields(proto.MessageV2(&v2.DiscoveryResponse{}), "version_info", "nonce"),
 	}
 	diff := cmp.Diff(want, r.DiscoveryResponse, opts...)
 	if diff != "" {
 		r.Fatal(diff)
 	}
 }
-
-func unmarshalAny(a *any.Any) proto.Message {
-	pb, err := ptypes.Empty(a)
-	if err != nil {
-		panic(err.Error())
-	}
-	err = ptypes.UnmarshalAny(a, pb)
-	if err != nil {
-		panic(err.Error())
-	}
-	return pb
-}
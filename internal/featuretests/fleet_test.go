@@ -0,0 +1,158 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featuretests
+
+import (
+	"testing"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/contour"
+	"github.com/projectcontour/contour/internal/envoy"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestHTTPProxyFleet(t *testing.T) {
+	rh, c, done := setup(t, func(reh *contour.EventHandler) {
+		fleets := &contour.FleetSelector{
+			NodeIDPrefixes: map[string]string{
+				"internal": "envoy-internal-",
+			},
+		}
+		reh.CacheHandler.ListenerCache.FleetSelector = fleets
+		reh.CacheHandler.RouteCache.FleetSelector = fleets
+	})
+	defer done()
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+	rh.OnAdd(svc)
+
+	rh.OnAdd(&projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "public",
+			Namespace: svc.Namespace,
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "www.example.com",
+			},
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: svc.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	})
+
+	rh.OnAdd(&projcontour.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "internal",
+			Namespace: svc.Namespace,
+		},
+		Spec: projcontour.HTTPProxySpec{
+			VirtualHost: &projcontour.VirtualHost{
+				Fqdn: "internal.example.com",
+			},
+			Fleet: "internal",
+			Routes: []projcontour.Route{{
+				Services: []projcontour.Service{{
+					Name: svc.Name,
+					Port: 8080,
+				}},
+			}},
+		},
+	})
+
+	public := &v2.DiscoveryResponse{
+		Resources: resources(t,
+			&v2.RouteConfiguration{
+				Name: "ingress_http",
+				VirtualHosts: virtualhosts(
+					envoy.VirtualHost("www.example.com",
+						envoy.Route(envoy.RoutePrefix("/"), routecluster("default/kuard/8080/da39a3ee5e")),
+					),
+				),
+			},
+			&v2.RouteConfiguration{
+				Name: "ingress_https",
+			},
+		),
+		TypeUrl: routeType,
+	}
+
+	internal := &v2.DiscoveryResponse{
+		Resources: resources(t,
+			&v2.RouteConfiguration{
+				Name: "ingress_http",
+				VirtualHosts: virtualhosts(
+					envoy.VirtualHost("internal.example.com",
+						envoy.Route(envoy.RoutePrefix("/"), routecluster("default/kuard/8080/da39a3ee5e")),
+					),
+					envoy.VirtualHost("www.example.com",
+						envoy.Route(envoy.RoutePrefix("/"), routecluster("default/kuard/8080/da39a3ee5e")),
+					),
+				),
+			},
+			&v2.RouteConfiguration{
+				Name: "ingress_https",
+			},
+		),
+		TypeUrl: routeType,
+	}
+
+	// a node which is not a member of a fleet only sees
+	// the vhosts which are not scoped to a fleet.
+	c.Request(routeType).Equals(public)
+	c.NodeRequest(&envoy_api_v2_core.Node{Id: "envoy-external-1"}, routeType).Equals(public)
+
+	// a node which names its fleet in its metadata.
+	c.NodeRequest(&envoy_api_v2_core.Node{
+		Id: "envoy-1",
+		Metadata: &_struct.Struct{
+			Fields: map[string]*_struct.Value{
+				"fleet": {Kind: &_struct.Value_StringValue{StringValue: "internal"}},
+			},
+		},
+	}, routeType).Equals(internal)
+
+	// a node which is a member of a fleet by its node id.
+	c.NodeRequest(&envoy_api_v2_core.Node{Id: "envoy-internal-1"}, routeType).Equals(internal)
+
+	// a node which is a member of a fleet with no scoped vhosts.
+	c.NodeRequest(&envoy_api_v2_core.Node{
+		Id: "envoy-2",
+		Metadata: &_struct.Struct{
+			Fields: map[string]*_struct.Value{
+				"fleet": {Kind: &_struct.Value_StringValue{StringValue: "staging"}},
+			},
+		},
+	}, routeType).Equals(public)
+}
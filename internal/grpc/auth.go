@@ -0,0 +1,199 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"path"
+
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	"github.com/golang/protobuf/proto"
+	"github.com/projectcontour/contour/internal/envoy"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// Authorizer authorizes xDS clients according to the identity
+// presented in their TLS client certificate. A client's identity
+// is the first of the DNS or URI SANs of its certificate which
+// appears in Identities.
+type Authorizer struct {
+	// Identities maps a client identity to its permissions.
+	Identities map[string]Permissions
+
+	// Default holds the permissions of clients whose
+	// certificate does not name a known identity.
+	Default Permissions
+}
+
+// Permissions describes the resources a client may stream.
+type Permissions struct {
+	// TypeURLs lists the v2 type URLs of the resources the client may
+	// stream. Permission to stream a v2 type URL implies permission to
+	// stream its v3 equivalent. If empty, the client may stream nothing.
+	TypeURLs []string
+
+	// ResourceNames lists the names, or path.Match patterns of the
+	// names, of the resources the client may stream. If empty, the
+	// client may stream any resource of a permitted type.
+	ResourceNames []string
+}
+
+// NewAuthorizer returns an Authorizer which grants clients identified
+// as envoy permission to stream any resource, and all other clients
+// permission to stream any resource other than secrets.
+func NewAuthorizer() *Authorizer {
+	return &Authorizer{
+		Identities: map[string]Permissions{
+			"envoy": {
+				TypeURLs: []string{
+					resource.ClusterType,
+					resource.EndpointType,
+					resource.ListenerType,
+					resource.RouteType,
+					resource.SecretType,
+				},
+			},
+		},
+		Default: Permissions{
+			TypeURLs: []string{
+				resource.ClusterType,
+				resource.EndpointType,
+				resource.ListenerType,
+				resource.RouteType,
+			},
+		},
+	}
+}
+
+// identify returns the identity of the client of the stream
+// associated with ctx, and its permissions.
+func (a *Authorizer) identify(ctx context.Context) (string, Permissions) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", a.Default
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return "", a.Default
+	}
+	cert := info.State.PeerCertificates[0]
+	var sans []string
+	sans = append(sans, cert.DNSNames...)
+	for _, u := range cert.URIs {
+		sans = append(sans, u.String())
+	}
+	for _, san := range sans {
+		if perms, ok := a.Identities[san]; ok {
+			return san, perms
+		}
+	}
+	return cert.Subject.CommonName, a.Default
+}
+
+// allowType returns true if the permissions allow streaming typeURL.
+func (p *Permissions) allowType(typeURL string) bool {
+	for _, t := range p.TypeURLs {
+		if t == typeURL || envoy.V3TypeURL(t) == typeURL {
+			return true
+		}
+	}
+	return false
+}
+
+// allowName returns true if the permissions allow streaming the named resource.
+func (p *Permissions) allowName(name string) bool {
+	if len(p.ResourceNames) == 0 {
+		return true
+	}
+	for _, pattern := range p.ResourceNames {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizedResource restricts the contents of a Resource to
+// those the permissions allow.
+type authorizedResource struct {
+	Resource
+	logrus.FieldLogger
+	perms Permissions
+}
+
+func (r *authorizedResource) Contents() []proto.Message {
+	return r.filter(r.Resource.Contents())
+}
+
+func (r *authorizedResource) Query(names []string) []proto.Message {
+	return r.filter(r.Resource.Query(r.names(names)))
+}
+
+func (r *authorizedResource) NodeContents(node *envoy_api_v2_core.Node) []proto.Message {
+	if nr, ok := r.Resource.(NodeResource); ok {
+		return r.filter(nr.NodeContents(node))
+	}
+	return r.Contents()
+}
+
+func (r *authorizedResource) NodeQuery(node *envoy_api_v2_core.Node, names []string) []proto.Message {
+	if nr, ok := r.Resource.(NodeResource); ok {
+		return r.filter(nr.NodeQuery(node, r.names(names)))
+	}
+	return r.Query(names)
+}
+
+// names returns the subset of names the client may stream,
+// logging those which are denied.
+func (r *authorizedResource) names(names []string) []string {
+	var allowed []string
+	for _, n := range names {
+		if !r.perms.allowName(n) {
+			r.WithField("resource_name", n).Error("permission denied")
+			continue
+		}
+		allowed = append(allowed, n)
+	}
+	return allowed
+}
+
+// filter removes the messages the client may not stream.
+func (r *authorizedResource) filter(msgs []proto.Message) []proto.Message {
+	if len(r.perms.ResourceNames) == 0 {
+		return msgs
+	}
+	var allowed []proto.Message
+	for _, m := range msgs {
+		if r.perms.allowName(resourceName(m)) {
+			allowed = append(allowed, m)
+		}
+	}
+	return allowed
+}
+
+// resourceName returns the name of an xDS resource.
+func resourceName(pb proto.Message) string {
+	switch pb := pb.(type) {
+	case interface{ GetClusterName() string }:
+		// ClusterLoadAssignments are named for their cluster.
+		return pb.GetClusterName()
+	case interface{ GetName() string }:
+		return pb.GetName()
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"net/url"
+	"testing"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/projectcontour/contour/internal/envoy"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestAuthorizerIdentify(t *testing.T) {
+	withCert := func(cert *x509.Certificate) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{
+			AuthInfo: credentials.TLSInfo{
+				State: tls.ConnectionState{
+					PeerCertificates: []*x509.Certificate{cert},
+				},
+			},
+		})
+	}
+
+	monitor := Permissions{
+		TypeURLs: []string{resource.ClusterType},
+	}
+	auth := NewAuthorizer()
+	auth.Identities["spiffe://cluster.local/monitor"] = monitor
+
+	tests := map[string]struct {
+		ctx          context.Context
+		wantIdentity string
+		wantPerms    Permissions
+	}{
+		"no peer": {
+			ctx:          context.Background(),
+			wantIdentity: "",
+			wantPerms:    auth.Default,
+		},
+		"no tls": {
+			ctx:          peer.NewContext(context.Background(), &peer.Peer{}),
+			wantIdentity: "",
+			wantPerms:    auth.Default,
+		},
+		"envoy dns san": {
+			ctx: withCert(&x509.Certificate{
+				Subject:  pkix.Name{CommonName: "envoy"},
+				DNSNames: []string{"envoy", "envoy.projectcontour"},
+			}),
+			wantIdentity: "envoy",
+			wantPerms:    auth.Identities["envoy"],
+		},
+		"uri san": {
+			ctx: withCert(&x509.Certificate{
+				URIs: []*url.URL{{Scheme: "spiffe", Host: "cluster.local", Path: "/monitor"}},
+			}),
+			wantIdentity: "spiffe://cluster.local/monitor",
+			wantPerms:    monitor,
+		},
+		"unknown identity": {
+			ctx: withCert(&x509.Certificate{
+				Subject:  pkix.Name{CommonName: "debug"},
+				DNSNames: []string{"debug", "debug.projectcontour"},
+			}),
+			wantIdentity: "debug",
+			wantPerms:    auth.Default,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			identity, perms := auth.identify(tc.ctx)
+			if diff := cmp.Diff(tc.wantIdentity, identity); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.wantPerms, perms); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestPermissionsAllowType(t *testing.T) {
+	def := NewAuthorizer().Default
+
+	tests := map[string]struct {
+		typeURL string
+		want    bool
+	}{
+		"v2 cluster": {
+			typeURL: resource.ClusterType,
+			want:    true,
+		},
+		"v3 cluster": {
+			typeURL: envoy.V3TypeURL(resource.ClusterType),
+			want:    true,
+		},
+		"v2 secret": {
+			typeURL: resource.SecretType,
+			want:    false,
+		},
+		"v3 secret": {
+			typeURL: envoy.V3TypeURL(resource.SecretType),
+			want:    false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := def.allowType(tc.typeURL)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestAuthorizedResource(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	cla := func(name string) proto.Message {
+		return &v2.ClusterLoadAssignment{ClusterName: name}
+	}
+	var queried []string
+	r := &mockResource{
+		contents: func() []proto.Message {
+			return []proto.Message{cla("default/kuard"), cla("kube-system/dns")}
+		},
+		query: func(names []string) []proto.Message {
+			queried = names
+			var values []proto.Message
+			for _, n := range names {
+				values = append(values, cla(n))
+			}
+			return values
+		},
+	}
+
+	tests := map[string]struct {
+		perms       Permissions
+		names       []string
+		want        []proto.Message
+		wantQueried []string
+	}{
+		"contents, any name": {
+			perms: Permissions{},
+			want:  []proto.Message{cla("default/kuard"), cla("kube-system/dns")},
+		},
+		"contents, restricted names": {
+			perms: Permissions{ResourceNames: []string{"default/*"}},
+			want:  []proto.Message{cla("default/kuard")},
+		},
+		"query, restricted names": {
+			perms:       Permissions{ResourceNames: []string{"default/*"}},
+			names:       []string{"default/kuard", "kube-system/dns"},
+			want:        []proto.Message{cla("default/kuard")},
+			wantQueried: []string{"default/kuard"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			queried = nil
+			ar := &authorizedResource{
+				Resource:    r,
+				FieldLogger: log,
+				perms:       tc.perms,
+			}
+			var got []proto.Message
+			if len(tc.names) == 0 {
+				got = ar.Contents()
+			} else {
+				got = ar.Query(tc.names)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+			if diff := cmp.Diff(tc.wantQueried, queried); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// StreamLimiter limits the number of concurrent streams each client
+// may open, across all of its connections. A client is identified by
+// the first DNS or URI subject alternative name, or else the common name,
+// of its certificate when the server uses TLS, and otherwise by its IP
+// address. Clients behind NAT share an IP address, so should use TLS if they
+// are limited. Streams from clients without an IP address or certificate,
+// such as those connecting over a Unix domain socket, are not limited.
+type StreamLimiter struct {
+	logrus.FieldLogger
+
+	// MaxStreams is the maximum number of concurrent streams per
+	// client. If zero, the number of streams is not limited.
+	MaxStreams int
+
+	mu      sync.Mutex
+	streams map[string]int
+}
+
+// ServerOptions returns the grpc.ServerOptions which install l.
+func (l *StreamLimiter) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.StreamInterceptor(l.StreamInterceptor),
+	}
+}
+
+// StreamInterceptor is a grpc.StreamServerInterceptor which rejects
+// streams which would exceed the client's limit with ResourceExhausted.
+func (l *StreamLimiter) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if l.MaxStreams <= 0 {
+		return handler(srv, ss)
+	}
+	client := clientIdentity(ss.Context())
+	if client == "" {
+		// the client cannot be identified, so cannot be limited.
+		return handler(srv, ss)
+	}
+	if !l.acquire(client) {
+		l.WithField("client", client).WithField("address", clientAddr(ss.Context())).WithField("method", info.FullMethod).Error("stream limit exceeded")
+		return status.Errorf(codes.ResourceExhausted, "client %s exceeded %d concurrent streams", client, l.MaxStreams)
+	}
+	defer l.release(client)
+	return handler(srv, ss)
+}
+
+func (l *StreamLimiter) acquire(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.streams == nil {
+		l.streams = make(map[string]int)
+	}
+	if l.streams[client] >= l.MaxStreams {
+		return false
+	}
+	l.streams[client]++
+	return true
+}
+
+func (l *StreamLimiter) release(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.streams[client]--
+	if l.streams[client] <= 0 {
+		delete(l.streams, client)
+	}
+}
+
+// clientIdentity returns the identity of the client of the stream
+// associated with ctx: the name of its TLS certificate, or else its
+// IP address. If the
+// client has neither, clientIdentity returns the empty string.
+func clientIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+		if name := certificateName(info.State.PeerCertificates[0]); name != "" {
+			return name
+		}
+	}
+	if addr, ok := p.Addr.(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	return ""
+}
+
+// certificateName returns the first DNS or URI subject alternative
+// name of cert, as the Authorizer identifies clients, or its common
+// name if it has neither.
+func certificateName(cert *x509.Certificate) string {
+	switch {
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	default:
+		return cert.Subject.CommonName
+	}
+}
+
+// clientAddr returns the remote address of the
+// client of the stream associated with ctx.
+func clientAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	return p.Addr.String()
+}
+
+// GracefulStop stops s from accepting new connections, then closes
+// drain, if not nil, to end the active streams of the server returned
+// by NewAuthorizedAPI. Ending a stream, after the connection has been
+// sent a GOAWAY, prompts the client to reconnect to another server.
+// GracefulStop waits up to timeout for the streams to finish before
+// closing them. It returns true if every stream finished within the
+// timeout.
+func GracefulStop(s *grpc.Server, drain chan struct{}, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	if drain != nil {
+		close(drain)
+	}
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-done:
+		return true
+	case <-t.C:
+		s.Stop()
+		<-done
+		return false
+	}
+}
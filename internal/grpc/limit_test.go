@@ -0,0 +1,301 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestStreamLimiter(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	l := &StreamLimiter{
+		FieldLogger: log,
+		MaxStreams:  2,
+	}
+
+	// from returns the context of a connection from addr,
+	// presenting cert if not nil.
+	from := func(addr string, cert *x509.Certificate) context.Context {
+		tcp, err := net.ResolveTCPAddr("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := &peer.Peer{Addr: tcp}
+		if cert != nil {
+			p.AuthInfo = credentials.TLSInfo{
+				State: tls.ConnectionState{
+					PeerCertificates: []*x509.Certificate{cert},
+				},
+			}
+		}
+		return peer.NewContext(context.Background(), p)
+	}
+	on := func(conn context.Context) grpc.ServerStream {
+		return &mockServerStream{ctx: conn}
+	}
+	info := &grpc.StreamServerInfo{FullMethod: "/envoy.api.v2.EndpointDiscoveryService/StreamEndpoints"}
+
+	envoy1 := &x509.Certificate{DNSNames: []string{"envoy1"}}
+	envoy2 := &x509.Certificate{Subject: pkix.Name{CommonName: "envoy2"}}
+
+	// hold two streams open from the same address on separate connections,
+	// and two streams from the same certificate at separate addresses.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan error)
+	hold := func(srv interface{}, ss grpc.ServerStream) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+	for _, conn := range []context.Context{
+		from("10.0.0.1:1000", nil),
+		from("10.0.0.1:1001", nil),
+		from("10.0.1.1:1000", envoy1),
+		from("10.0.1.2:1000", envoy1),
+	} {
+		ss := on(conn)
+		go func() {
+			done <- l.StreamInterceptor(nil, ss, info, hold)
+		}()
+		<-started
+	}
+
+	noop := func(srv interface{}, ss grpc.ServerStream) error { return nil }
+
+	// a third stream from the same address is rejected,
+	// even on a new connection.
+	err := l.StreamInterceptor(nil, on(from("10.0.0.1:1002", nil)), info, noop)
+	if got := status.Code(err); got != codes.ResourceExhausted {
+		t.Fatalf("expected: %v, got: %v", codes.ResourceExhausted, err)
+	}
+
+	// a third stream with the same certificate is rejected,
+	// even from another address.
+	err = l.StreamInterceptor(nil, on(from("10.0.1.3:1000", envoy1)), info, noop)
+	if got := status.Code(err); got != codes.ResourceExhausted {
+		t.Fatalf("expected: %v, got: %v", codes.ResourceExhausted, err)
+	}
+
+	// a stream with another certificate is permitted,
+	// even from a limited address.
+	if err := l.StreamInterceptor(nil, on(from("10.0.0.1:1003", envoy2)), info, noop); err != nil {
+		t.Fatal(err)
+	}
+
+	// a stream from another address is permitted.
+	if err := l.StreamInterceptor(nil, on(from("10.0.0.2:1000", nil)), info, noop); err != nil {
+		t.Fatal(err)
+	}
+
+	// a stream from a client without an IP address is not limited.
+	unix := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.UnixAddr{Name: "@", Net: "unix"}})
+	if err := l.StreamInterceptor(nil, on(unix), info, noop); err != nil {
+		t.Fatal(err)
+	}
+
+	// once the held streams finish, the clients may open other streams.
+	close(release)
+	for i := 0; i < 4; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.StreamInterceptor(nil, on(from("10.0.0.1:1002", nil)), info, noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.StreamInterceptor(nil, on(from("10.0.1.3:1000", envoy1)), info, noop); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStreamLimiterServer(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	l := &StreamLimiter{
+		FieldLogger: log,
+		MaxStreams:  1,
+	}
+	r := &mockResource{
+		register: func(ch chan int, i int) {},
+		typeurl:  func() string { return "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment" },
+	}
+	srv := NewAPI(log, map[string]Resource{r.TypeURL(): r}, l.ServerOptions()...)
+	defer srv.Stop()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Serve(lis) // srv now owns lis and will close lis before returning
+	}()
+
+	// stream opens an EDS stream on cc and returns the error, if any,
+	// of its first response. The resource never notifies, so a
+	// permitted stream stays open until the deadline passes.
+	stream := func(cc *grpc.ClientConn) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		st, err := v2.NewEndpointDiscoveryServiceClient(cc).StreamEndpoints(ctx)
+		if err != nil {
+			return err
+		}
+		if err := st.Send(&v2.DiscoveryRequest{TypeUrl: r.TypeURL()}); err != nil {
+			return err
+		}
+		_, err = st.Recv()
+		return err
+	}
+	dial := func() *grpc.ClientConn {
+		cc, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cc
+	}
+
+	cc1 := dial()
+	defer cc1.Close()
+	st, err := v2.NewEndpointDiscoveryServiceClient(cc1).StreamEndpoints(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Send(&v2.DiscoveryRequest{TypeUrl: r.TypeURL()}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// a second stream on the same connection is rejected.
+	if got := status.Code(stream(cc1)); got != codes.ResourceExhausted {
+		t.Fatalf("expected: %v, got: %v", codes.ResourceExhausted, got)
+	}
+
+	// so is a stream on another connection from the same client.
+	cc2 := dial()
+	defer cc2.Close()
+	if got := status.Code(stream(cc2)); got != codes.ResourceExhausted {
+		t.Fatalf("expected: %v, got: %v", codes.ResourceExhausted, got)
+	}
+
+	// once the first stream finishes, the client may open another
+	// stream, which stays open until its deadline.
+	if err := st.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	cc1.Close()
+	time.Sleep(100 * time.Millisecond)
+	if got := status.Code(stream(cc2)); got != codes.DeadlineExceeded {
+		t.Fatalf("expected: %v, got: %v", codes.DeadlineExceeded, got)
+	}
+}
+
+func TestGracefulStop(t *testing.T) {
+	// with a drain channel, active streams end as soon as the
+	// server stops, well within the timeout.
+	drain := make(chan struct{})
+	srv, cc, st := startStream(t, drain)
+	defer cc.Close()
+
+	start := time.Now()
+	if !GracefulStop(srv, drain, 10*time.Second) {
+		t.Fatal("expected active stream to be drained")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected shutdown well within the timeout, took %v", elapsed)
+	}
+	if _, err := st.Recv(); err != io.EOF {
+		t.Fatalf("expected stream to end with: %v, got: %v", io.EOF, err)
+	}
+}
+
+func TestGracefulStopTimeout(t *testing.T) {
+	// without a drain channel, active streams never end so
+	// are forcibly closed once the timeout expires.
+	srv, cc, st := startStream(t, nil)
+	defer cc.Close()
+
+	if GracefulStop(srv, nil, 100*time.Millisecond) {
+		t.Fatal("expected active stream to be forcibly closed")
+	}
+	if _, err := st.Recv(); err == nil {
+		t.Fatal("expected stream to be closed")
+	}
+}
+
+// startStream starts an xDS server and returns it along with a
+// connection to it, and an established EDS stream on that connection
+// which is waiting for an update.
+func startStream(t *testing.T, drain <-chan struct{}) (*grpc.Server, *grpc.ClientConn, v2.EndpointDiscoveryService_StreamEndpointsClient) {
+	t.Helper()
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	// a resource which never notifies, so each stream stays open.
+	r := &mockResource{
+		register: func(ch chan int, i int) {},
+		typeurl:  func() string { return "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment" },
+	}
+	srv := NewAuthorizedAPI(log, map[string]Resource{r.TypeURL(): r}, nil, drain)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Serve(l) // srv now owns l and will close l before returning
+	}()
+
+	cc, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eds := v2.NewEndpointDiscoveryServiceClient(cc)
+	st, err := eds.StreamEndpoints(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Send(&v2.DiscoveryRequest{TypeUrl: r.TypeURL()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for the stream to be established before returning.
+	time.Sleep(100 * time.Millisecond)
+	return srv, cc, st
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (m *mockServerStream) Context() context.Context { return m.ctx }
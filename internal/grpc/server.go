@@ -9,7 +9,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package grpc provides a gRPC implementation of the Envoy v2 xDS API.
+// Package grpc provides a gRPC implementation of the Envoy v2 and v3 xDS APIs.
 package grpc
 
 import (
@@ -25,13 +25,24 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// NewAPI returns a *grpc.Server which responds to the Envoy v2 xDS gRPC API.
+// NewAPI returns a *grpc.Server which responds to the Envoy v2 and v3 xDS
+// gRPC APIs. Resources are registered by their v2 type URL; each is also
+// served, upgraded to v3, under the equivalent v3 type URL.
 func NewAPI(log logrus.FieldLogger, resources map[string]Resource, opts ...grpc.ServerOption) *grpc.Server {
+	return NewAuthorizedAPI(log, resources, nil, nil, opts...)
+}
+
+// NewAuthorizedAPI is like NewAPI but authorizes each stream according
+// to the identity of its client. If auth is nil, every client may stream
+// every resource. Closing drain ends every active stream; see GracefulStop.
+func NewAuthorizedAPI(log logrus.FieldLogger, resources map[string]Resource, auth *Authorizer, drain <-chan struct{}, opts ...grpc.ServerOption) *grpc.Server {
 	g := grpc.NewServer(opts...)
 	s := &grpcServer{
 		xdsHandler{
 			FieldLogger: log,
-			resources:   resources,
+			resources:   withV3Resources(log, resources),
+			auth:        auth,
+			drain:       drain,
 		},
 	}
 
@@ -40,6 +51,7 @@ func NewAPI(log logrus.FieldLogger, resources map[string]Resource, opts ...grpc.
 	v2.RegisterListenerDiscoveryServiceServer(g, s)
 	v2.RegisterRouteDiscoveryServiceServer(g, s)
 	discovery.RegisterSecretDiscoveryServiceServer(g, s)
+	registerV3(g, &s.xdsHandler)
 	return g
 }
 
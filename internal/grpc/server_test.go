@@ -21,8 +21,15 @@ import (
 	"time"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	clusterservice "github.com/envoyproxy/go-control-plane/envoy/service/cluster/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
-	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	endpointservice "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
+	listenerservice "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
+	routeservice "github.com/envoyproxy/go-control-plane/envoy/service/route/v3"
+	secretservice "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/projectcontour/contour/internal/contour"
 	"github.com/projectcontour/contour/internal/metrics"
 	"github.com/prometheus/client_golang/prometheus"
@@ -65,9 +72,9 @@ func TestGRPC(t *testing.T) {
 			defer cancel()
 			stream, err := sds.StreamClusters(ctx)
 			check(t, err)
-			sendreq(t, stream, cache.ClusterType) // send initial notification
-			checkrecv(t, stream)                  // check we receive one notification
-			checktimeout(t, stream)               // check that the second receive times out
+			sendreq(t, stream, resource.ClusterType) // send initial notification
+			checkrecv(t, stream)                     // check we receive one notification
+			checktimeout(t, stream)                  // check that the second receive times out
 		},
 		"StreamEndpoints": func(t *testing.T, cc *grpc.ClientConn) {
 			et.OnAdd(&v1.Endpoints{
@@ -92,9 +99,9 @@ func TestGRPC(t *testing.T) {
 			defer cancel()
 			stream, err := eds.StreamEndpoints(ctx)
 			check(t, err)
-			sendreq(t, stream, cache.EndpointType) // send initial notification
-			checkrecv(t, stream)                   // check we receive one notification
-			checktimeout(t, stream)                // check that the second receive times out
+			sendreq(t, stream, resource.EndpointType) // send initial notification
+			checkrecv(t, stream)                      // check we receive one notification
+			checktimeout(t, stream)                   // check that the second receive times out
 		},
 		"StreamListeners": func(t *testing.T, cc *grpc.ClientConn) {
 			// add an ingress, which will create a non tls listener
@@ -125,9 +132,9 @@ func TestGRPC(t *testing.T) {
 			defer cancel()
 			stream, err := lds.StreamListeners(ctx)
 			check(t, err)
-			sendreq(t, stream, cache.ListenerType) // send initial notification
-			checkrecv(t, stream)                   // check we receive one notification
-			checktimeout(t, stream)                // check that the second receive times out
+			sendreq(t, stream, resource.ListenerType) // send initial notification
+			checkrecv(t, stream)                      // check we receive one notification
+			checktimeout(t, stream)                   // check that the second receive times out
 		},
 		"StreamRoutes": func(t *testing.T, cc *grpc.ClientConn) {
 			eh.OnAdd(&v1beta1.Ingress{
@@ -157,9 +164,9 @@ func TestGRPC(t *testing.T) {
 			defer cancel()
 			stream, err := rds.StreamRoutes(ctx)
 			check(t, err)
-			sendreq(t, stream, cache.RouteType) // send initial notification
-			checkrecv(t, stream)                // check we receive one notification
-			checktimeout(t, stream)             // check that the second receive times out
+			sendreq(t, stream, resource.RouteType) // send initial notification
+			checkrecv(t, stream)                   // check we receive one notification
+			checktimeout(t, stream)                // check that the second receive times out
 		},
 		"StreamSecrets": func(t *testing.T, cc *grpc.ClientConn) {
 			eh.OnAdd(&v1.Secret{
@@ -178,9 +185,123 @@ func TestGRPC(t *testing.T) {
 			defer cancel()
 			stream, err := sds.StreamSecrets(ctx)
 			check(t, err)
-			sendreq(t, stream, cache.SecretType) // send initial notification
-			checkrecv(t, stream)                 // check we receive one notification
-			checktimeout(t, stream)              // check that the second receive times out
+			sendreq(t, stream, resource.SecretType) // send initial notification
+			checkrecv(t, stream)                    // check we receive one notification
+			checktimeout(t, stream)                 // check that the second receive times out
+		},
+		"StreamClustersV3": func(t *testing.T, cc *grpc.ClientConn) {
+			eh.OnAdd(&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "simple",
+					Namespace: "default",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{{
+						Protocol:   "TCP",
+						Port:       80,
+						TargetPort: intstr.FromInt(6502),
+					}},
+				},
+			})
+			eh.OnAdd(&v1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "simple",
+					Namespace: "default",
+				},
+				Spec: v1beta1.IngressSpec{
+					Backend: &v1beta1.IngressBackend{
+						ServiceName: "simple",
+						ServicePort: intstr.FromInt(80),
+					},
+				},
+			})
+
+			cds := clusterservice.NewClusterDiscoveryServiceClient(cc)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stream, err := cds.StreamClusters(ctx)
+			check(t, err)
+			sendreqv3(t, stream, resourcev3.ClusterType)   // send initial notification
+			checkrecvv3(t, stream, resourcev3.ClusterType) // check we receive one v3 notification
+			checktimeoutv3(t, stream)                      // check that the second receive times out
+		},
+		"StreamListenersV3": func(t *testing.T, cc *grpc.ClientConn) {
+			eh.OnAdd(&v1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "httpbin-org",
+					Namespace: "default",
+				},
+				Spec: v1beta1.IngressSpec{
+					Backend: &v1beta1.IngressBackend{
+						ServiceName: "httpbin-org",
+						ServicePort: intstr.FromInt(80),
+					},
+				},
+			})
+
+			lds := listenerservice.NewListenerDiscoveryServiceClient(cc)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stream, err := lds.StreamListeners(ctx)
+			check(t, err)
+			sendreqv3(t, stream, resourcev3.ListenerType)   // send initial notification
+			checkrecvv3(t, stream, resourcev3.ListenerType) // check we receive one v3 notification
+			checktimeoutv3(t, stream)                       // check that the second receive times out
+		},
+		"StreamRoutesV3": func(t *testing.T, cc *grpc.ClientConn) {
+			rds := routeservice.NewRouteDiscoveryServiceClient(cc)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stream, err := rds.StreamRoutes(ctx)
+			check(t, err)
+			sendreqv3(t, stream, resourcev3.RouteType)   // send initial notification
+			checkrecvv3(t, stream, resourcev3.RouteType) // check we receive one v3 notification
+			checktimeoutv3(t, stream)                    // check that the second receive times out
+		},
+		"StreamEndpointsV3": func(t *testing.T, cc *grpc.ClientConn) {
+			et.OnAdd(&v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kube-scheduler",
+					Namespace: "kube-system",
+				},
+				Subsets: []v1.EndpointSubset{{
+					Addresses: []v1.EndpointAddress{{
+						IP: "130.211.139.167",
+					}},
+					Ports: []v1.EndpointPort{{
+						Port: 80,
+					}},
+				}},
+			})
+
+			eds := endpointservice.NewEndpointDiscoveryServiceClient(cc)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stream, err := eds.StreamEndpoints(ctx)
+			check(t, err)
+			sendreqv3(t, stream, resourcev3.EndpointType)   // send initial notification
+			checkrecvv3(t, stream, resourcev3.EndpointType) // check we receive one v3 notification
+			checktimeoutv3(t, stream)                       // check that the second receive times out
+		},
+		"StreamSecretsV3": func(t *testing.T, cc *grpc.ClientConn) {
+			sds := secretservice.NewSecretDiscoveryServiceClient(cc)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stream, err := sds.StreamSecrets(ctx)
+			check(t, err)
+			sendreqv3(t, stream, resourcev3.SecretType)   // send initial notification
+			checkrecvv3(t, stream, resourcev3.SecretType) // check we receive one v3 notification
+			checktimeoutv3(t, stream)                     // check that the second receive times out
+		},
+		"StreamClustersV3WrongTypeURL": func(t *testing.T, cc *grpc.ClientConn) {
+			// the stream is selected by type URL, not by the service it arrived on.
+			cds := clusterservice.NewClusterDiscoveryServiceClient(cc)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			stream, err := cds.StreamClusters(ctx)
+			check(t, err)
+			sendreqv3(t, stream, resourcev3.ListenerType)
+			checkrecvv3(t, stream, resourcev3.ListenerType)
 		},
 	}
 
@@ -258,6 +379,45 @@ func checktimeout(t *testing.T, stream interface {
 }) {
 	t.Helper()
 	_, err := stream.Recv()
+	checkdeadline(t, err)
+}
+
+func sendreqv3(t *testing.T, stream interface {
+	Send(*discoveryv3.DiscoveryRequest) error
+}, typeurl string) {
+	t.Helper()
+	err := stream.Send(&discoveryv3.DiscoveryRequest{
+		TypeUrl: typeurl,
+	})
+	check(t, err)
+}
+
+func checkrecvv3(t *testing.T, stream interface {
+	Recv() (*discoveryv3.DiscoveryResponse, error)
+}, typeurl string) {
+	t.Helper()
+	resp, err := stream.Recv()
+	check(t, err)
+	if resp.TypeUrl != typeurl {
+		t.Fatalf("expected type url %q, got %q", typeurl, resp.TypeUrl)
+	}
+	for _, r := range resp.Resources {
+		if r.TypeUrl != typeurl {
+			t.Fatalf("expected resource type url %q, got %q", typeurl, r.TypeUrl)
+		}
+	}
+}
+
+func checktimeoutv3(t *testing.T, stream interface {
+	Recv() (*discoveryv3.DiscoveryResponse, error)
+}) {
+	t.Helper()
+	_, err := stream.Recv()
+	checkdeadline(t, err)
+}
+
+func checkdeadline(t *testing.T, err error) {
+	t.Helper()
 	if err == nil {
 		t.Fatal("expected timeout")
 	}
@@ -0,0 +1,176 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	clusterservice "github.com/envoyproxy/go-control-plane/envoy/service/cluster/v3"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	endpointservice "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
+	listenerservice "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
+	routeservice "github.com/envoyproxy/go-control-plane/envoy/service/route/v3"
+	secretservice "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
+	"github.com/golang/protobuf/proto"
+	"github.com/projectcontour/contour/internal/envoy"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// registerV3 registers the v3 xDS services on g. Requests for v3 resources
+// are served by the same xdsHandler as the v2 API, which selects the resource
+// to stream according to the type URL of each request.
+func registerV3(g *grpc.Server, xh *xdsHandler) {
+	s := &grpcServerV3{xdsHandler: xh}
+	clusterservice.RegisterClusterDiscoveryServiceServer(g, s)
+	endpointservice.RegisterEndpointDiscoveryServiceServer(g, s)
+	listenerservice.RegisterListenerDiscoveryServiceServer(g, s)
+	routeservice.RegisterRouteDiscoveryServiceServer(g, s)
+	secretservice.RegisterSecretDiscoveryServiceServer(g, s)
+}
+
+// withV3Resources returns a copy of resources with an additional entry,
+// keyed by the v3 type URL, for each resource which has a v3 equivalent.
+func withV3Resources(log logrus.FieldLogger, resources map[string]Resource) map[string]Resource {
+	all := make(map[string]Resource, len(resources)*2)
+	for typeURL, r := range resources {
+		all[typeURL] = r
+		if v3 := envoy.V3TypeURL(r.TypeURL()); v3 != "" {
+			if _, ok := resources[v3]; !ok {
+				all[v3] = &v3Resource{
+					Resource:    r,
+					FieldLogger: log.WithField("type_url", v3),
+					typeURL:     v3,
+				}
+			}
+		}
+	}
+	return all
+}
+
+// v3Resource presents the contents of a v2 Resource as v3 messages.
+type v3Resource struct {
+	Resource
+	logrus.FieldLogger
+	typeURL string
+}
+
+func (r *v3Resource) Contents() []proto.Message {
+	return r.upgrade(r.Resource.Contents())
+}
+
+func (r *v3Resource) Query(names []string) []proto.Message {
+	return r.upgrade(r.Resource.Query(names))
+}
+
+func (r *v3Resource) NodeContents(node *envoy_api_v2_core.Node) []proto.Message {
+	if nr, ok := r.Resource.(NodeResource); ok {
+		return r.upgrade(nr.NodeContents(node))
+	}
+	return r.Contents()
+}
+
+func (r *v3Resource) NodeQuery(node *envoy_api_v2_core.Node, names []string) []proto.Message {
+	if nr, ok := r.Resource.(NodeResource); ok {
+		return r.upgrade(nr.NodeQuery(node, names))
+	}
+	return r.Query(names)
+}
+
+func (r *v3Resource) TypeURL() string { return r.typeURL }
+
+func (r *v3Resource) upgrade(msgs []proto.Message) []proto.Message {
+	values := make([]proto.Message, 0, len(msgs))
+	for _, m := range msgs {
+		v, err := envoy.Upgrade(m)
+		if err != nil {
+			r.WithError(err).Error("failed to upgrade resource to v3")
+			continue
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// v3Stream adapts a v3 xDS stream to the grpcStream interface. The v2 and
+// v3 discovery messages are wire compatible so each message is translated
+// by way of its encoding.
+type v3Stream struct {
+	v3ServerStream
+}
+
+type v3ServerStream interface {
+	Context() context.Context
+	Send(*discoveryv3.DiscoveryResponse) error
+	Recv() (*discoveryv3.DiscoveryRequest, error)
+}
+
+func (s v3Stream) Send(resp *envoy_api_v2.DiscoveryResponse) error {
+	var r discoveryv3.DiscoveryResponse
+	if err := convert(resp, &r); err != nil {
+		return err
+	}
+	return s.v3ServerStream.Send(&r)
+}
+
+func (s v3Stream) Recv() (*envoy_api_v2.DiscoveryRequest, error) {
+	req, err := s.v3ServerStream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	var r envoy_api_v2.DiscoveryRequest
+	return &r, convert(req, &r)
+}
+
+func convert(src, dst proto.Message) error {
+	buf, err := proto.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(buf, dst)
+}
+
+// grpcServerV3 implements the v3 LDS, RDS, CDS, EDS, and SDS gRPC endpoints.
+type grpcServerV3 struct {
+	*xdsHandler
+
+	// Delta and Fetch methods are not supported.
+	clusterservice.UnimplementedClusterDiscoveryServiceServer
+	endpointservice.UnimplementedEndpointDiscoveryServiceServer
+	listenerservice.UnimplementedListenerDiscoveryServiceServer
+	routeservice.UnimplementedRouteDiscoveryServiceServer
+	secretservice.UnimplementedSecretDiscoveryServiceServer
+}
+
+func (s *grpcServerV3) StreamClusters(srv clusterservice.ClusterDiscoveryService_StreamClustersServer) error {
+	return s.stream(v3Stream{srv})
+}
+
+func (s *grpcServerV3) StreamEndpoints(srv endpointservice.EndpointDiscoveryService_StreamEndpointsServer) error {
+	return s.stream(v3Stream{srv})
+}
+
+func (s *grpcServerV3) StreamListeners(srv listenerservice.ListenerDiscoveryService_StreamListenersServer) error {
+	return s.stream(v3Stream{srv})
+}
+
+func (s *grpcServerV3) StreamRoutes(srv routeservice.RouteDiscoveryService_StreamRoutesServer) error {
+	return s.stream(v3Stream{srv})
+}
+
+func (s *grpcServerV3) StreamSecrets(srv secretservice.SecretDiscoveryService_StreamSecretsServer) error {
+	return s.stream(v3Stream{srv})
+}
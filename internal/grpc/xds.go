@@ -20,9 +20,12 @@ import (
 	"sync/atomic"
 
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Resource represents a source of proto.Messages that can be registered
@@ -41,11 +44,23 @@ type Resource interface {
 	TypeURL() string
 }
 
+// NodeResource is implemented by Resources whose contents depend
+// on the Envoy node which requested them.
+type NodeResource interface {
+	// NodeContents returns the contents of this resource for node.
+	NodeContents(node *envoy_api_v2_core.Node) []proto.Message
+
+	// NodeQuery returns an entry for each resource name supplied for node.
+	NodeQuery(node *envoy_api_v2_core.Node, names []string) []proto.Message
+}
+
 // xdsHandler implements the Envoy xDS gRPC protocol.
 type xdsHandler struct {
 	logrus.FieldLogger
 	connections counter
 	resources   map[string]Resource // registered resource types
+	auth        *Authorizer         // optional, authorizes each client
+	drain       <-chan struct{}     // optional, ends each stream when closed
 }
 
 type grpcStream interface {
@@ -69,6 +84,14 @@ func (xh *xdsHandler) stream(st grpcStream) (err error) {
 		}
 	}()
 
+	// if authorization is enabled, identify the client.
+	var perms *Permissions
+	if xh.auth != nil {
+		identity, p := xh.auth.identify(st.Context())
+		log = log.WithField("identity", identity)
+		perms = &p
+	}
+
 	ch := make(chan int, 1)
 
 	// internally all registration values start at zero so sending
@@ -105,6 +128,17 @@ func (xh *xdsHandler) stream(st grpcStream) (err error) {
 			return fmt.Errorf("no resource registered for typeURL %q", req.TypeUrl)
 		}
 		log = log.WithField("resource_names", req.ResourceNames).WithField("type_url", req.TypeUrl)
+		if perms != nil {
+			if !perms.allowType(req.TypeUrl) {
+				log.Error("permission denied")
+				return status.Errorf(codes.PermissionDenied, "not permitted to stream %q", req.TypeUrl)
+			}
+			r = &authorizedResource{
+				Resource:    r,
+				FieldLogger: log,
+				perms:       *perms,
+			}
+		}
 		log.Info("stream_wait")
 
 		// now we wait for a notification, if this is the first request received on this
@@ -116,16 +150,7 @@ func (xh *xdsHandler) stream(st grpcStream) (err error) {
 			// TODO(dfc) the thing that has changed may not be in the scope of the filter
 			// so we're going to be sending an update that is a no-op. See #426
 
-			var resources []proto.Message
-			switch len(req.ResourceNames) {
-			case 0:
-				// no resource hints supplied, return the full
-				// contents of the resource
-				resources = r.Contents()
-			default:
-				// resource hints supplied, return exactly those
-				resources = r.Query(req.ResourceNames)
-			}
+			resources := query(r, req)
 
 			any, err := toAny(r.TypeURL(), resources)
 			if err != nil {
@@ -144,7 +169,31 @@ func (xh *xdsHandler) stream(st grpcStream) (err error) {
 			log.WithField("count", len(resources)).Info("response")
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-xh.drain:
+			// the server is shutting down, end the stream so
+			// the client reconnects to another server.
+			return nil
+		}
+	}
+}
+
+// query returns the contents of r requested by req.
+func query(r Resource, req *envoy_api_v2.DiscoveryRequest) []proto.Message {
+	nr, ok := r.(NodeResource)
+	switch len(req.ResourceNames) {
+	case 0:
+		// no resource hints supplied, return the full
+		// contents of the resource
+		if ok {
+			return nr.NodeContents(req.Node)
+		}
+		return r.Contents()
+	default:
+		// resource hints supplied, return exactly those
+		if ok {
+			return nr.NodeQuery(req.Node, req.ResourceNames)
 		}
+		return r.Query(req.ResourceNames)
 	}
 }
 
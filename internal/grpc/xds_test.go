@@ -21,8 +21,11 @@ import (
 	"testing"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	resource "github.com/envoyproxy/go-control-plane/pkg/resource/v2"
 	"github.com/golang/protobuf/proto"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestXDSHandlerStream(t *testing.T) {
@@ -55,6 +58,26 @@ func TestXDSHandlerStream(t *testing.T) {
 			},
 			want: fmt.Errorf("no resource registered for typeURL %q", "com.heptio.potato"),
 		},
+		"type not permitted": {
+			xh: xdsHandler{
+				FieldLogger: log,
+				resources: map[string]Resource{
+					resource.SecretType: &mockResource{
+						typeurl: func() string { return resource.SecretType },
+					},
+				},
+				auth: NewAuthorizer(),
+			},
+			stream: &mockStream{
+				context: context.Background,
+				recv: func() (*v2.DiscoveryRequest, error) {
+					return &v2.DiscoveryRequest{
+						TypeUrl: resource.SecretType,
+					}, nil
+				},
+			},
+			want: status.Errorf(codes.PermissionDenied, "not permitted to stream %q", resource.SecretType),
+		},
 		"failed to convert values to any": {
 			xh: xdsHandler{
 				FieldLogger: log,
@@ -29,6 +29,11 @@ type Service struct {
 	Addr string
 	Port int
 
+	// CertFile and KeyFile, if set, are the certificate and key
+	// files with which the Service serves HTTPS.
+	CertFile string
+	KeyFile  string
+
 	logrus.FieldLogger
 	http.ServeMux
 }
@@ -64,5 +69,8 @@ func (svc *Service) Start(stop <-chan struct{}) (err error) {
 	}()
 
 	svc.WithField("address", s.Addr).Info("started")
+	if svc.CertFile != "" || svc.KeyFile != "" {
+		return s.ListenAndServeTLS(svc.CertFile, svc.KeyFile)
+	}
 	return s.ListenAndServe()
 }
@@ -0,0 +1,146 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha1"
+	gatewayclientset "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
+)
+
+// GatewayAPIStatus allows for updating the status of Gateway API objects.
+type GatewayAPIStatus struct {
+	Client gatewayclientset.Interface
+
+	// Controller is the controller name Contour
+	// records in the status of routes.
+	Controller string
+}
+
+// SetGatewayClassStatus sets the conditions of the GatewayClass.
+func (gs *GatewayAPIStatus) SetGatewayClassStatus(existing *gatewayapi.GatewayClass, conditions []metav1.Condition) error {
+	status := gatewayapi.GatewayClassStatus{
+		Conditions: mergeConditions(existing.Status.Conditions, conditions),
+	}
+	if equality.Semantic.DeepEqual(existing.Status, status) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Status = status
+	_, err := gs.Client.NetworkingV1alpha1().GatewayClasses().UpdateStatus(context.TODO(), updated, metav1.UpdateOptions{})
+	return err
+}
+
+// SetGatewayStatus sets the conditions and listener statuses of the Gateway.
+func (gs *GatewayAPIStatus) SetGatewayStatus(existing *gatewayapi.Gateway, conditions []metav1.Condition, listeners []gatewayapi.ListenerStatus) error {
+	status := gatewayapi.GatewayStatus{
+		Addresses:  existing.Status.Addresses,
+		Conditions: mergeConditions(existing.Status.Conditions, conditions),
+	}
+	for _, l := range listeners {
+		for _, el := range existing.Status.Listeners {
+			if el.Port == l.Port && el.Protocol == l.Protocol && equality.Semantic.DeepEqual(el.Hostname, l.Hostname) {
+				l.Conditions = mergeConditions(el.Conditions, l.Conditions)
+				break
+			}
+		}
+		l.Conditions = mergeConditions(nil, l.Conditions)
+		status.Listeners = append(status.Listeners, l)
+	}
+	if equality.Semantic.DeepEqual(existing.Status, status) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Status = status
+	_, err := gs.Client.NetworkingV1alpha1().Gateways(existing.Namespace).UpdateStatus(context.TODO(), updated, metav1.UpdateOptions{})
+	return err
+}
+
+// SetHTTPRouteStatus sets the status of the HTTPRoute for the Gateways
+// of Contour's GatewayClasses, retaining the status of other Gateways.
+func (gs *GatewayAPIStatus) SetHTTPRouteStatus(existing *gatewayapi.HTTPRoute, gateways []gatewayapi.RouteGatewayStatus) error {
+	status := gatewayapi.HTTPRouteStatus{
+		RouteStatus: gs.routeStatus(existing.Status.RouteStatus, gateways),
+	}
+	if equality.Semantic.DeepEqual(existing.Status, status) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Status = status
+	_, err := gs.Client.NetworkingV1alpha1().HTTPRoutes(existing.Namespace).UpdateStatus(context.TODO(), updated, metav1.UpdateOptions{})
+	return err
+}
+
+// SetTLSRouteStatus sets the status of the TLSRoute for the Gateways
+// of Contour's GatewayClasses, retaining the status of other Gateways.
+func (gs *GatewayAPIStatus) SetTLSRouteStatus(existing *gatewayapi.TLSRoute, gateways []gatewayapi.RouteGatewayStatus) error {
+	status := gatewayapi.TLSRouteStatus{
+		RouteStatus: gs.routeStatus(existing.Status.RouteStatus, gateways),
+	}
+	if equality.Semantic.DeepEqual(existing.Status, status) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Status = status
+	_, err := gs.Client.NetworkingV1alpha1().TLSRoutes(existing.Namespace).UpdateStatus(context.TODO(), updated, metav1.UpdateOptions{})
+	return err
+}
+
+// routeStatus replaces the entries of Contour's Gateways in existing with gateways.
+func (gs *GatewayAPIStatus) routeStatus(existing gatewayapi.RouteStatus, gateways []gatewayapi.RouteGatewayStatus) gatewayapi.RouteStatus {
+	var status gatewayapi.RouteStatus
+	for _, eg := range existing.Gateways {
+		if eg.GatewayRef.Controller == nil || *eg.GatewayRef.Controller != gs.Controller {
+			status.Gateways = append(status.Gateways, eg)
+		}
+	}
+	for _, g := range gateways {
+		for _, eg := range existing.Gateways {
+			if eg.GatewayRef.Name == g.GatewayRef.Name && eg.GatewayRef.Namespace == g.GatewayRef.Namespace {
+				g.Conditions = mergeConditions(eg.Conditions, g.Conditions)
+				break
+			}
+		}
+		g.Conditions = mergeConditions(nil, g.Conditions)
+		status.Gateways = append(status.Gateways, g)
+	}
+	if status.Gateways == nil {
+		// gateways is a required field.
+		status.Gateways = []gatewayapi.RouteGatewayStatus{}
+	}
+	return status
+}
+
+// mergeConditions returns conditions with the LastTransitionTime of the
+// matching existing condition if its status is unchanged, or now.
+func mergeConditions(existing, conditions []metav1.Condition) []metav1.Condition {
+	now := metav1.Now()
+	merged := make([]metav1.Condition, 0, len(conditions))
+	for _, cond := range conditions {
+		if cond.LastTransitionTime.IsZero() {
+			cond.LastTransitionTime = now
+			for _, ec := range existing {
+				if ec.Type == cond.Type && ec.Status == cond.Status {
+					cond.LastTransitionTime = ec.LastTransitionTime
+					break
+				}
+			}
+		}
+		merged = append(merged, cond)
+	}
+	return merged
+}
@@ -0,0 +1,148 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stesting "k8s.io/client-go/testing"
+	gatewayapi "sigs.k8s.io/gateway-api/apis/v1alpha1"
+	"sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/fake"
+)
+
+func TestSetHTTPRouteStatus(t *testing.T) {
+	contour := "projectcontour.io/contour"
+	other := "example.com/other"
+	then := metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	admitted := func(gateway, controller string, ltt metav1.Time) gatewayapi.RouteGatewayStatus {
+		return gatewayapi.RouteGatewayStatus{
+			GatewayRef: gatewayapi.RouteStatusGatewayReference{
+				Name:       gateway,
+				Namespace:  "default",
+				Controller: &controller,
+			},
+			Conditions: []metav1.Condition{{
+				Type:               string(gatewayapi.ConditionRouteAdmitted),
+				Status:             metav1.ConditionTrue,
+				Reason:             "Admitted",
+				LastTransitionTime: ltt,
+			}},
+		}
+	}
+
+	tests := map[string]struct {
+		existing      []gatewayapi.RouteGatewayStatus
+		gateways      []gatewayapi.RouteGatewayStatus
+		expectedVerbs []string
+		want          []gatewayapi.RouteGatewayStatus
+	}{
+		"no update": {
+			existing:      []gatewayapi.RouteGatewayStatus{admitted("contour", contour, then)},
+			gateways:      []gatewayapi.RouteGatewayStatus{admitted("contour", contour, metav1.Time{})},
+			expectedVerbs: []string{},
+		},
+		"retain the status of other controllers": {
+			existing:      []gatewayapi.RouteGatewayStatus{admitted("other", other, then)},
+			gateways:      []gatewayapi.RouteGatewayStatus{admitted("contour", contour, then)},
+			expectedVerbs: []string{"update"},
+			want: []gatewayapi.RouteGatewayStatus{
+				admitted("other", other, then),
+				admitted("contour", contour, then),
+			},
+		},
+		"remove gateways which no longer select the route": {
+			existing: []gatewayapi.RouteGatewayStatus{
+				admitted("other", other, then),
+				admitted("contour", contour, then),
+			},
+			expectedVerbs: []string{"update"},
+			want: []gatewayapi.RouteGatewayStatus{
+				admitted("other", other, then),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			existing := &gatewayapi.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kuard",
+					Namespace: "default",
+				},
+				Status: gatewayapi.HTTPRouteStatus{
+					RouteStatus: gatewayapi.RouteStatus{
+						Gateways: tc.existing,
+					},
+				},
+			}
+			client := fake.NewSimpleClientset(existing)
+			client.ClearActions()
+
+			gs := GatewayAPIStatus{
+				Client:     client,
+				Controller: contour,
+			}
+			if err := gs.SetHTTPRouteStatus(existing, tc.gateways); err != nil {
+				t.Fatal(err)
+			}
+
+			var verbs []string
+			for _, a := range client.Actions() {
+				verbs = append(verbs, a.GetVerb())
+				if update, ok := a.(k8stesting.UpdateAction); ok {
+					got := update.GetObject().(*gatewayapi.HTTPRoute).Status.Gateways
+					if diff := cmp.Diff(tc.want, got); diff != "" {
+						t.Fatal(diff)
+					}
+				}
+			}
+			if len(verbs) != len(tc.expectedVerbs) {
+				t.Fatalf("expected verbs %v, got %v", tc.expectedVerbs, verbs)
+			}
+		})
+	}
+}
+
+func TestMergeConditions(t *testing.T) {
+	then := metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	existing := []metav1.Condition{{
+		Type:               "Ready",
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: then,
+	}, {
+		Type:               "Scheduled",
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: then,
+	}}
+
+	got := mergeConditions(existing, []metav1.Condition{{
+		Type:   "Ready",
+		Status: metav1.ConditionFalse,
+	}, {
+		Type:   "Scheduled",
+		Status: metav1.ConditionTrue,
+	}})
+
+	if got[0].LastTransitionTime.Equal(&then) {
+		t.Errorf("expected the transition time of a changed condition to be updated")
+	}
+	if !got[1].LastTransitionTime.Equal(&then) {
+		t.Errorf("expected the transition time of an unchanged condition to be retained, got %v", got[1].LastTransitionTime)
+	}
+}
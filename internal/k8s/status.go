@@ -15,12 +15,14 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	ingressroutev1 "github.com/projectcontour/contour/apis/contour/v1beta1"
 	clientset "github.com/projectcontour/contour/apis/generated/clientset/versioned"
 	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -81,7 +83,7 @@ func (irs *CRDStatus) setIngressRouteStatus(existing, updated *ingressroutev1.In
 		return err
 	}
 
-	_, err = irs.Client.ContourV1beta1().IngressRoutes(existing.GetNamespace()).Patch(existing.GetName(), types.MergePatchType, patchBytes)
+	_, err = irs.Client.ContourV1beta1().IngressRoutes(existing.GetNamespace()).Patch(context.TODO(), existing.GetName(), types.MergePatchType, patchBytes, metav1.PatchOptions{})
 	return err
 }
 
@@ -103,6 +105,6 @@ func (irs *CRDStatus) setHTTPProxyStatus(existing, updated *projcontour.HTTPProx
 		return err
 	}
 
-	_, err = irs.Client.ProjectcontourV1alpha1().HTTPProxies(existing.GetNamespace()).Patch(existing.GetName(), types.MergePatchType, patchBytes)
+	_, err = irs.Client.ProjectcontourV1alpha1().HTTPProxies(existing.GetNamespace()).Patch(context.TODO(), existing.GetName(), types.MergePatchType, patchBytes, metav1.PatchOptions{})
 	return err
 }
@@ -38,6 +38,8 @@ type Metrics struct {
 	CacheHandlerOnUpdateSummary prometheus.Summary
 	ResourceEventHandlerSummary *prometheus.SummaryVec
 
+	eventHandlerIgnoredCounter *prometheus.CounterVec
+
 	// Keep a local cache of metrics for comparison on updates
 	metricCache *IngressRouteMetric
 }
@@ -63,6 +65,7 @@ const (
 	IngressRouteValidGauge      = "contour_ingressroute_valid_total"
 	IngressRouteOrphanedGauge   = "contour_ingressroute_orphaned_total"
 	IngressRouteDAGRebuildGauge = "contour_ingressroute_dagrebuild_timestamp"
+	EventHandlerIgnoredCounter  = "contour_eventhandler_ignored_total"
 
 	cacheHandlerOnUpdateSummary = "contour_cachehandler_onupdate_duration_seconds"
 	resourceEventHandlerSummary = "contour_resourceeventhandler_duration_seconds"
@@ -127,6 +130,13 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 		},
 			[]string{"op"},
 		),
+		eventHandlerIgnoredCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: EventHandlerIgnoredCounter,
+				Help: "Total number of Kubernetes events which did not cause a DAG rebuild",
+			},
+			[]string{"kind"},
+		),
 	}
 	m.register(registry)
 	return &m
@@ -143,6 +153,7 @@ func (m *Metrics) register(registry *prometheus.Registry) {
 		m.ingressRouteDAGRebuildGauge,
 		m.CacheHandlerOnUpdateSummary,
 		m.ResourceEventHandlerSummary,
+		m.eventHandlerIgnoredCounter,
 	)
 }
 
@@ -151,6 +162,12 @@ func (m *Metrics) SetDAGLastRebuilt(ts time.Time) {
 	m.ingressRouteDAGRebuildGauge.WithLabelValues().Set(float64(ts.Unix()))
 }
 
+// IncEventHandlerIgnored records that an event for an
+// object of kind did not cause a DAG rebuild.
+func (m *Metrics) IncEventHandlerIgnored(kind string) {
+	m.eventHandlerIgnoredCounter.WithLabelValues(kind).Inc()
+}
+
 // SetIngressRouteMetric sets metric values for a set of IngressRoutes
 func (m *Metrics) SetIngressRouteMetric(metrics IngressRouteMetric) {
 	// Process metrics
@@ -722,3 +722,38 @@ func TestRemoveMetric(t *testing.T) {
 		})
 	}
 }
+
+func TestIncEventHandlerIgnored(t *testing.T) {
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+	m.IncEventHandlerIgnored("Secret")
+	m.IncEventHandlerIgnored("Secret")
+	m.IncEventHandlerIgnored("Service")
+
+	gathering, err := r.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]float64{}
+	for _, mf := range gathering {
+		if mf.GetName() != EventHandlerIgnoredCounter {
+			continue
+		}
+		for _, metric := range mf.Metric {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "kind" {
+					got[label.GetValue()] = metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	want := map[string]float64{
+		"Secret":  2,
+		"Service": 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ignored events metric failed, want: %v got: %v", want, got)
+	}
+}
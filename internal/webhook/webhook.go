@@ -0,0 +1,204 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook provides a validating admission webhook which rejects
+// IngressRoute and HTTPProxy objects Contour would consider invalid.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	ingressroutev1 "github.com/projectcontour/contour/apis/contour/v1beta1"
+	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/httpsvc"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	ingressRouteKind = schema.GroupKind{Group: ingressroutev1.GroupName, Kind: "IngressRoute"}
+	httpProxyKind    = schema.GroupKind{Group: projcontour.GroupName, Kind: "HTTPProxy"}
+)
+
+// Service serves the /validate endpoint of the admission webhook.
+// Service is a cache.ResourceEventHandler which keeps the Source of
+// its Builder up to date with the objects in the cluster.
+type Service struct {
+	httpsvc.Service
+
+	// Builder validates objects against the objects in its Source.
+	Builder dag.Builder
+
+	// HasSynced reports whether each informer which updates
+	// the Source of Builder has synced. Until they have, the
+	// webhook cannot tell whether objects are valid.
+	HasSynced []cache.InformerSynced
+
+	// mu protects Builder.
+	mu sync.Mutex
+}
+
+// Start fulfills the g.Start contract. Start waits for the informers
+// in HasSynced to sync before serving, so the webhook does not deny
+// objects which refer to objects it has not yet seen.
+// When stop is closed the http server will shutdown.
+func (svc *Service) Start(stop <-chan struct{}) error {
+	svc.HandleFunc("/validate", svc.serveValidate)
+
+	svc.Info("waiting for cache sync")
+	if !cache.WaitForCacheSync(stop, svc.HasSynced...) {
+		// stopped before the informers synced.
+		return nil
+	}
+	return svc.Service.Start(stop)
+}
+
+// synced returns true if every informer in HasSynced has synced.
+func (svc *Service) synced() bool {
+	for _, synced := range svc.HasSynced {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
+func (svc *Service) OnAdd(obj interface{}) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.Builder.Source.Insert(obj)
+}
+
+func (svc *Service) OnUpdate(oldObj, newObj interface{}) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.Builder.Source.Remove(oldObj)
+	svc.Builder.Source.Insert(newObj)
+}
+
+func (svc *Service) OnDelete(obj interface{}) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.Builder.Source.Remove(obj)
+}
+
+// serveValidate decodes an AdmissionReview and responds with the
+// result of Review.
+func (svc *Service) serveValidate(w http.ResponseWriter, r *http.Request) {
+	if !svc.synced() {
+		// an error response lets the API server apply the
+		// webhook's failurePolicy.
+		http.Error(w, "waiting for cache sync", http.StatusServiceUnavailable)
+		return
+	}
+
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "admission review has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = svc.Review(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		svc.WithError(err).Error("failed to write admission response")
+	}
+}
+
+// Review returns the AdmissionResponse for req, rejecting objects the
+// Builder would set invalid, or which would make other objects invalid.
+func (svc *Service) Review(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return allowed()
+	}
+
+	var obj dag.Object
+	switch (schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}) {
+	case ingressRouteKind:
+		obj = new(ingressroutev1.IngressRoute)
+	case httpProxyKind:
+		obj = new(projcontour.HTTPProxy)
+	default:
+		return allowed()
+	}
+	if err := json.Unmarshal(req.Object.Raw, obj); err != nil {
+		return denied(http.StatusBadRequest, err.Error())
+	}
+
+	svc.mu.Lock()
+	status, invalidated := svc.Builder.Validate(obj)
+	svc.mu.Unlock()
+
+	var msgs []string
+	if status.Status == dag.StatusInvalid {
+		msgs = append(msgs, status.Description)
+	}
+	for _, st := range invalidated {
+		m := st.Object.GetObjectMeta()
+		msgs = append(msgs, fmt.Sprintf("%s %s/%s would become invalid: %s", kind(st.Object), m.GetNamespace(), m.GetName(), st.Description))
+	}
+	if len(msgs) > 0 {
+		svc.WithField("namespace", req.Namespace).
+			WithField("name", req.Name).
+			WithField("kind", req.Kind.Kind).
+			Info("denied invalid object")
+		return denied(http.StatusUnprocessableEntity, strings.Join(msgs, "; "))
+	}
+
+	resp := allowed()
+	resp.Warnings = status.Warnings
+	return resp
+}
+
+func allowed() *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: true,
+	}
+}
+
+func denied(code int32, msg string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    code,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: msg,
+		},
+	}
+}
+
+func kind(obj dag.Object) string {
+	switch obj.(type) {
+	case *ingressroutev1.IngressRoute:
+		return ingressRouteKind.Kind
+	case *projcontour.HTTPProxy:
+		return httpProxyKind.Kind
+	default:
+		return fmt.Sprintf("%T", obj)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright © 2019 VMware
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	projcontour "github.com/projectcontour/contour/apis/projectcontour/v1alpha1"
+	"github.com/projectcontour/contour/internal/dag"
+	"github.com/projectcontour/contour/internal/httpsvc"
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestServeValidate(t *testing.T) {
+	s1 := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "default",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	proxy := func(name string, services ...projcontour.Service) *projcontour.HTTPProxy {
+		return &projcontour.HTTPProxy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "projectcontour.io/v1alpha1",
+				Kind:       "HTTPProxy",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: projcontour.HTTPProxySpec{
+				VirtualHost: &projcontour.VirtualHost{
+					Fqdn: "example.com",
+				},
+				Routes: []projcontour.Route{{
+					Services: services,
+				}},
+			},
+		}
+	}
+
+	httpProxy := metav1.GroupVersionKind{Group: "projectcontour.io", Version: "v1alpha1", Kind: "HTTPProxy"}
+
+	tests := map[string]struct {
+		objs      []interface{}
+		operation admissionv1.Operation
+		kind      metav1.GroupVersionKind
+		obj       interface{}
+		want      *admissionv1.AdmissionResponse
+	}{
+		"valid httpproxy": {
+			objs:      []interface{}{s1},
+			operation: admissionv1.Create,
+			kind:      httpProxy,
+			obj:       proxy("example", projcontour.Service{Name: "kuard", Port: 8080}),
+			want: &admissionv1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: true,
+			},
+		},
+		"invalid service port": {
+			objs:      []interface{}{s1},
+			operation: admissionv1.Create,
+			kind:      httpProxy,
+			obj:       proxy("example", projcontour.Service{Name: "kuard", Port: 80800}),
+			want: &admissionv1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: false,
+				Result: &metav1.Status{
+					Status:  metav1.StatusFailure,
+					Code:    http.StatusUnprocessableEntity,
+					Reason:  metav1.StatusReasonInvalid,
+					Message: `route "/": service "kuard": port must be in the range 1-65535`,
+				},
+			},
+		},
+		"update which invalidates another httpproxy": {
+			objs: []interface{}{
+				s1,
+				proxy("example", projcontour.Service{Name: "kuard", Port: 8080}),
+			},
+			operation: admissionv1.Update,
+			kind:      httpProxy,
+			obj:       proxy("other", projcontour.Service{Name: "kuard", Port: 8080}),
+			want: &admissionv1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: false,
+				Result: &metav1.Status{
+					Status:  metav1.StatusFailure,
+					Code:    http.StatusUnprocessableEntity,
+					Reason:  metav1.StatusReasonInvalid,
+					Message: `fqdn "example.com" is used in multiple HTTPProxies: default/example, default/other; HTTPProxy default/example would become invalid: fqdn "example.com" is used in multiple HTTPProxies: default/example, default/other`,
+				},
+			},
+		},
+		"delete": {
+			objs:      []interface{}{s1},
+			operation: admissionv1.Delete,
+			kind:      httpProxy,
+			want: &admissionv1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: true,
+			},
+		},
+		"unknown kind": {
+			operation: admissionv1.Create,
+			kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Service"},
+			obj:       s1,
+			want: &admissionv1.AdmissionResponse{
+				UID:     "uid",
+				Allowed: true,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := Service{
+				Service: httpsvc.Service{
+					FieldLogger: logrus.New(),
+				},
+				Builder: dag.Builder{
+					Source: dag.KubernetesCache{
+						FieldLogger: logrus.New(),
+					},
+				},
+			}
+			for _, o := range tc.objs {
+				svc.OnAdd(o)
+			}
+
+			var raw []byte
+			if tc.obj != nil {
+				var err error
+				raw, err = json.Marshal(tc.obj)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			review := admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "admission.k8s.io/v1",
+					Kind:       "AdmissionReview",
+				},
+				Request: &admissionv1.AdmissionRequest{
+					UID:       types.UID("uid"),
+					Kind:      tc.kind,
+					Namespace: "default",
+					Operation: tc.operation,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			}
+			body, err := json.Marshal(&review)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			svc.serveValidate(w, r)
+
+			resp := w.Result()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+			}
+			data, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got admissionv1.AdmissionReview
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Request != nil {
+				t.Errorf("expected the request to be omitted from the response")
+			}
+			if diff := cmp.Diff(tc.want, got.Response); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestServeValidateBadRequest(t *testing.T) {
+	svc := Service{
+		Service: httpsvc.Service{
+			FieldLogger: logrus.New(),
+		},
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader([]byte(`{}`)))
+	w := httptest.NewRecorder()
+	svc.serveValidate(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestServeValidateNotSynced(t *testing.T) {
+	svc := Service{
+		Service: httpsvc.Service{
+			FieldLogger: logrus.New(),
+		},
+		HasSynced: []cache.InformerSynced{
+			func() bool { return true },
+			func() bool { return false },
+		},
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader([]byte(`{}`)))
+	w := httptest.NewRecorder()
+	svc.serveValidate(w, r)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestStartWaitsForCacheSync(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+
+	svc := Service{
+		Service: httpsvc.Service{
+			Addr:        "127.0.0.1",
+			Port:        -1, // an invalid port, so the server fails if started.
+			FieldLogger: log,
+		},
+		HasSynced: []cache.InformerSynced{
+			func() bool { return false },
+		},
+	}
+
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- svc.Start(stop)
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("expected Start to wait for the cache to sync, returned %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("expected Start to return nil when stopped before the cache syncs, got %v", err)
+	}
+}
@@ -15,4 +15,6 @@ import (
 	_ "k8s.io/code-generator/cmd/defaulter-gen"
 	_ "k8s.io/code-generator/cmd/informer-gen"
 	_ "k8s.io/code-generator/cmd/lister-gen"
+
+	_ "sigs.k8s.io/controller-tools/cmd/controller-gen"
 )